@@ -1,6 +1,9 @@
 package seth
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strings"
 )
 
@@ -28,13 +31,252 @@ func (c *CompiledContract) Find(desc string) *ABIDescriptor {
 }
 
 // Signature returns the canonical function/event signature.
+// Tuple arguments are expanded into their member types.
 // For functions, the first 4 bytes of the hash of the
 // signature is the function selector, and for events,
 // the hash of the signature is the first topic in the log.
 func (d *ABIDescriptor) Signature() string {
 	var args []string
 	for i := range d.Inputs {
-		args = append(args, d.Inputs[i].Type)
+		args = append(args, d.Inputs[i].canonicalType())
 	}
 	return d.Name + "(" + strings.Join(args, ",") + ")"
 }
+
+// ABI is a contract ABI indexed for
+// looking up functions by name, signature,
+// or selector, events by topic, and custom
+// errors by selector.
+type ABI struct {
+	// Descriptors is the complete list of descriptors
+	// in the ABI. It should not be modified.
+	Descriptors []ABIDescriptor
+
+	bysig  map[string]*ABIDescriptor
+	bysel  map[[4]byte]*ABIDescriptor
+	byname map[string][]*ABIDescriptor
+	events map[Hash]*ABIDescriptor
+	anon   []*ABIDescriptor
+	errors map[[4]byte]*ABIDescriptor
+}
+
+// NewABI constructs an ABI from a list of descriptors.
+// An error is returned if any of the descriptors
+// contains an invalid type.
+func NewABI(desc []ABIDescriptor) (*ABI, error) {
+	a := &ABI{
+		Descriptors: desc,
+		bysig:       make(map[string]*ABIDescriptor),
+		bysel:       make(map[[4]byte]*ABIDescriptor),
+		byname:      make(map[string][]*ABIDescriptor),
+		events:      make(map[Hash]*ABIDescriptor),
+		errors:      make(map[[4]byte]*ABIDescriptor),
+	}
+	for i := range desc {
+		d := &desc[i]
+		if _, err := paramsType(d.Inputs); err != nil {
+			return nil, fmt.Errorf("%s: %s", d.Name, err)
+		}
+		if _, err := paramsType(d.Outputs); err != nil {
+			return nil, fmt.Errorf("%s: %s", d.Name, err)
+		}
+		if d.Type == "event" {
+			if d.Anonymous {
+				a.anon = append(a.anon, d)
+			} else {
+				a.events[d.Topic()] = d
+			}
+			continue
+		}
+		if d.Type == "error" {
+			a.errors[d.Selector()] = d
+			continue
+		}
+		if d.Type != "function" && d.Type != "" {
+			continue
+		}
+		sig := d.Signature()
+		if a.bysig[sig] != nil {
+			return nil, fmt.Errorf("duplicate function %s", sig)
+		}
+		a.bysig[sig] = d
+		a.bysel[d.Selector()] = d
+		a.byname[d.Name] = append(a.byname[d.Name], d)
+	}
+	return a, nil
+}
+
+// ParseABI parses a JSON contract ABI. The ABI may either
+// be a plain JSON array of descriptors (as produced by solc),
+// an object with an "abi" field holding the array (as in
+// solc standard-json output and most build artifacts), or
+// an etherscan API response whose "result" is the array
+// encoded as a string.
+func ParseABI(buf []byte) (*ABI, error) {
+	var desc []ABIDescriptor
+	buf = bytes.TrimSpace(buf)
+	if len(buf) > 0 && buf[0] == '{' {
+		var obj struct {
+			ABI    json.RawMessage `json:"abi"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(buf, &obj); err != nil {
+			return nil, err
+		}
+		switch {
+		case obj.ABI != nil:
+			buf = obj.ABI
+		case obj.Result != nil:
+			var s string
+			if err := json.Unmarshal(obj.Result, &s); err != nil {
+				return nil, fmt.Errorf("etherscan result is not a string: %s", err)
+			}
+			buf = []byte(s)
+		default:
+			return nil, fmt.Errorf("JSON object has no ABI")
+		}
+	}
+	if err := json.Unmarshal(buf, &desc); err != nil {
+		return nil, err
+	}
+	return NewABI(desc)
+}
+
+// MustParseABI is like ParseABI, but panics on error.
+func MustParseABI(s string) *ABI {
+	a, err := ParseABI([]byte(s))
+	if err != nil {
+		panic("seth: MustParseABI: " + err.Error())
+	}
+	return a
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (a *ABI) UnmarshalJSON(b []byte) error {
+	na, err := ParseABI(b)
+	if err != nil {
+		return err
+	}
+	*a = *na
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (a *ABI) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Descriptors)
+}
+
+// BySelector returns the function with the given
+// selector, or nil if there is no such function.
+func (a *ABI) BySelector(sel [4]byte) *ABIDescriptor {
+	return a.bysel[sel]
+}
+
+// Overloads returns all of the functions with the given name.
+func (a *ABI) Overloads(name string) []*ABIDescriptor {
+	return a.byname[name]
+}
+
+// Method finds a function by its signature or by its name.
+// Signatures need not be in canonical form. If a name is
+// given and the function is overloaded, an error is returned.
+func (a *ABI) Method(method string) (*ABIDescriptor, error) {
+	if strings.IndexByte(method, '(') != -1 {
+		name, t, err := parseSignature(strings.Join(strings.Fields(method), ""))
+		if err != nil {
+			return nil, err
+		}
+		if d := a.bysig[name+t.String()]; d != nil {
+			return d, nil
+		}
+		return nil, fmt.Errorf("no function %s", method)
+	}
+	switch funcs := a.byname[method]; len(funcs) {
+	case 0:
+		return nil, fmt.Errorf("no function %q", method)
+	case 1:
+		return funcs[0], nil
+	default:
+		return nil, fmt.Errorf("function %q is overloaded (%s)", method, signatures(funcs))
+	}
+}
+
+// Resolve finds the function that should be called when
+// method is called with the given arguments. If method is
+// the name of an overloaded function, the overload is chosen
+// by the number of arguments and whether or not the arguments
+// can be encoded as the parameters of the function.
+func (a *ABI) Resolve(method string, args ...interface{}) (*ABIDescriptor, error) {
+	if strings.IndexByte(method, '(') != -1 {
+		return a.Method(method)
+	}
+	funcs := a.byname[method]
+	if len(funcs) == 0 {
+		return nil, fmt.Errorf("no function %q", method)
+	} else if len(funcs) == 1 {
+		return funcs[0], nil
+	}
+	var match []*ABIDescriptor
+	for _, d := range funcs {
+		if len(d.Inputs) != len(args) {
+			continue
+		}
+		if _, err := EncodeParams(d.Inputs, args...); err == nil {
+			match = append(match, d)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return nil, fmt.Errorf("no overload of %q (%s) accepts the arguments", method, signatures(funcs))
+	case 1:
+		return match[0], nil
+	default:
+		return nil, fmt.Errorf("call to %q is ambiguous (%s)", method, signatures(match))
+	}
+}
+
+func signatures(funcs []*ABIDescriptor) string {
+	s := make([]string, len(funcs))
+	for i := range funcs {
+		s[i] = funcs[i].Signature()
+	}
+	return strings.Join(s, ", ")
+}
+
+// Pack encodes a call to method with the given arguments,
+// including the function selector. See EncodeParams for
+// the Go types that may be used for arguments, and Resolve
+// for the way that method is matched to a function.
+func (a *ABI) Pack(method string, args ...interface{}) ([]byte, error) {
+	d, err := a.Resolve(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d.Pack(args...)
+}
+
+// Unpack decodes the return value of method from
+// output into dst. See DecodeParams for the rules
+// for decoding into dst.
+func (a *ABI) Unpack(method string, output []byte, dst ...interface{}) error {
+	d, err := a.Method(method)
+	if err != nil {
+		return err
+	}
+	return DecodeParams(d.Outputs, output, dst...)
+}
+
+// Pack encodes a call to the function described by
+// d with the given arguments, including the selector.
+func (d *ABIDescriptor) Pack(args ...interface{}) ([]byte, error) {
+	t, err := paramsType(d.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	sel := d.Selector()
+	out, err := encodeArgs(sel[:], t, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", d.Signature(), err)
+	}
+	return out, nil
+}
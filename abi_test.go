@@ -0,0 +1,134 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+const testABI = `[
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"set","inputs":[{"name":"v","type":"uint8"}],"outputs":[]},
+	{"type":"function","name":"set","inputs":[{"name":"v","type":"string"}],"outputs":[]},
+	{"type":"function","name":"balanceOf","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"balance","type":"uint"}]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}],"anonymous":false},
+	{"type":"constructor","inputs":[]}
+]`
+
+func TestParseABI(t *testing.T) {
+	t.Parallel()
+	etherscan, _ := json.Marshal(map[string]string{"status": "1", "message": "OK", "result": testABI})
+	forms := []string{
+		testABI,
+		`{"contractName":"Token","abi":` + testABI + `}`,
+		string(etherscan),
+	}
+	for i := range forms {
+		a, err := ParseABI([]byte(forms[i]))
+		if err != nil {
+			t.Fatalf("form %d: %s", i, err)
+		}
+		if len(a.Descriptors) != 7 {
+			t.Errorf("form %d: got %d descriptors", i, len(a.Descriptors))
+		}
+	}
+	if _, err := ParseABI([]byte(`[{"type":"function","name":"f","inputs":[{"type":"uint7"}]}]`)); err == nil {
+		t.Error("expected an error for a bad type")
+	}
+}
+
+func TestABIMethod(t *testing.T) {
+	t.Parallel()
+	a := MustParseABI(testABI)
+
+	d, err := a.Method("balanceOf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sel := d.Selector(); a.BySelector(sel) != d {
+		t.Errorf("selector %x lookup failed", sel)
+	}
+	if _, err := a.Method("transfer"); err == nil {
+		t.Error("expected an error for an overloaded name")
+	}
+	if d, err := a.Method("transfer(address, uint)"); err != nil || len(d.Inputs) != 2 {
+		t.Errorf("non-canonical signature: %v %v", d, err)
+	}
+	if _, err := a.Method("Transfer"); err == nil {
+		t.Error("events should not be found as methods")
+	}
+	var to Address
+	if d, err := a.Resolve("transfer", &to, 1, []byte("hi")); err != nil || len(d.Inputs) != 3 {
+		t.Errorf("resolve by argument count: %v %v", d, err)
+	}
+	if d, err := a.Resolve("set", "hello"); err != nil || d.Inputs[0].Type != "string" {
+		t.Errorf("resolve by argument type: %v %v", d, err)
+	}
+	if _, err := a.Resolve("set", true); err == nil {
+		t.Error("expected an error when no overload accepts the arguments")
+	}
+}
+
+func TestFunctionType(t *testing.T) {
+	t.Parallel()
+	a := MustParseABI(`[{"type":"function","name":"call","inputs":[{"name":"cb","type":"function"},{"name":"x","type":"uint256"}],"outputs":[]}]`)
+	d, err := a.Method("call(function,uint256)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := d.Signature(); sig != "call(function,uint256)" {
+		t.Errorf("signature %q", sig)
+	}
+	h := HashString("call(function,uint256)")
+	if sel := d.Selector(); !bytes.Equal(sel[:], h[:4]) {
+		t.Errorf("selector %x; want %x", sel, h[:4])
+	}
+
+	// function values are encoded like bytes24
+	fn := bytes.Repeat([]byte{0xab}, 24)
+	buf, err := d.Pack(fn, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf[4:28], fn) || !bytes.Equal(buf[28:36], make([]byte, 8)) {
+		t.Errorf("packed %x", buf)
+	}
+	var out Data
+	var x big.Int
+	if err := DecodeParams(d.Inputs, buf[4:], &out, &x); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, fn) || x.Int64() != 1 {
+		t.Errorf("decoded %x and %s", out, &x)
+	}
+}
+
+func TestABIPackUnpack(t *testing.T) {
+	t.Parallel()
+	a := MustParseABI(testABI)
+	var to Address
+	to[19] = 1
+
+	got, err := a.Pack("transfer", &to, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ABIEncode("transfer(address,uint256)", &to, NewInt(10))
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+
+	var out struct{ Balance uint64 }
+	if err := a.Unpack("balanceOf", words(t, "", word("2a")), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Balance != 42 {
+		t.Errorf("got balance %d", out.Balance)
+	}
+	var ok bool
+	if err := a.Unpack("transfer(address,uint256)", words(t, "", word("1")), &ok); err != nil || !ok {
+		t.Errorf("unpacking bool: %v %v", ok, err)
+	}
+}
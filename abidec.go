@@ -0,0 +1,431 @@
+package seth
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// An ABIError is returned when ABI-encoded data is malformed
+// or cannot be decoded into the destination value.
+type ABIError struct {
+	Offset int    // byte offset into the encoded data
+	Path   string // argument being decoded, e.g. "arg1[2].amount"
+	Msg    string // description of the error
+}
+
+func (e *ABIError) Error() string {
+	return fmt.Sprintf("abi: %s (offset %d): %s", e.Path, e.Offset, e.Msg)
+}
+
+// DecodeParams decodes ABI-encoded data as the tuple of
+// parameters described by params. Each element of dst should
+// be a pointer to a value that can hold the corresponding
+// parameter. Alternatively, a single pointer to a struct, map,
+// or slice may be provided, in which case the whole tuple of
+// parameters is decoded into it. (If there is only one parameter,
+// this only happens for structs, and only if the parameter is not
+// itself a tuple.)
+//
+// Integers may be decoded into any Go integer type (as long as
+// the value fits), big.Int, or Int. Addresses may be decoded into
+// an Address, and byte strings may be decoded into byte slices,
+// byte arrays, or strings. Tuples may be decoded into structs
+// (fields are matched to members as in EncodeParams), maps from
+// strings to values, or []interface{}. Slices and arrays may be
+// decoded into Go slices or arrays. Destinations that are nil
+// pointers are allocated as necessary.
+//
+// Decoding into an interface{} produces *big.Int for integers,
+// Address for addresses, bool, Data for fixed-size byte strings,
+// Bytes for dynamic byte strings, string, []interface{} for
+// arrays, and map[string]interface{} for tuples whose members
+// are all named (otherwise []interface{}).
+func DecodeParams(params []ABIParam, data []byte, dst ...interface{}) error {
+	t, err := paramsType(params)
+	if err != nil {
+		return err
+	}
+	return decodeArgs(data, t, dst)
+}
+
+// decodeArgs decodes data as the tuple t into dst
+func decodeArgs(data []byte, t *abiType, dst []interface{}) error {
+	d := abiDecoder{data: data}
+	if len(dst) == 1 && wholeTuple(t, dst[0]) {
+		return d.decode(t, 0, reflect.ValueOf(dst[0]), "args")
+	}
+	if len(dst) != len(t.elems) {
+		return fmt.Errorf("mismatched argument lists: %d args vs %d given", len(t.elems), len(dst))
+	}
+	vals := make([]reflect.Value, len(dst))
+	for i := range dst {
+		vals[i] = reflect.ValueOf(dst[i])
+		if vals[i].Kind() != reflect.Ptr || vals[i].IsNil() {
+			return fmt.Errorf("arg %d: cannot decode into non-pointer %T", i, dst[i])
+		}
+	}
+	return d.decodeSeq(t.elems, 0, vals, func(i int) string {
+		return "arg" + strconv.Itoa(i)
+	})
+}
+
+// wholeTuple returns whether or not dst should
+// hold the whole tuple t rather than its only member
+func wholeTuple(t *abiType, dst interface{}) bool {
+	if len(t.elems) != 1 {
+		return true
+	}
+	rt := reflect.TypeOf(dst)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt != nil && rt.Kind() == reflect.Struct &&
+		rt != bigType && rt != intType && t.elems[0].kind != abiTuple
+}
+
+type abiDecoder struct {
+	data []byte
+}
+
+func (d *abiDecoder) errorf(off int, path, f string, args ...interface{}) error {
+	return &ABIError{Offset: off, Path: path, Msg: fmt.Sprintf(f, args...)}
+}
+
+// word returns the 32-byte word at off
+func (d *abiDecoder) word(off int, path string) ([]byte, error) {
+	if off < 0 || off+32 > len(d.data) {
+		return nil, d.errorf(off, path, "data too short (%d bytes)", len(d.data))
+	}
+	return d.data[off : off+32], nil
+}
+
+// uint reads the word at off as an integer no greater than
+// the length of the data, which is true of all valid lengths
+// and offsets
+func (d *abiDecoder) uint(off int, path, what string) (int, error) {
+	w, err := d.word(off, path)
+	if err != nil {
+		return 0, err
+	}
+	var n big.Int
+	n.SetBytes(w)
+	if !n.IsInt64() || n.Int64() > int64(len(d.data)) {
+		return 0, d.errorf(off, path, "bad %s %s for data length %d", what, &n, len(d.data))
+	}
+	return int(n.Int64()), nil
+}
+
+// decodeSeq decodes a sequence of values that
+// begins at base using head/tail encoding
+func (d *abiDecoder) decodeSeq(types []*abiType, base int, vals []reflect.Value, path func(int) string) error {
+	hp := base
+	for i, t := range types {
+		pos := hp
+		if t.dynamic() {
+			o, err := d.uint(hp, path(i), "offset")
+			if err != nil {
+				return err
+			}
+			pos = base + o
+		}
+		if err := d.decode(t, pos, vals[i], path(i)); err != nil {
+			return err
+		}
+		hp += t.headsize()
+	}
+	return nil
+}
+
+// deref follows (and allocates, if necessary)
+// pointers until it reaches a non-pointer value
+func deref(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// decode decodes the value of type t whose encoding starts at off into v
+func (d *abiDecoder) decode(t *abiType, off int, v reflect.Value, path string) error {
+	if v.Kind() == reflect.Ptr && v.IsNil() && !v.CanSet() {
+		return d.errorf(off, path, "cannot decode into nil %s", v.Type())
+	}
+	v = deref(v)
+	if v.Kind() == reflect.Interface {
+		if !v.IsNil() && v.Elem().Kind() == reflect.Ptr && !v.Elem().IsNil() {
+			return d.decode(t, off, v.Elem(), path)
+		}
+		if v.NumMethod() != 0 {
+			return d.errorf(off, path, "cannot decode %s into %s", t, v.Type())
+		}
+		nv, err := d.natural(t, off, path)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(nv))
+		return nil
+	}
+	if !v.CanSet() {
+		return d.errorf(off, path, "cannot decode into unaddressable %s", v.Type())
+	}
+	mismatch := func() error {
+		return d.errorf(off, path, "cannot decode %s into %s", t, v.Type())
+	}
+
+	switch t.kind {
+	case abiUint, abiInt:
+		w, err := d.word(off, path)
+		if err != nil {
+			return err
+		}
+		n := new(big.Int).SetBytes(w)
+		if t.kind == abiInt && w[0]&0x80 != 0 {
+			n.Sub(n, tt256)
+		}
+		if t.checkInt(n) != nil {
+			return d.errorf(off, path, "value %s out of range for %s", n, t)
+		}
+		return d.setInt(v, n, off, path, mismatch)
+	case abiAddress:
+		w, err := d.word(off, path)
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.Array || v.Len() != 20 || v.Type().Elem().Kind() != reflect.Uint8 {
+			return mismatch()
+		}
+		reflect.Copy(v, reflect.ValueOf(w[12:]))
+		return nil
+	case abiBool:
+		w, err := d.word(off, path)
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.Bool {
+			return mismatch()
+		}
+		v.SetBool(new(big.Int).SetBytes(w).Sign() != 0)
+		return nil
+	case abiFixedBytes, abiFunction:
+		w, err := d.word(off, path)
+		if err != nil {
+			return err
+		}
+		buf := make([]byte, t.size)
+		copy(buf, w)
+		return d.setBytes(v, buf, mismatch)
+	case abiBytes, abiString:
+		n, err := d.uint(off, path, "length")
+		if err != nil {
+			return err
+		}
+		start := off + 32
+		if start+n > len(d.data) {
+			return d.errorf(off, path, "bad length %d for data length %d", n, len(d.data))
+		}
+		buf := make([]byte, n)
+		copy(buf, d.data[start:])
+		return d.setBytes(v, buf, mismatch)
+	case abiSlice, abiArray:
+		n, base := t.size, off
+		if t.kind == abiSlice {
+			var err error
+			n, err = d.uint(off, path, "length")
+			if err != nil {
+				return err
+			}
+			base += 32
+			// don't allocate anything that the data
+			// can't possibly hold
+			if base+n*t.elem.headsize() > len(d.data) {
+				return d.errorf(off, path, "bad length %d for data length %d", n, len(d.data))
+			}
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+		case reflect.Array:
+			if v.Len() != n {
+				return d.errorf(off, path, "cannot decode %d elements into %s", n, v.Type())
+			}
+		default:
+			return mismatch()
+		}
+		types := make([]*abiType, n)
+		vals := make([]reflect.Value, n)
+		for i := range vals {
+			types[i] = t.elem
+			vals[i] = v.Index(i)
+		}
+		return d.decodeSeq(types, base, vals, func(i int) string {
+			return path + "[" + strconv.Itoa(i) + "]"
+		})
+	case abiTuple:
+		return d.decodeTuple(t, off, v, path, mismatch)
+	}
+	return mismatch()
+}
+
+func (d *abiDecoder) decodeTuple(t *abiType, off int, v reflect.Value, path string, mismatch func() error) error {
+	vals, done, err := d.members(t, off, v, path, mismatch)
+	if err != nil {
+		return err
+	}
+	err = d.decodeSeq(t.elems, off, vals, func(i int) string {
+		if t.names[i] != "" {
+			return path + "." + t.names[i]
+		}
+		return path + "." + strconv.Itoa(i)
+	})
+	if err != nil {
+		return err
+	}
+	done()
+	return nil
+}
+
+// members returns settable values for each of the members
+// of the tuple t in v, and a function that must be called
+// once the values have been filled in
+func (d *abiDecoder) members(t *abiType, off int, v reflect.Value, path string, mismatch func() error) ([]reflect.Value, func(), error) {
+	vals := make([]reflect.Value, len(t.elems))
+	done := func() {}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == bigType || v.Type() == intType {
+			return nil, nil, mismatch()
+		}
+		fields, err := tupleFields(v.Type(), t.names)
+		if err != nil {
+			return nil, nil, d.errorf(off, path, "%s", err)
+		}
+		for i := range vals {
+			vals[i] = v.Field(fields[i])
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), len(vals), len(vals)))
+		fallthrough
+	case reflect.Array:
+		if v.Len() != len(vals) {
+			return nil, nil, mismatch()
+		}
+		for i := range vals {
+			vals[i] = v.Index(i)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, nil, mismatch()
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for i := range vals {
+			vals[i] = reflect.New(v.Type().Elem()).Elem()
+		}
+		done = func() {
+			for i := range vals {
+				v.SetMapIndex(reflect.ValueOf(t.names[i]).Convert(v.Type().Key()), vals[i])
+			}
+		}
+	default:
+		return nil, nil, mismatch()
+	}
+	return vals, done, nil
+}
+
+// setInt stores n into v
+func (d *abiDecoder) setInt(v reflect.Value, n *big.Int, off int, path string, mismatch func() error) error {
+	overflow := func() error {
+		return d.errorf(off, path, "value %s overflows %s", n, v.Type())
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !n.IsInt64() || v.OverflowInt(n.Int64()) {
+			return overflow()
+		}
+		v.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+			return overflow()
+		}
+		v.SetUint(n.Uint64())
+	case reflect.Struct:
+		switch v.Type() {
+		case bigType:
+			v.Addr().Interface().(*big.Int).Set(n)
+		case intType:
+			v.Addr().Interface().(*Int).Big().Set(n)
+		default:
+			return mismatch()
+		}
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// setBytes stores b into v
+func (d *abiDecoder) setBytes(v reflect.Value, b []byte, mismatch func() error) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return mismatch()
+		}
+		v.SetBytes(b)
+	case reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Uint8 || v.Len() != len(b) {
+			return mismatch()
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// natural decodes a value of type t into its
+// natural Go representation (see DecodeParams)
+func (d *abiDecoder) natural(t *abiType, off int, path string) (interface{}, error) {
+	var v reflect.Value
+	switch t.kind {
+	case abiUint, abiInt:
+		v = reflect.New(reflect.TypeOf((*big.Int)(nil)))
+	case abiAddress:
+		v = reflect.New(addressType)
+	case abiBool:
+		v = reflect.New(reflect.TypeOf(false))
+	case abiFixedBytes, abiFunction:
+		v = reflect.New(reflect.TypeOf(Data(nil)))
+	case abiBytes:
+		v = reflect.New(reflect.TypeOf(Bytes(nil)))
+	case abiString:
+		v = reflect.New(reflect.TypeOf(""))
+	case abiSlice, abiArray:
+		v = reflect.New(reflect.TypeOf([]interface{}(nil)))
+	case abiTuple:
+		named := true
+		for i := range t.names {
+			if t.names[i] == "" {
+				named = false
+			}
+		}
+		if named {
+			v = reflect.New(reflect.TypeOf(map[string]interface{}(nil)))
+		} else {
+			v = reflect.New(reflect.TypeOf([]interface{}(nil)))
+		}
+	default:
+		return nil, d.errorf(off, path, "cannot decode %s", t)
+	}
+	if err := d.decode(t, off, v, path); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,177 @@
+package seth
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeParams(t *testing.T) {
+	t.Parallel()
+	type item struct {
+		Amount *big.Int
+		Memo   string `abi:"note"`
+	}
+	type pair struct {
+		A int8
+		B [2][2]byte
+	}
+	params := []ABIParam{
+		{Name: "to", Type: "address"},
+		{Name: "items", Type: "tuple[]", Components: []ABIParam{
+			{Name: "amount", Type: "uint256"},
+			{Name: "note", Type: "string"},
+		}},
+		{Name: "pair", Type: "tuple", Components: []ABIParam{
+			{Name: "a", Type: "int8"},
+			{Name: "b", Type: "bytes2[2]"},
+		}},
+		{Name: "nums", Type: "uint16[][]"},
+	}
+	var to Address
+	to[19] = 0xaa
+	items := []item{{big.NewInt(1), "ab"}, {big.NewInt(300), ""}}
+	p := pair{A: -3, B: [2][2]byte{{1, 2}, {3, 4}}}
+	nums := [][]uint16{{1, 2}, {}, {3}}
+
+	buf, err := EncodeParams(params, &to, items, p, nums)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		gotTo    Address
+		gotItems []item
+		gotPair  *pair
+		gotNums  [][]uint16
+	)
+	if err := DecodeParams(params, buf, &gotTo, &gotItems, &gotPair, &gotNums); err != nil {
+		t.Fatal(err)
+	}
+	if gotTo != to {
+		t.Errorf("to: got %s", &gotTo)
+	}
+	if !reflect.DeepEqual(gotItems, items) {
+		t.Errorf("items: got %+v", gotItems)
+	}
+	if gotPair == nil || *gotPair != p {
+		t.Errorf("pair: got %+v", gotPair)
+	}
+	if !reflect.DeepEqual(gotNums, nums) {
+		t.Errorf("nums: got %v", gotNums)
+	}
+
+	// decode the whole tuple into a single struct
+	var all struct {
+		To    Address
+		Items []item
+		Pair  pair
+		Nums  [][]uint16
+	}
+	if err := DecodeParams(params, buf, &all); err != nil {
+		t.Fatal(err)
+	}
+	if all.To != to || all.Pair != p || !reflect.DeepEqual(all.Items, items) {
+		t.Errorf("got %+v", all)
+	}
+
+	// ... or into a map of natural values
+	var m map[string]interface{}
+	if err := DecodeParams(params, buf, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["to"] != to {
+		t.Errorf("to: got %v", m["to"])
+	}
+	it := m["items"].([]interface{})[1].(map[string]interface{})
+	if it["amount"].(*big.Int).Int64() != 300 {
+		t.Errorf("items[1].amount: got %v", it["amount"])
+	}
+	if n := m["pair"].(map[string]interface{})["a"].(*big.Int); n.Int64() != -3 {
+		t.Errorf("pair.a: got %v", n)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		typ  string
+		data []byte
+		dst  interface{}
+		path string
+	}{
+		// offset points past the end of the data
+		{"string", words(t, "", word("1000")), new(string), "arg0"},
+		// huge length
+		{"uint256[]", words(t, "", word("20"), strings.Repeat("f", 64)), new([]int), "arg0"},
+		// length runs past the end of the data
+		{"bytes", words(t, "", word("20"), word("40"), word("1")), new([]byte), "arg0"},
+		// truncated nested slice
+		{"uint8[][]", words(t, "", word("20"), word("1"), word("20"), word("2"), word("1")), new([][]uint8), "arg0[0]"},
+		// value out of range for the type
+		{"uint8", words(t, "", word("100")), new(uint64), "arg0"},
+		// value out of range for the destination
+		{"uint16", words(t, "", word("100")), new(uint8), "arg0"},
+		// wrong destination type
+		{"address", words(t, "", word("1")), new(string), "arg0"},
+	}
+	for _, c := range cases {
+		err := DecodeParams([]ABIParam{{Type: c.typ}}, c.data, c.dst)
+		ae, ok := err.(*ABIError)
+		if !ok {
+			t.Errorf("%s: expected *ABIError; got %v", c.typ, err)
+			continue
+		}
+		if ae.Path != c.path {
+			t.Errorf("%s: got path %q; want %q", c.typ, ae.Path, c.path)
+		}
+	}
+}
+
+func TestDecodeABI(t *testing.T) {
+	t.Parallel()
+	var a Address
+	a[0], a[19] = 0x11, 0x22
+	d := make(Data, 32)
+	d[0], d[31] = 0x33, 0x44
+	buf, err := EncodeParams([]ABIParam{
+		{Type: "int256"}, {Type: "address"}, {Type: "bytes32"}, {Type: "bytes32[]"}, {Type: "address[]"},
+	}, -2, &a, d, []Data{d, d}, []Address{a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		n     int
+		addr  Address
+		data  Data
+		slice DataSlice
+		addrs AddrSlice
+	)
+	if err := DecodeABI(buf, &n, &addr, &data, &slice, &addrs); err != nil {
+		t.Fatal(err)
+	}
+	if n != -2 {
+		t.Errorf("got int %d", n)
+	}
+	if addr != a {
+		t.Errorf("got address %s", &addr)
+	}
+	if !reflect.DeepEqual(data, d) {
+		t.Errorf("got data %s", data)
+	}
+	if len(slice) != 2 || !reflect.DeepEqual(slice[1], d) {
+		t.Errorf("got data slice %v", slice)
+	}
+	if len(addrs) != 1 || addrs[0] != a {
+		t.Errorf("got address slice %v", addrs)
+	}
+
+	// decoded values don't alias the input
+	for i := range buf {
+		buf[i] = 0
+	}
+	if !reflect.DeepEqual(data, d) || !reflect.DeepEqual(slice[0], d) {
+		t.Errorf("decoded data changed with the input: %s %v", data, slice)
+	}
+}
@@ -0,0 +1,302 @@
+package seth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// EncodeParams ABI-encodes args as the tuple of parameters
+// described by params. Tuple-typed parameters may be provided
+// as structs, as maps from member name to value, or as a
+// []interface{} holding the members in order. Struct fields
+// are matched to tuple members by an `abi:"name"` tag, by
+// name, or failing that, by position. Integers may be any Go
+// integer type, *big.Int, or *Int; byte strings may be []byte,
+// Data, Bytes, or byte arrays; slices and arrays may be any
+// Go slice or array whose elements can be encoded as the
+// element type.
+//
+// Any of the EtherType implementations in this package
+// are also accepted wherever they make sense.
+func EncodeParams(params []ABIParam, args ...interface{}) ([]byte, error) {
+	t, err := paramsType(params)
+	if err != nil {
+		return nil, err
+	}
+	return encodeArgs(nil, t, args)
+}
+
+// Selector returns the 4-byte function selector of a function.
+func (d *ABIDescriptor) Selector() [4]byte {
+	var sel [4]byte
+	h := HashString(d.Signature())
+	copy(sel[:], h[:4])
+	return sel
+}
+
+// encodeArgs appends the encoding of args as the tuple t to dst
+func encodeArgs(dst []byte, t *abiType, args []interface{}) ([]byte, error) {
+	if len(args) != len(t.elems) {
+		return nil, fmt.Errorf("mismatched argument lists: %d args vs %d given", len(t.elems), len(args))
+	}
+	vals := make([]reflect.Value, len(args))
+	for i := range args {
+		vals[i] = reflect.ValueOf(args[i])
+	}
+	return encodeSeq(dst, t.elems, vals, "arg ")
+}
+
+// encodeSeq encodes a sequence of values with head/tail encoding
+func encodeSeq(dst []byte, types []*abiType, vals []reflect.Value, what string) ([]byte, error) {
+	hsize := 0
+	for i := range types {
+		hsize += types[i].headsize()
+	}
+	head := make([]byte, 0, hsize)
+	var tail []byte
+	var err error
+	for i, t := range types {
+		if t.dynamic() {
+			head = appendUint(head, uint64(hsize+len(tail)))
+			tail, err = t.encode(tail, vals[i])
+		} else {
+			head, err = t.encode(head, vals[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s%d: %s", what, i, err)
+		}
+	}
+	dst = append(dst, head...)
+	return append(dst, tail...), nil
+}
+
+// appendUint appends a uint as a 32-byte word
+func appendUint(dst []byte, u uint64) []byte {
+	var w [32]byte
+	binary.BigEndian.PutUint64(w[24:], u)
+	return append(dst, w[:]...)
+}
+
+// indirect dereferences pointers and interfaces
+func indirect(v reflect.Value) (reflect.Value, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, fmt.Errorf("nil %s", v.Type())
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return v, fmt.Errorf("nil value")
+	}
+	return v, nil
+}
+
+// encode appends the encoding of v to dst. For dynamic
+// types, this is the tail portion of the encoding.
+func (t *abiType) encode(dst []byte, v reflect.Value) ([]byte, error) {
+	v, err := indirect(v)
+	if err != nil {
+		return nil, err
+	}
+	switch t.kind {
+	case abiUint, abiInt:
+		n, ok := bigOf(v)
+		if !ok {
+			return nil, fmt.Errorf("cannot use %s as %s", v.Type(), t)
+		}
+		if err := t.checkInt(n); err != nil {
+			return nil, err
+		}
+		return appendInt(dst, n), nil
+	case abiAddress:
+		if v.Type() != addressType && !(v.Kind() == reflect.Array && v.Len() == 20 && v.Type().Elem().Kind() == reflect.Uint8) {
+			return nil, fmt.Errorf("cannot use %s as address", v.Type())
+		}
+		var w [32]byte
+		b, _ := bytesOf(v)
+		copy(w[12:], b)
+		return append(dst, w[:]...), nil
+	case abiBool:
+		var w [32]byte
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				w[31] = 1
+			}
+			return append(dst, w[:]...), nil
+		}
+		n, ok := bigOf(v)
+		if !ok || n.Sign() < 0 || n.Cmp(big.NewInt(1)) > 0 {
+			return nil, fmt.Errorf("cannot use %s as bool", v.Type())
+		}
+		w[31] = byte(n.Uint64())
+		return append(dst, w[:]...), nil
+	case abiFixedBytes, abiFunction:
+		var w [32]byte
+		if b, ok := bytesOf(v); ok && v.Kind() != reflect.String {
+			if len(b) > t.size {
+				return nil, fmt.Errorf("%d bytes overflows %s", len(b), t)
+			}
+			copy(w[:], b)
+			return append(dst, w[:]...), nil
+		}
+		n, ok := bigOf(v)
+		if !ok {
+			return nil, fmt.Errorf("cannot use %s as %s", v.Type(), t)
+		}
+		if n.Sign() < 0 || n.BitLen() > 8*t.size {
+			return nil, fmt.Errorf("value %s overflows %s", n, t)
+		}
+		b := n.Bytes()
+		copy(w[t.size-len(b):], b)
+		return append(dst, w[:]...), nil
+	case abiBytes, abiString:
+		b, ok := bytesOf(v)
+		if !ok {
+			return nil, fmt.Errorf("cannot use %s as %s", v.Type(), t)
+		}
+		dst = appendUint(dst, uint64(len(b)))
+		return append(dst, padright(b)...), nil
+	case abiSlice, abiArray:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("cannot use %s as %s", v.Type(), t)
+		}
+		n := v.Len()
+		if t.kind == abiArray {
+			if n != t.size {
+				return nil, fmt.Errorf("cannot use %d elements as %s", n, t)
+			}
+		} else {
+			dst = appendUint(dst, uint64(n))
+		}
+		types := make([]*abiType, n)
+		vals := make([]reflect.Value, n)
+		for i := range vals {
+			types[i] = t.elem
+			vals[i] = v.Index(i)
+		}
+		return encodeSeq(dst, types, vals, "element ")
+	case abiTuple:
+		vals, err := t.members(v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeSeq(dst, t.elems, vals, "member ")
+	}
+	return nil, fmt.Errorf("cannot encode %s", t)
+}
+
+// members returns the values of the tuple members in v
+func (t *abiType) members(v reflect.Value) ([]reflect.Value, error) {
+	vals := make([]reflect.Value, len(t.elems))
+	switch v.Kind() {
+	case reflect.Struct:
+		fields, err := tupleFields(v.Type(), t.names)
+		if err != nil {
+			return nil, err
+		}
+		for i := range vals {
+			vals[i] = v.Field(fields[i])
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() != len(vals) {
+			return nil, fmt.Errorf("cannot use %d values as %s", v.Len(), t)
+		}
+		for i := range vals {
+			vals[i] = v.Index(i)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot use %s as %s", v.Type(), t)
+		}
+		for i := range vals {
+			vals[i] = v.MapIndex(reflect.ValueOf(t.names[i]).Convert(v.Type().Key()))
+			if !vals[i].IsValid() {
+				return nil, fmt.Errorf("missing tuple member %q", t.names[i])
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cannot use %s as %s", v.Type(), t)
+	}
+	return vals, nil
+}
+
+var (
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1))
+)
+
+// checkInt checks that n is in range for the integer type t
+func (t *abiType) checkInt(n *big.Int) error {
+	if t.kind == abiUint {
+		if n.Sign() < 0 || n.BitLen() > t.size {
+			return fmt.Errorf("value %s overflows %s", n, t)
+		}
+		return nil
+	}
+	m := n
+	if n.Sign() < 0 {
+		m = new(big.Int).Not(n) // -n - 1
+	}
+	if m.BitLen() > t.size-1 {
+		return fmt.Errorf("value %s overflows %s", n, t)
+	}
+	return nil
+}
+
+// appendInt appends n as a two's-complement 256-bit word
+func appendInt(dst []byte, n *big.Int) []byte {
+	var w [32]byte
+	if n.Sign() < 0 {
+		n = new(big.Int).And(n, tt256m1)
+	}
+	b := n.Bytes()
+	copy(w[32-len(b):], b)
+	return append(dst, w[:]...)
+}
+
+// bigOf returns the integer value of v
+func bigOf(v reflect.Value) (*big.Int, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(v.Uint()), true
+	case reflect.Struct:
+		switch v.Type() {
+		case bigType:
+			if v.CanAddr() {
+				return v.Addr().Interface().(*big.Int), true
+			}
+			b := v.Interface().(big.Int)
+			return &b, true
+		case intType:
+			if v.CanAddr() {
+				return v.Addr().Interface().(*Int).Big(), true
+			}
+			i := v.Interface().(Int)
+			return i.Big(), true
+		}
+	}
+	return nil, false
+}
+
+// bytesOf returns the contents of a byte slice, byte array, or string
+func bytesOf(v reflect.Value) ([]byte, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), true
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return b, true
+		}
+	}
+	return nil, false
+}
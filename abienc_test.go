@@ -0,0 +1,200 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func words(t *testing.T, sel string, w ...string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(sel + strings.Join(w, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func word(s string) string {
+	return strings.Repeat("0", 64-len(s)) + s
+}
+
+func rword(s string) string {
+	return s + strings.Repeat("0", 64-len(s))
+}
+
+func TestEncodeSpecExamples(t *testing.T) {
+	t.Parallel()
+	// examples from the solidity ABI specification
+	cases := []struct {
+		sig  string
+		args []EtherType
+		want []byte
+	}{
+		{
+			sig:  "baz(uint32,bool)",
+			args: []EtherType{NewInt(69), NewInt(1)},
+			want: words(t, "cdcd77c0", word("45"), word("1")),
+		},
+		{
+			sig: "sam(bytes,bool,uint256[])",
+			args: []EtherType{
+				&Bytes{'d', 'a', 'v', 'e'},
+				NewInt(1),
+				&IntSlice{*NewInt(1), *NewInt(2), *NewInt(3)},
+			},
+			want: words(t, "a5643bf2",
+				word("60"), word("1"), word("a0"),
+				word("4"), rword("64617665"),
+				word("3"), word("1"), word("2"), word("3")),
+		},
+	}
+	for _, c := range cases {
+		got := ABIEncode(c.sig, c.args...)
+		if !bytes.Equal(got, c.want) {
+			t.Errorf("%s:\ngot  %x\nwant %x", c.sig, got, c.want)
+		}
+	}
+}
+
+func TestEncodeParams(t *testing.T) {
+	t.Parallel()
+	d := &ABIDescriptor{
+		Type: "function",
+		Name: "f",
+		Inputs: []ABIParam{
+			{Type: "uint256"},
+			{Type: "uint32[]"},
+			{Type: "bytes10"},
+			{Type: "bytes"},
+		},
+	}
+	sel := d.Selector()
+	if hex.EncodeToString(sel[:]) != "8be65246" {
+		t.Errorf("bad selector %x", sel)
+	}
+	got, err := EncodeParams(d.Inputs, 0x123, []uint32{0x456, 0x789}, []byte("1234567890"), "Hello, world!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := words(t, "",
+		word("123"), word("80"), rword("31323334353637383930"), word("e0"),
+		word("2"), word("456"), word("789"),
+		word("d"), rword("48656c6c6f2c20776f726c6421"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+
+	// nested dynamic arrays
+	got, err = EncodeParams([]ABIParam{{Type: "uint256[][]"}, {Type: "string[]"}},
+		[][]int{{1, 2}, {3}}, []string{"one", "two", "three"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = words(t, "",
+		word("40"), word("140"),
+		word("2"), word("40"), word("a0"),
+		word("2"), word("1"), word("2"),
+		word("1"), word("3"),
+		word("3"), word("60"), word("a0"), word("e0"),
+		word("3"), rword("6f6e65"),
+		word("3"), rword("74776f"),
+		word("5"), rword("7468726565"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+}
+
+func TestEncodeTuple(t *testing.T) {
+	t.Parallel()
+	type inner struct {
+		Amount *big.Int
+		Memo   string `abi:"note"`
+	}
+	params := []ABIParam{
+		{Name: "to", Type: "address"},
+		{Name: "items", Type: "tuple[]", Components: []ABIParam{
+			{Name: "amount", Type: "uint256"},
+			{Name: "note", Type: "string"},
+		}},
+		{Name: "pair", Type: "tuple", Components: []ABIParam{
+			{Name: "a", Type: "int8"},
+			{Name: "b", Type: "bytes2[2]"},
+		}},
+	}
+	d := &ABIDescriptor{Name: "g", Inputs: params}
+	if sig := d.Signature(); sig != "g(address,(uint256,string)[],(int8,bytes2[2]))" {
+		t.Errorf("bad signature %q", sig)
+	}
+
+	var to Address
+	to[19] = 0xaa
+	items := []inner{{big.NewInt(1), "ab"}}
+	pair := []interface{}{-1, [2][]byte{{1, 2}, {3, 4}}}
+
+	got, err := EncodeParams(params, &to, items, pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := words(t, "",
+		word("aa"), word("a0"),
+		strings.Repeat("f", 64), rword("0102"), rword("0304"),
+		// items
+		word("1"), word("20"),
+		// items[0]
+		word("1"), word("40"), word("2"), rword("6162"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+
+	// a map works just as well as a struct
+	m := []map[string]interface{}{{"amount": 1, "note": "ab"}}
+	got, err = EncodeParams(params, &to, m, pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+}
+
+func TestEncodeRange(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		typ string
+		val interface{}
+		ok  bool
+	}{
+		{"uint8", 255, true},
+		{"uint8", 256, false},
+		{"uint8", -1, false},
+		{"int8", 127, true},
+		{"int8", 128, false},
+		{"int8", -128, true},
+		{"int8", -129, false},
+		{"uint256", new(big.Int).Lsh(big.NewInt(1), 255), true},
+		{"uint256", new(big.Int).Lsh(big.NewInt(1), 256), false},
+		{"int256", new(big.Int).Lsh(big.NewInt(1), 255), false},
+		{"bytes2", []byte{1, 2, 3}, false},
+		{"address", [20]byte{}, true},
+		{"address", [32]byte{}, false},
+		{"uint256[2]", []int{1}, false},
+		{"bool", 2, false},
+	}
+	for _, c := range cases {
+		_, err := EncodeParams([]ABIParam{{Type: c.typ}}, c.val)
+		if (err == nil) != c.ok {
+			t.Errorf("%s %v: unexpected error state %v", c.typ, c.val, err)
+		}
+	}
+
+	got, err := EncodeParams([]ABIParam{{Type: "int8"}}, -128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := words(t, "", strings.Repeat("f", 62)+"80"); !bytes.Equal(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+}
@@ -0,0 +1,320 @@
+package seth
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// abiKind is the kind of an ABI type
+type abiKind uint8
+
+const (
+	abiUint       abiKind = iota // uintN
+	abiInt                       // intN
+	abiAddress                   // address
+	abiBool                      // bool
+	abiFixedBytes                // bytesN
+	abiBytes                     // bytes
+	abiString                    // string
+	abiSlice                     // T[]
+	abiArray                     // T[k]
+	abiTuple                     // (T0,T1,...)
+	abiFunction                  // function (encoded as bytes24)
+)
+
+// abiType is a parsed ABI type
+type abiType struct {
+	kind  abiKind
+	size  int        // bit width for integers, byte width for bytesN and function, k for T[k]
+	elem  *abiType   // element type of T[] and T[k]
+	elems []*abiType // tuple members
+	names []string   // tuple member names (may be empty strings)
+}
+
+// String returns the canonical name of the type,
+// as it would appear in a function signature.
+func (t *abiType) String() string {
+	switch t.kind {
+	case abiUint:
+		return "uint" + strconv.Itoa(t.size)
+	case abiInt:
+		return "int" + strconv.Itoa(t.size)
+	case abiAddress:
+		return "address"
+	case abiBool:
+		return "bool"
+	case abiFixedBytes:
+		return "bytes" + strconv.Itoa(t.size)
+	case abiFunction:
+		return "function"
+	case abiBytes:
+		return "bytes"
+	case abiString:
+		return "string"
+	case abiSlice:
+		return t.elem.String() + "[]"
+	case abiArray:
+		return t.elem.String() + "[" + strconv.Itoa(t.size) + "]"
+	case abiTuple:
+		s := make([]string, len(t.elems))
+		for i := range t.elems {
+			s[i] = t.elems[i].String()
+		}
+		return "(" + strings.Join(s, ",") + ")"
+	}
+	return "<invalid>"
+}
+
+// dynamic returns whether or not the type
+// is encoded out-of-line in the ABI encoding
+func (t *abiType) dynamic() bool {
+	switch t.kind {
+	case abiBytes, abiString, abiSlice:
+		return true
+	case abiArray:
+		return t.elem.dynamic()
+	case abiTuple:
+		for i := range t.elems {
+			if t.elems[i].dynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headsize returns the number of bytes that the
+// type occupies in the head of an ABI encoding
+func (t *abiType) headsize() int {
+	if t.dynamic() {
+		return 32
+	}
+	switch t.kind {
+	case abiArray:
+		return t.size * t.elem.headsize()
+	case abiTuple:
+		n := 0
+		for i := range t.elems {
+			n += t.elems[i].headsize()
+		}
+		return n
+	}
+	return 32
+}
+
+// parseType parses an ABI type string. Tuple types may be
+// specified either as "tuple" (in which case the components
+// describe the tuple members) or inline as "(T0,T1,...)".
+func parseType(typ string, components []ABIParam) (*abiType, error) {
+	if strings.HasSuffix(typ, "]") {
+		lb := strings.LastIndexByte(typ, '[')
+		if lb <= 0 {
+			return nil, fmt.Errorf("bad array type %q", typ)
+		}
+		elem, err := parseType(typ[:lb], components)
+		if err != nil {
+			return nil, err
+		}
+		dim := typ[lb+1 : len(typ)-1]
+		if dim == "" {
+			return &abiType{kind: abiSlice, elem: elem}, nil
+		}
+		k, err := strconv.Atoi(dim)
+		if err != nil || k <= 0 {
+			return nil, fmt.Errorf("bad array length in %q", typ)
+		}
+		return &abiType{kind: abiArray, size: k, elem: elem}, nil
+	}
+	if typ == "tuple" {
+		return paramsType(components)
+	}
+	if strings.HasPrefix(typ, "(") {
+		if !strings.HasSuffix(typ, ")") {
+			return nil, fmt.Errorf("bad tuple type %q", typ)
+		}
+		parts, err := splitTuple(typ[1 : len(typ)-1])
+		if err != nil {
+			return nil, fmt.Errorf("bad tuple type %q: %s", typ, err)
+		}
+		t := &abiType{kind: abiTuple, elems: make([]*abiType, len(parts)), names: make([]string, len(parts))}
+		for i := range parts {
+			t.elems[i], err = parseType(parts[i], nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+	switch typ {
+	case "address":
+		return &abiType{kind: abiAddress}, nil
+	case "bool":
+		return &abiType{kind: abiBool}, nil
+	case "string":
+		return &abiType{kind: abiString}, nil
+	case "bytes":
+		return &abiType{kind: abiBytes}, nil
+	case "function":
+		return &abiType{kind: abiFunction, size: 24}, nil
+	case "uint", "int":
+		typ += "256"
+	}
+	kind, rest := abiUint, ""
+	switch {
+	case strings.HasPrefix(typ, "uint"):
+		rest = typ[4:]
+	case strings.HasPrefix(typ, "int"):
+		kind, rest = abiInt, typ[3:]
+	case strings.HasPrefix(typ, "bytes"):
+		n, err := strconv.Atoi(typ[5:])
+		if err != nil || n < 1 || n > 32 {
+			return nil, fmt.Errorf("bad type %q", typ)
+		}
+		return &abiType{kind: abiFixedBytes, size: n}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", typ)
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil || n < 8 || n > 256 || n%8 != 0 {
+		return nil, fmt.Errorf("bad type %q", typ)
+	}
+	return &abiType{kind: kind, size: n}, nil
+}
+
+// paramsType returns the tuple type described by a parameter list
+func paramsType(params []ABIParam) (*abiType, error) {
+	t := &abiType{kind: abiTuple, elems: make([]*abiType, len(params)), names: make([]string, len(params))}
+	for i := range params {
+		et, err := parseType(params[i].Type, params[i].Components)
+		if err != nil {
+			return nil, err
+		}
+		t.elems[i] = et
+		t.names[i] = params[i].Name
+	}
+	return t, nil
+}
+
+// splitTuple splits the interior of a tuple type
+// string on commas that are not nested in parentheses
+func splitTuple(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	return append(out, s[start:]), nil
+}
+
+// parseSignature splits a function signature of the
+// form "name(type0,type1,...)" into its name and the
+// tuple of its argument types.
+func parseSignature(fn string) (string, *abiType, error) {
+	if strings.ContainsAny(fn, illegal) {
+		return "", nil, fmt.Errorf("illegal characters in function signature %q", fn)
+	}
+	lparen := strings.IndexByte(fn, '(')
+	if lparen == -1 {
+		return "", nil, fmt.Errorf("%s has no left paren", fn)
+	}
+	if fn[len(fn)-1] != ')' {
+		return "", nil, fmt.Errorf("%s has a bad right paren", fn)
+	}
+	t, err := parseType(fn[lparen:], nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return fn[:lparen], t, nil
+}
+
+// canonicalType returns the canonical type name
+// of the parameter, with tuples expanded
+func (p *ABIParam) canonicalType() string {
+	t, err := parseType(p.Type, p.Components)
+	if err != nil {
+		return p.Type
+	}
+	return t.String()
+}
+
+var (
+	bigType     = reflect.TypeOf(big.Int{})
+	intType     = reflect.TypeOf(Int{})
+	addressType = reflect.TypeOf(Address{})
+)
+
+// tupleFields maps the members of a tuple onto the fields
+// of a struct type. Fields are matched to tuple members by
+// an `abi:"name"` struct tag, or by a case-insensitive match
+// of the field name and the member name. If the members can't
+// all be matched by name, and the struct has exactly as many
+// exported fields as the tuple has members, fields are matched
+// by position.
+func tupleFields(st reflect.Type, names []string) ([]int, error) {
+	var fields []int
+	tagged := make(map[string]int)
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("abi")
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			tagged[tag] = i
+		}
+		fields = append(fields, i)
+	}
+	out := make([]int, len(names))
+	byname := true
+outer:
+	for i, name := range names {
+		if j, ok := tagged[name]; ok && name != "" {
+			out[i] = j
+			continue
+		}
+		name = strings.TrimLeft(name, "_")
+		if name != "" {
+			for _, j := range fields {
+				if strings.EqualFold(st.Field(j).Name, name) {
+					out[i] = j
+					continue outer
+				}
+			}
+		}
+		byname = false
+		break
+	}
+	if byname {
+		return out, nil
+	}
+	if len(fields) != len(names) {
+		return nil, fmt.Errorf("cannot map %d tuple members onto struct %s", len(names), st)
+	}
+	copy(out, fields)
+	return out, nil
+}
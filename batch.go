@@ -0,0 +1,146 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// errNoResponse is the error of a batched request
+// to which the node did not send a response
+var errNoResponse = errors.New("seth: no response to batched request")
+
+// A BatchElem is a request in a batch (see (*Client).Batch).
+type BatchElem struct {
+	Method string
+	Params []json.RawMessage
+	// Result is the value into which
+	// the result of the request is unmarshaled.
+	Result interface{}
+	// Error is set by (*Client).Batch if the request failed.
+	// It is interpreted like the errors returned by (*Client).Do.
+	Error error
+}
+
+// A BatchTransport is a Transport that can
+// send a batch of requests in one round trip.
+type BatchTransport interface {
+	Transport
+	// ExecuteBatch executes the requests in reqs and stores
+	// the response to each request in the corresponding element
+	// of res. (The responses are matched to the requests by ID,
+	// and the element for a request that wasn't answered is left
+	// as it is.) An error is returned only if the batch as a whole
+	// failed.
+	ExecuteBatch(reqs []RPCRequest, res []RPCResponse) error
+}
+
+// A BatchContextTransport is a BatchTransport that
+// can abandon a batch when its context is done.
+type BatchContextTransport interface {
+	BatchTransport
+	// ExecuteBatchContext is like ExecuteBatch, but it returns
+	// ctx.Err() if ctx is done before the responses arrive.
+	ExecuteBatchContext(ctx context.Context, reqs []RPCRequest, res []RPCResponse) error
+}
+
+// Batch executes a batch of requests. If the client's
+// transport is a BatchTransport, the requests are sent in
+// one round trip; otherwise, they are made one at a time.
+//
+// The error returned by Batch is the failure of the batch as
+// a whole. The failure of each request is reported in its Error.
+func (c *Client) Batch(elems []BatchElem) error {
+	return c.BatchContext(context.Background(), elems)
+}
+
+// BatchContext is like Batch, but it returns ctx.Err() if
+// ctx is done before the responses arrive. If the client's
+// transport is a BatchTransport but not a BatchContextTransport,
+// the batch is abandoned rather than interrupted.
+func (c *Client) BatchContext(ctx context.Context, elems []BatchElem) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	bt, ok := c.tport.(BatchTransport)
+	if !ok {
+		for i := range elems {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			elems[i].Error = c.DoContext(ctx, elems[i].Method, elems[i].Params, elems[i].Result)
+		}
+		return nil
+	}
+	reqs := make([]RPCRequest, len(elems))
+	res := make([]RPCResponse, len(elems))
+	for i := range elems {
+		reqs[i] = *c.request(elems[i].Method, elems[i].Params)
+	}
+	if err := executeBatch(ctx, bt, reqs, res); err != nil {
+		return err
+	}
+	for i := range elems {
+		if res[i].ID != reqs[i].ID {
+			elems[i].Error = errNoResponse
+			continue
+		}
+		elems[i].Error = res[i].decode(elems[i].Result)
+	}
+	return nil
+}
+
+// executeBatch executes a batch with bt,
+// giving up on it once ctx is done
+func executeBatch(ctx context.Context, bt BatchTransport, reqs []RPCRequest, res []RPCResponse) error {
+	if ctx.Done() == nil {
+		return bt.ExecuteBatch(reqs, res)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if bct, ok := bt.(BatchContextTransport); ok {
+		return bct.ExecuteBatchContext(ctx, reqs, res)
+	}
+	done := make(chan error, 1)
+	out := make([]RPCResponse, len(res))
+	go func() {
+		done <- bt.ExecuteBatch(reqs, out)
+	}()
+	select {
+	case err := <-done:
+		copy(res, out)
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// matchBatch stores each response in in at the
+// index in res of the request in reqs with its ID
+func matchBatch(reqs []RPCRequest, in, res []RPCResponse) {
+	index := make(map[int]int, len(reqs))
+	for i := range reqs {
+		index[reqs[i].ID] = i
+	}
+	for j := range in {
+		if i, ok := index[in[j].ID]; ok {
+			res[i] = in[j]
+		}
+	}
+}
+
+// isBatch returns whether buf holds a JSON array
+func isBatch(buf []byte) bool {
+	for _, b := range buf {
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
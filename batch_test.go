@@ -0,0 +1,224 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// batchNode answers batches of requests in reverse
+// order, and doesn't answer test_skip at all
+func batchNode(reqs []RPCRequest) []RPCResponse {
+	var out []RPCResponse
+	for i := len(reqs) - 1; i >= 0; i-- {
+		res := RPCResponse{ID: reqs[i].ID, Version: "2.0"}
+		switch reqs[i].Method {
+		case "eth_blockNumber":
+			res.Result = json.RawMessage(`"0x10"`)
+		case "eth_getTransactionByHash":
+			res.Result = json.RawMessage(`null`)
+		case "test_skip":
+			continue
+		default:
+			res.Error.Code = -32601
+			res.Error.Message = "unsupported method"
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// serveBatches answers batches of
+// requests read from conn until it fails
+func serveBatches(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var reqs []RPCRequest
+		if dec.Decode(&reqs) != nil || enc.Encode(batchNode(reqs)) != nil {
+			return
+		}
+	}
+}
+
+func testBatch(t *testing.T, c *Client) {
+	t.Helper()
+	var num Uint64
+	var tx Transaction
+	var out json.RawMessage
+	elems := []BatchElem{
+		{Method: "eth_blockNumber", Result: &num},
+		{Method: "eth_getTransactionByHash", Result: &tx},
+		{Method: "test_skip", Result: &out},
+		{Method: "test_fail", Result: &out},
+	}
+	if err := c.Batch(elems); err != nil {
+		t.Fatal(err)
+	}
+	if elems[0].Error != nil || num != 0x10 {
+		t.Errorf("eth_blockNumber: %d %v", num, elems[0].Error)
+	}
+	if elems[1].Error != ErrNotFound {
+		t.Errorf("eth_getTransactionByHash: %v", elems[1].Error)
+	}
+	if elems[2].Error != errNoResponse {
+		t.Errorf("test_skip: %v", elems[2].Error)
+	}
+	if e, ok := elems[3].Error.(*RPCError); !ok || e.Code != -32601 {
+		t.Errorf("test_fail: %v", elems[3].Error)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("http", func(t *testing.T) {
+		hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqs []RPCRequest
+			if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+				// respond like a node without batch support
+				json.NewEncoder(w).Encode(&RPCResponse{Error: RPCError{Code: -32600, Message: "invalid request"}})
+				return
+			}
+			json.NewEncoder(w).Encode(batchNode(reqs))
+		}))
+		defer hs.Close()
+		testBatch(t, NewHTTPClient(hs.URL))
+	})
+	t.Run("rpc", func(t *testing.T) {
+		c := NewClient(func() (io.ReadWriteCloser, error) {
+			a, b := net.Pipe()
+			go serveBatches(b)
+			return a, nil
+		})
+		testBatch(t, c)
+	})
+	t.Run("ws", func(t *testing.T) {
+		hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var up websocket.Upgrader
+			conn, err := up.Upgrade(w, r, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			for {
+				var reqs []RPCRequest
+				if conn.ReadJSON(&reqs) != nil || conn.WriteJSON(batchNode(reqs)) != nil {
+					return
+				}
+			}
+		}))
+		defer hs.Close()
+		c := NewWSClient(wsURL(hs))
+		defer c.tport.(*WSTransport).Close()
+		testBatch(t, c)
+	})
+	t.Run("fallback", func(t *testing.T) {
+		// chainNode doesn't support batches
+		c := NewClientTransport(newChainNode())
+		var b Block
+		elems := []BatchElem{
+			{Method: "eth_getBlockByNumber", Params: []json.RawMessage{rawlatest}, Result: &b},
+			{Method: "test_fail"},
+		}
+		if err := c.Batch(elems); err != nil {
+			t.Fatal(err)
+		}
+		if elems[0].Error != nil || b.Hash == nil || elems[1].Error == nil {
+			t.Errorf("unexpected errors %v, %v", elems[0].Error, elems[1].Error)
+		}
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		// nodes without batch support respond with
+		// a single error that has a null ID
+		const unsupported = `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batches not supported"}}`
+		check := func(t *testing.T, c *Client) {
+			t.Helper()
+			err := c.Batch([]BatchElem{{Method: "eth_blockNumber"}, {Method: "eth_blockNumber"}})
+			if e, ok := err.(*RPCError); !ok || e.Code != -32600 {
+				t.Errorf("unexpected error %v", err)
+			}
+		}
+		t.Run("http", func(t *testing.T) {
+			hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, unsupported)
+			}))
+			defer hs.Close()
+			check(t, NewHTTPClient(hs.URL))
+		})
+		t.Run("rpc", func(t *testing.T) {
+			c := NewClient(func() (io.ReadWriteCloser, error) {
+				a, b := net.Pipe()
+				go func() {
+					defer b.Close()
+					dec := json.NewDecoder(b)
+					for {
+						var reqs []RPCRequest
+						if dec.Decode(&reqs) != nil {
+							return
+						}
+						if _, err := io.WriteString(b, unsupported+"\n"); err != nil {
+							return
+						}
+					}
+				}()
+				return a, nil
+			})
+			check(t, c)
+		})
+		t.Run("ws", func(t *testing.T) {
+			hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var up websocket.Upgrader
+				conn, err := up.Upgrade(w, r, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer conn.Close()
+				for {
+					var reqs []RPCRequest
+					if conn.ReadJSON(&reqs) != nil || conn.WriteMessage(websocket.TextMessage, []byte(unsupported)) != nil {
+						return
+					}
+				}
+			}))
+			defer hs.Close()
+			c := NewWSClient(wsURL(hs))
+			defer c.tport.(*WSTransport).Close()
+			check(t, c)
+		})
+	})
+}
+
+func TestBatchContext(t *testing.T) {
+	var server net.Conn
+	c := NewClient(func() (io.ReadWriteCloser, error) {
+		a, b := net.Pipe()
+		server = b
+		// read requests and never respond
+		go io.Copy(ioutil.Discard, b)
+		return a, nil
+	})
+	defer func() { server.Close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	elems := []BatchElem{{Method: "eth_blockNumber"}, {Method: "eth_blockNumber"}}
+	if err := c.BatchContext(ctx, elems); err != context.DeadlineExceeded {
+		t.Fatalf("expected a timeout; got %v", err)
+	}
+	tp := c.tport.(*RPCTransport)
+	tp.lock.Lock()
+	n := len(tp.pending)
+	tp.lock.Unlock()
+	if n != 0 {
+		t.Errorf("%d requests still pending", n)
+	}
+}
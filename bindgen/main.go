@@ -2,11 +2,13 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/newalchemylimited/seth"
@@ -149,6 +151,19 @@ func deref(v string) string {
 	return v
 }
 
+// methodName returns the Go method name for the ABI function
+// fn and marks it as used. Overloaded functions get numbered
+// names (Foo, Foo1, Foo2), skipping any name already in use.
+func methodName(used map[string]bool, fn string) string {
+	base := strings.Title(fn)
+	name := base
+	for n := 1; used[name]; n++ {
+		name = base + strconv.Itoa(n)
+	}
+	used[name] = true
+	return name
+}
+
 func generate(w io.Writer, c *seth.CompiledContract) {
 	if bin {
 		fmt.Fprintf(w, "var %sCode = %#v\n", c.Name, c.Code)
@@ -165,7 +180,15 @@ func generate(w io.Writer, c *seth.CompiledContract) {
 	fmt.Fprintf(w, "\treturn &%s{addr: addr, s: sender}\n", c.Name)
 	fmt.Fprintln(w, "}")
 
+	// abi
+	abi, err := json.Marshal(c.ABI)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Fprintf(w, "\nvar %sABI = seth.MustParseABI(%s)\n", c.Name, quote(string(abi)))
+
 	// methods
+	used := make(map[string]bool)
 	for i := range c.ABI {
 		d := &c.ABI[i]
 		if d.Type != "function" {
@@ -178,11 +201,14 @@ func generate(w io.Writer, c *seth.CompiledContract) {
 
 		fmt.Fprintln(w)
 
-		fmt.Fprintf(w, "func (z *%s) %s(", c.Name, strings.Title(d.Name))
+		name := methodName(used, d.Name)
+
+		fmt.Fprintf(w, "func (z *%s) %s(", c.Name, name)
 		// input arguments
-		var argstrs []string
+		var argstrs, argnames []string
 		for i := range d.Inputs {
 			argstrs = append(argstrs, fmt.Sprintf("arg%d %s", i, typeconv(d.Inputs[i].Type)))
+			argnames = append(argnames, fmt.Sprintf("arg%d", i))
 		}
 		fmt.Fprint(w, strings.Join(argstrs, ", ")+") ")
 
@@ -202,23 +228,27 @@ func generate(w io.Writer, c *seth.CompiledContract) {
 				retargs = append(retargs, fmt.Sprintf("&ret%d", i))
 			}
 
-			fmt.Fprintln(w, "\td := seth.NewABIDecoder("+strings.Join(retargs, ", ")+")")
-
-			fmt.Fprintf(w, "\terr = z.s.ConstCall(z.addr, %q, d", d.Signature())
-			for i := 0; i < len(d.Inputs); i++ {
-				fmt.Fprintf(w, ", arg%d", i)
-			}
-			fmt.Fprintln(w, ")")
+			fmt.Fprintf(w, "\terr = z.s.ConstCallABI(z.addr, %sABI, %q, []interface{}{%s}, %s)\n",
+				c.Name, d.Signature(), strings.Join(argnames, ", "), strings.Join(retargs, ", "))
 			fmt.Fprintln(w, "\treturn")
 			fmt.Fprintln(w, "}")
 		} else {
 			fmt.Fprintln(w, "(seth.Hash, error) {")
-			fmt.Fprintf(w, "\treturn z.s.Send(z.addr, %q", d.Signature())
-			for i := 0; i < len(d.Inputs); i++ {
-				fmt.Fprintf(w, ", arg%d", i)
+			fmt.Fprintf(w, "\treturn z.s.SendABI(z.addr, %sABI, %q", c.Name, d.Signature())
+			for i := range argnames {
+				fmt.Fprintf(w, ", %s", argnames[i])
 			}
 			fmt.Fprintln(w, ")")
 			fmt.Fprintln(w, "}")
 		}
 	}
 }
+
+// quote returns s as a Go string literal,
+// preferring a raw string literal
+func quote(s string) string {
+	if strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
@@ -24,3 +24,15 @@ func TestBindgen(t *testing.T) {
 	do(t, "go generate ./test/")
 	do(t, "go run "+strings.Join(gofiles, " "))
 }
+
+func TestMethodName(t *testing.T) {
+	used := make(map[string]bool)
+	var got []string
+	for _, fn := range []string{"foo", "foo", "foo1", "foo", "bar"} {
+		got = append(got, methodName(used, fn))
+	}
+	want := "Foo Foo1 Foo11 Foo2 Bar"
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("got %q; want %q", s, want)
+	}
+}
@@ -15,22 +15,22 @@ func NewTest(addr *seth.Address, sender *seth.Sender) *Test {
 	return &Test{addr: addr, s: sender}
 }
 
+var TestABI = seth.MustParseABI(`[{"type":"function","name":"mustThrow","inputs":[],"payable":false,"stateMutability":"nonpayable","constant":false,"anonymous":false},{"type":"function","name":"inc","inputs":[],"payable":false,"stateMutability":"nonpayable","constant":false,"anonymous":false},{"type":"function","name":"value","inputs":[],"outputs":[{"name":"","type":"uint256","indexed":false}],"payable":false,"stateMutability":"view","constant":true,"anonymous":false},{"type":"function","name":"counter","inputs":[],"outputs":[{"name":"","type":"uint256","indexed":false}],"payable":false,"stateMutability":"view","constant":true,"anonymous":false},{"type":"constructor","name":"","inputs":[],"payable":false,"stateMutability":"nonpayable","constant":false,"anonymous":false}]`)
+
 func (z *Test) MustThrow() (seth.Hash, error) {
-	return z.s.Send(z.addr, "mustThrow()")
+	return z.s.SendABI(z.addr, TestABI, "mustThrow()")
 }
 
 func (z *Test) Inc() (seth.Hash, error) {
-	return z.s.Send(z.addr, "inc()")
+	return z.s.SendABI(z.addr, TestABI, "inc()")
 }
 
 func (z *Test) Value() (ret0 seth.Int, err error) {
-	d := seth.NewABIDecoder(&ret0)
-	err = z.s.ConstCall(z.addr, "value()", d)
+	err = z.s.ConstCallABI(z.addr, TestABI, "value()", []interface{}{}, &ret0)
 	return
 }
 
 func (z *Test) Counter() (ret0 seth.Int, err error) {
-	d := seth.NewABIDecoder(&ret0)
-	err = z.s.ConstCall(z.addr, "counter()", d)
+	err = z.s.ConstCallABI(z.addr, TestABI, "counter()", []interface{}{}, &ret0)
 	return
 }
@@ -2,6 +2,7 @@ package seth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -19,6 +20,43 @@ type pending struct {
 	notify chan struct{}
 	res    *RPCResponse
 	err    error
+	batch  []int // IDs of the requests in the same batch, if any
+}
+
+// finishBatch completes the requests in a batch
+// that the response to the batch left unanswered
+func finishBatch(pending map[int]*pending, batch []int) {
+	for _, id := range batch {
+		if p := pending[id]; p != nil {
+			delete(pending, id)
+			close(p.notify)
+		}
+	}
+}
+
+// failBatches handles a response that answers none of the
+// pending requests: nodes that can't handle a batch respond
+// to it with a single error (usually with a null ID), which
+// fails every outstanding batch. It returns whether or not
+// the response was taken to be such an error.
+func failBatches(pending map[int]*pending, res *RPCResponse) bool {
+	if res.Error.Code == 0 && res.Error.Message == "" {
+		return false
+	}
+	if pending[res.ID] != nil {
+		return false
+	}
+	failed := false
+	for id, p := range pending {
+		if p.batch != nil {
+			e := res.Error
+			p.err = &e
+			delete(pending, id)
+			close(p.notify)
+			failed = true
+		}
+	}
+	return failed
 }
 
 // An RPCTrasport is a client transport for making requests over an RPC
@@ -28,15 +66,14 @@ type RPCTransport struct {
 	conn    io.ReadWriteCloser
 	enc     *json.Encoder // wraps send side of conn
 	pending map[int]*pending
-	res     RPCResponse
 	dial    func() (io.ReadWriteCloser, error)
 }
 
 func (t *RPCTransport) background(conn io.ReadWriteCloser) {
 	dec := json.NewDecoder(conn)
 	for {
-		t.res = RPCResponse{}
-		err := dec.Decode(&t.res)
+		var msg json.RawMessage
+		err := dec.Decode(&msg)
 		if err != nil {
 			log.Printf("seth: conn read: %s", err)
 			t.lock.Lock()
@@ -47,28 +84,56 @@ func (t *RPCTransport) background(conn io.ReadWriteCloser) {
 			t.lock.Unlock()
 			return
 		}
-		t.lock.Lock()
-		p := t.pending[t.res.ID]
-		if p != nil {
-			delete(t.pending, t.res.ID)
+		var batch []RPCResponse
+		if isBatch(msg) {
+			err = json.Unmarshal(msg, &batch)
+		} else {
+			batch = make([]RPCResponse, 1)
+			err = json.Unmarshal(msg, &batch[0])
 		}
-		t.lock.Unlock()
-		if p == nil {
-			log.Printf("spurious response ID %d", t.res.ID)
+		if err != nil {
+			log.Printf("seth: bad response: %s", err)
 			continue
 		}
-		if t.res.Error.Code != 0 || t.res.Error.Message != "" {
-			c := t.res.Error
-			p.err = &c
-		} else if bytes.Equal(t.res.Result, rawnull) {
-			p.err = ErrNotFound
-		} else {
-			*p.res = t.res
+		if !isBatch(msg) {
+			t.lock.Lock()
+			failed := failBatches(t.pending, &batch[0])
+			t.lock.Unlock()
+			if failed {
+				continue
+			}
+		}
+		var group []int
+		for i := range batch {
+			if p := t.dispatch(&batch[i]); p != nil {
+				group = p.batch
+			}
+		}
+		if group != nil {
+			t.lock.Lock()
+			finishBatch(t.pending, group)
+			t.lock.Unlock()
 		}
-		close(p.notify)
 	}
 }
 
+// dispatch delivers a response to its pending request
+func (t *RPCTransport) dispatch(res *RPCResponse) *pending {
+	t.lock.Lock()
+	p := t.pending[res.ID]
+	if p != nil {
+		delete(t.pending, res.ID)
+	}
+	t.lock.Unlock()
+	if p == nil {
+		log.Printf("spurious response ID %d", res.ID)
+		return nil
+	}
+	*p.res = *res
+	close(p.notify)
+	return p
+}
+
 func (t *RPCTransport) abort(err error) {
 	for id, p := range t.pending {
 		p.err = err
@@ -100,20 +165,67 @@ func (t *RPCTransport) reconnect() error {
 
 // Do makes a raw rpc request; it does not interpret the method or param
 // strings, and tries to unmarshal the result directly into "result." Use
-// another method instead, if you can.
+// another method instead, if you can. Errors that describe a reverted
+// call and include its return data are returned as a *RevertError.
 func (c *Client) Do(method string, params []json.RawMessage, result interface{}) error {
-	req := &RPCRequest{
+	return c.DoContext(context.Background(), method, params, result)
+}
+
+// DoContext is like Do, but it returns ctx.Err() if ctx is done
+// before the response arrives. If the client's transport is not
+// a ContextTransport, the request is abandoned rather than
+// interrupted.
+func (c *Client) DoContext(ctx context.Context, method string, params []json.RawMessage, result interface{}) error {
+	req := c.request(method, params)
+	res := new(RPCResponse)
+	if err := c.execute(ctx, req, res); err != nil {
+		return err
+	}
+	return res.decode(result)
+}
+
+func (c *Client) execute(ctx context.Context, req *RPCRequest, res *RPCResponse) error {
+	if ctx.Done() == nil {
+		return c.tport.Execute(req, res)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ct, ok := c.tport.(ContextTransport); ok {
+		return ct.ExecuteContext(ctx, req, res)
+	}
+	done := make(chan error, 1)
+	out := new(RPCResponse)
+	go func() {
+		done <- c.tport.Execute(req, out)
+	}()
+	select {
+	case err := <-done:
+		*res = *out
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// request creates a request with a new ID
+func (c *Client) request(method string, params []json.RawMessage) *RPCRequest {
+	return &RPCRequest{
 		Version: "2.0",
 		Method:  method,
 		Params:  params,
 		ID:      int(atomic.AddUintptr(&c.nextid, 1)),
 	}
-	res := new(RPCResponse)
-	if err := c.tport.Execute(req, res); err != nil {
-		return err
-	}
+}
+
+// decode interprets a response, unmarshaling
+// its result into "result" or returning its error
+func (res *RPCResponse) decode(result interface{}) error {
 	if res.Error.Code != 0 || res.Error.Message != "" {
 		e := res.Error
+		if re := revertError(&e); re != nil {
+			return re
+		}
 		return &e
 	} else if bytes.Equal(res.Result, rawnull) {
 		return ErrNotFound
@@ -121,8 +233,40 @@ func (c *Client) Do(method string, params []json.RawMessage, result interface{})
 	return json.Unmarshal(res.Result, result)
 }
 
+// Execute implements Transport.
 func (t *RPCTransport) Execute(req *RPCRequest, res *RPCResponse) error {
-	notify := make(chan struct{}, 1)
+	return t.send(context.Background(), req, []int{req.ID}, []*RPCResponse{res})
+}
+
+// ExecuteContext implements ContextTransport.
+func (t *RPCTransport) ExecuteContext(ctx context.Context, req *RPCRequest, res *RPCResponse) error {
+	return t.send(ctx, req, []int{req.ID}, []*RPCResponse{res})
+}
+
+// ExecuteBatch implements BatchTransport.
+func (t *RPCTransport) ExecuteBatch(reqs []RPCRequest, res []RPCResponse) error {
+	return t.ExecuteBatchContext(context.Background(), reqs, res)
+}
+
+// ExecuteBatchContext implements BatchContextTransport.
+func (t *RPCTransport) ExecuteBatchContext(ctx context.Context, reqs []RPCRequest, res []RPCResponse) error {
+	ids := make([]int, len(reqs))
+	out := make([]*RPCResponse, len(reqs))
+	for i := range reqs {
+		ids[i] = reqs[i].ID
+		out[i] = &res[i]
+	}
+	return t.send(ctx, reqs, ids, out)
+}
+
+// send encodes v, which is a request or a batch of requests
+// with the given IDs, and waits for all of their responses
+// or for ctx to be done
+func (t *RPCTransport) send(ctx context.Context, v interface{}, ids []int, res []*RPCResponse) error {
+	var batch []int
+	if _, ok := v.([]RPCRequest); ok {
+		batch = ids
+	}
 	t.lock.Lock()
 	if t.enc == nil {
 		if err := t.reconnect(); err != nil {
@@ -130,15 +274,43 @@ func (t *RPCTransport) Execute(req *RPCRequest, res *RPCResponse) error {
 			return err
 		}
 	}
-	p := &pending{notify: notify, res: res}
-	t.pending[req.ID] = p
-	err := t.enc.Encode(req)
+	ps := make([]*pending, len(ids))
+	for i, id := range ids {
+		ps[i] = &pending{notify: make(chan struct{}), res: res[i], batch: batch}
+		t.pending[id] = ps[i]
+	}
+	err := t.enc.Encode(v)
 	if err != nil {
 		t.abort(err)
 	}
+	pending := t.pending
 	t.lock.Unlock()
-	<-p.notify
-	return p.err
+	return wait(ctx, &t.lock, pending, ids, ps)
+}
+
+// wait waits for the responses to the requests with the
+// given IDs. If ctx is done first, the requests are removed
+// from pending (which is guarded by lock) and ctx.Err() is
+// returned.
+func wait(ctx context.Context, lock *sync.Mutex, pending map[int]*pending, ids []int, ps []*pending) error {
+	for i, p := range ps {
+		select {
+		case <-p.notify:
+		case <-ctx.Done():
+			lock.Lock()
+			for j, id := range ids[i:] {
+				if pending[id] == ps[i+j] {
+					delete(pending, id)
+				}
+			}
+			lock.Unlock()
+			return ctx.Err()
+		}
+		if p.err != nil {
+			return p.err
+		}
+	}
+	return nil
 }
 
 // An HTTPTransport is a client transport for making requests over HTTP.
@@ -148,19 +320,67 @@ type HTTPTransport struct {
 
 // Execute implements Transport.
 func (t *HTTPTransport) Execute(req *RPCRequest, res *RPCResponse) error {
-	body, err := json.Marshal(req)
+	return t.post(context.Background(), req, res)
+}
+
+// ExecuteContext implements ContextTransport.
+func (t *HTTPTransport) ExecuteContext(ctx context.Context, req *RPCRequest, res *RPCResponse) error {
+	return t.post(ctx, req, res)
+}
+
+// ExecuteBatch implements BatchTransport.
+func (t *HTTPTransport) ExecuteBatch(reqs []RPCRequest, res []RPCResponse) error {
+	return t.ExecuteBatchContext(context.Background(), reqs, res)
+}
+
+// ExecuteBatchContext implements BatchContextTransport.
+func (t *HTTPTransport) ExecuteBatchContext(ctx context.Context, reqs []RPCRequest, res []RPCResponse) error {
+	var msg json.RawMessage
+	if err := t.post(ctx, reqs, &msg); err != nil {
+		return err
+	}
+	if !isBatch(msg) {
+		// nodes that don't support batches
+		// respond with a single error
+		var single RPCResponse
+		if err := json.Unmarshal(msg, &single); err != nil {
+			return err
+		}
+		if single.Error.Code == 0 && single.Error.Message == "" {
+			return errors.New("seth: unexpected response to batch")
+		}
+		return &single.Error
+	}
+	var in []RPCResponse
+	if err := json.Unmarshal(msg, &in); err != nil {
+		return err
+	}
+	matchBatch(reqs, in, res)
+	return nil
+}
+
+func (t *HTTPTransport) post(ctx context.Context, body, out interface{}) error {
+	buf, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
-	hres, err := http.Post(t.URL, "application/json", bytes.NewReader(body))
+	hreq, err := http.NewRequest("POST", t.URL, bytes.NewReader(buf))
 	if err != nil {
 		return err
 	}
+	hreq.Header.Set("Content-Type", "application/json")
+	hres, err := http.DefaultClient.Do(hreq.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
 	defer hres.Body.Close()
 	if hres.StatusCode != http.StatusOK {
 		return errors.New("http error: " + hres.Status)
 	}
-	return json.NewDecoder(hres.Body).Decode(res)
+	return json.NewDecoder(hres.Body).Decode(out)
 }
 
 // InfuraTransport is a transport that operates on
@@ -0,0 +1,98 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// hangTransport never responds
+type hangTransport struct {
+	exit chan struct{}
+}
+
+func (h *hangTransport) Execute(req *RPCRequest, res *RPCResponse) error {
+	<-h.exit
+	return nil
+}
+
+// pendingTransport reports that every
+// transaction is pending
+type pendingTransport struct{}
+
+func (pendingTransport) Execute(req *RPCRequest, res *RPCResponse) error {
+	res.ID = req.ID
+	res.Result, _ = json.Marshal(&Transaction{})
+	return nil
+}
+
+func TestDoContext(t *testing.T) {
+	timeout := func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 20*time.Millisecond)
+	}
+	check := func(t *testing.T, c *Client) {
+		t.Helper()
+		ctx, cancel := timeout()
+		defer cancel()
+		var out json.RawMessage
+		if err := c.DoContext(ctx, "test_hang", nil, &out); err != context.DeadlineExceeded {
+			t.Fatalf("expected a timeout; got %v", err)
+		}
+	}
+
+	t.Run("rpc", func(t *testing.T) {
+		var server net.Conn
+		c := NewClient(func() (io.ReadWriteCloser, error) {
+			a, b := net.Pipe()
+			server = b
+			// read requests and never respond
+			go io.Copy(ioutil.Discard, b)
+			return a, nil
+		})
+		defer func() { server.Close() }()
+		check(t, c)
+		tp := c.tport.(*RPCTransport)
+		tp.lock.Lock()
+		n := len(tp.pending)
+		tp.lock.Unlock()
+		if n != 0 {
+			t.Errorf("%d requests still pending", n)
+		}
+	})
+	t.Run("http", func(t *testing.T) {
+		canceled := make(chan struct{})
+		hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the server only notices that the client
+			// went away once the body has been read
+			io.Copy(ioutil.Discard, r.Body)
+			<-r.Context().Done()
+			close(canceled)
+		}))
+		defer hs.Close()
+		check(t, NewHTTPClient(hs.URL))
+		select {
+		case <-canceled:
+		case <-time.After(10 * time.Second):
+			t.Error("HTTP request wasn't aborted")
+		}
+	})
+	t.Run("fallback", func(t *testing.T) {
+		h := &hangTransport{exit: make(chan struct{})}
+		defer close(h.exit)
+		check(t, NewClientTransport(h))
+	})
+	t.Run("wait", func(t *testing.T) {
+		s := NewSender(NewClientTransport(pendingTransport{}), &Address{})
+		ctx, cancel := timeout()
+		defer cancel()
+		if err := s.WaitContext(ctx, &Hash{}); err != context.DeadlineExceeded {
+			t.Fatalf("expected a timeout; got %v", err)
+		}
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/hex"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"strconv"
 	"strings"
 
@@ -20,13 +22,17 @@ var cmdcall = &cmd{
 
 var forcecall bool
 var noncecall int
-var gweicall int
+var gweicall string
+var maxfeecall float64
+var abicall string
 
 func init() {
 	cmdcall.fs.Init("call", flag.ExitOnError)
 	cmdcall.fs.BoolVar(&forcecall, "f", false, "force call (avoid checking jump-table)")
 	cmdcall.fs.IntVar(&noncecall, "n", -1, "call nonce")
-	cmdcall.fs.IntVar(&gweicall, "g", 4, "gas price (gwei)")
+	cmdcall.fs.StringVar(&gweicall, "g", "4", "gas price (gwei), or fee strategy ('node', 'node*<mult>', 'p<percentile>')")
+	cmdcall.fs.Float64Var(&maxfeecall, "maxfee", 0, "maximum gas price or fee per gas (gwei)")
+	cmdcall.fs.StringVar(&abicall, "abi", "", "contract ABI file (allows <fn> to be a function name)")
 }
 
 func etherstring(s string) seth.EtherType {
@@ -212,6 +218,36 @@ func parsefn(c *seth.Client, addr *seth.Address, fn string, args []string) []set
 	return callargs
 }
 
+// resolvefn resolves a function name or signature
+// to a canonical signature using the ABI in file
+func resolvefn(file, fn string, nargs int) string {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		fatalf("reading ABI: %s\n", err)
+	}
+	abi, err := seth.ParseABI(buf)
+	if err != nil {
+		fatalf("parsing ABI: %s\n", err)
+	}
+	if strings.IndexByte(fn, '(') == -1 {
+		// pick the overload by the number of arguments
+		var match []*seth.ABIDescriptor
+		for _, d := range abi.Overloads(fn) {
+			if len(d.Inputs) == nargs {
+				match = append(match, d)
+			}
+		}
+		if len(match) == 1 {
+			return match[0].Signature()
+		}
+	}
+	d, err := abi.Method(fn)
+	if err != nil {
+		fatalf("%s\n", err)
+	}
+	return d.Signature()
+}
+
 func call(fs *flag.FlagSet) {
 	args := fs.Args()
 	if len(args) < 2 {
@@ -224,14 +260,17 @@ func call(fs *flag.FlagSet) {
 		fatalf("can't parse address %q: %s\n", args[0], err)
 	}
 
+	if abicall != "" {
+		args[1] = resolvefn(abicall, args[1], len(args)-2)
+	}
+
 	c := client()
 	callargs := parsefn(c, addr, args[1], args[2:])
 
 	sign, from := signer()
 	opts := seth.CallOpts{
-		From:     &from,
-		To:       addr,
-		GasPrice: seth.NewInt(int64(gweicall) * 1e9),
+		From: &from,
+		To:   addr,
 	}
 	if noncecall >= 0 {
 		u := seth.Uint64(noncecall)
@@ -241,6 +280,10 @@ func call(fs *flag.FlagSet) {
 
 	s := seth.NewSender(c, &from)
 	s.Signer = sign
+	s.Fees = feestrategy(gweicall, maxfeecall)
+	s.Logf = func(f string, args ...interface{}) {
+		fmt.Fprintf(os.Stderr, f+"\n", args...)
+	}
 
 	h, err := s.Call(&opts)
 	if err != nil {
@@ -248,3 +291,44 @@ func call(fs *flag.FlagSet) {
 	}
 	fmt.Println(h.String())
 }
+
+// gweiint converts an amount in gwei to wei
+func gweiint(g float64) *seth.Int {
+	v := new(big.Float).Mul(big.NewFloat(g), big.NewFloat(1e9))
+	i, _ := v.Int(nil)
+	return (*seth.Int)(i)
+}
+
+// feestrategy parses the fee strategy given by the -g flag,
+// capping its fees at max gwei if max is non-zero
+func feestrategy(spec string, max float64) seth.FeeStrategy {
+	var fs seth.FeeStrategy
+	switch {
+	case spec == "node":
+		fs = &seth.NodeGasPrice{}
+	case strings.HasPrefix(spec, "node*"):
+		mult, err := strconv.ParseFloat(spec[len("node*"):], 64)
+		if err != nil || mult <= 0 {
+			fatalf("bad gas price multiplier in %q\n", spec)
+		}
+		fs = &seth.NodeGasPrice{Multiplier: mult}
+	case strings.HasPrefix(spec, "p"):
+		pct, err := strconv.ParseFloat(spec[1:], 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			fatalf("bad fee percentile in %q\n", spec)
+		}
+		fs = &seth.HistoryFees{Percentile: pct}
+	default:
+		g, err := strconv.ParseFloat(spec, 64)
+		if err != nil || g < 0 {
+			fatalf("bad gas price %q\n", spec)
+		}
+		fs = &seth.FixedFees{GasPrice: gweiint(g)}
+	}
+	if max < 0 {
+		fatalf("bad maximum fee %g\n", max)
+	} else if max > 0 {
+		fs = &seth.CappedFees{FeeStrategy: fs, Max: gweiint(max)}
+	}
+	return fs
+}
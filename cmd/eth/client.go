@@ -26,6 +26,10 @@ func client() *seth.Client {
 		}
 		return seth.NewClientTransport(t)
 	}
+	if strings.HasPrefix(url, "ws") {
+		debugf("using websocket transport %q", url)
+		return seth.NewWSClient(url)
+	}
 	if _, err := os.Stat(url); err == nil {
 		debugf("using IPC path %s", url)
 		return seth.NewClient(seth.IPCPath(url))
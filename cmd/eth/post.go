@@ -2,20 +2,36 @@ package main
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+
+	"github.com/newalchemylimited/seth"
 )
 
 var cmdpost = &cmd{
-	desc: "post a raw transaction",
-	do:   post,
+	desc:  "post a raw transaction",
+	usage: "eth post [-dry] <file|-...>",
+	do:    post,
 }
 
+var postdry bool // don't actually post
+
 func init() {
 	cmdpost.fs.Init("post", flag.ExitOnError)
+	cmdpost.fs.BoolVar(&postdry, "dry", false, "print the transactions without posting them")
+}
+
+// showtx prints a parsed raw transaction to stderr
+func showtx(tx *seth.Transaction) {
+	buf, err := json.MarshalIndent(tx, "", "\t")
+	if err != nil {
+		fatalf("fatal error: %s", err)
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", buf)
 }
 
 func post(fs *flag.FlagSet) {
@@ -53,8 +69,18 @@ func post(fs *flag.FlagSet) {
 		if err != nil {
 			fatalf("decoding arg %d: %s", i, err)
 		}
+		tx, _, err := seth.ParseRawTransaction(buf)
+		if err != nil {
+			// the node may still accept what we can't parse
+			fmt.Fprintf(os.Stderr, "warning: parsing arg %d: %s\n", i, err)
+		} else {
+			showtx(tx)
+		}
 		out = append(out, buf)
 	}
+	if postdry {
+		return
+	}
 
 	c := client()
 	for i, b := range out {
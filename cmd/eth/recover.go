@@ -11,15 +11,19 @@ import (
 
 var cmdrecover = &cmd{
 	desc:  "recover the address from a signature",
-	usage: "eth recover <sig> [hash]",
+	usage: "eth recover [-p] [-a addr] <sig> [hash]",
 	do:    ecrecover,
 }
 
-var ecrpub bool // print public key
+var ecrpub bool      // print public key
+var ecrpersonal bool // input is a personal message
+var ecraddr string   // expected address
 
 func init() {
 	cmdrecover.fs.Init("recover", flag.ExitOnError)
 	cmdrecover.fs.BoolVar(&ecrpub, "pub", false, "print public key")
+	cmdrecover.fs.BoolVar(&ecrpersonal, "p", false, "input is an EIP-191 personal message")
+	cmdrecover.fs.StringVar(&ecraddr, "a", "", "verify that the signature was produced by `addr`")
 }
 
 func ecrecover(fs *flag.FlagSet) {
@@ -32,6 +36,10 @@ func ecrecover(fs *flag.FlagSet) {
 	if err != nil {
 		fatal("signature:", err)
 	}
+	// accept the v values produced by personal_sign
+	if sig[64] == 27 || sig[64] == 28 {
+		sig[64] -= 27
+	}
 
 	var hash seth.Hash
 
@@ -40,7 +48,13 @@ func ecrecover(fs *flag.FlagSet) {
 		if err != nil {
 			fatal("failed reading:", err)
 		}
-		hash = seth.HashBytes(b)
+		if ecrpersonal {
+			hash = seth.HashPersonalMessage(b)
+		} else {
+			hash = seth.HashBytes(b)
+		}
+	} else if ecrpersonal {
+		fatal("eth recover: -p reads the message from stdin; it cannot be used with a hash")
 	} else if err := hash.FromString(args[1]); err != nil {
 		fatal("hash:", err)
 	}
@@ -50,6 +64,16 @@ func ecrecover(fs *flag.FlagSet) {
 		fatal("recover:", err)
 	}
 
+	if ecraddr != "" {
+		want, err := seth.ParseAddress(ecraddr)
+		if err != nil {
+			fatal("address:", err)
+		}
+		if got := pk.Address(); *got != *want {
+			fatalf("signature was produced by %s, not %s\n", got, want)
+		}
+	}
+
 	if ecrpub {
 		fmt.Println(pk)
 	} else {
@@ -21,6 +21,8 @@ var signprefix string // prefix to add to signature
 var sighex bool       // output hex
 var sigjson bool      // output in json
 var hashed bool       // input is already hashed
+var typed bool        // input is EIP-712 typed data
+var personal bool     // input is a personal message
 
 func bool2i(b bool) int {
 	if b {
@@ -33,6 +35,8 @@ func init() {
 	cmdsign.fs.Init("sign", flag.ExitOnError)
 	cmdsign.fs.StringVar(&signprefix, "prefix", "", "signing prefix")
 	cmdsign.fs.BoolVar(&hashed, "h", false, "input already hashed")
+	cmdsign.fs.BoolVar(&typed, "t", false, "input is EIP-712 typed data in JSON")
+	cmdsign.fs.BoolVar(&personal, "p", false, "sign input as an EIP-191 personal message")
 	cmdsign.fs.BoolVar(&sighex, "x", false, "output is in hex instead of binary")
 	cmdsign.fs.BoolVar(&sigjson, "j", false, "output is in json instead of binary")
 }
@@ -45,6 +49,12 @@ func sign(fs *flag.FlagSet) {
 	if hashed && signprefix != "" {
 		fatalf("cannot add a prefix to hashed plaintext\n")
 	}
+	if typed && (hashed || signprefix != "") {
+		fatalf("eth sign: -t cannot be combined with -h or -prefix\n")
+	}
+	if personal && (typed || hashed || signprefix != "") {
+		fatalf("eth sign: -p cannot be combined with -t, -h, or -prefix\n")
+	}
 
 	if bool2i(sighex)+bool2i(sigjson) > 1 {
 		fatalf("eth sign: cannot specify more than one of -x or -j at a time\n")
@@ -59,7 +69,19 @@ func sign(fs *flag.FlagSet) {
 		fatalf("reading: %s\n", err)
 	}
 	var h seth.Hash
-	if hashed {
+	if typed {
+		td, err := seth.ParseTypedData(buf)
+		if err != nil {
+			fatalf("parsing typed data: %s\n", err)
+		}
+		th, err := seth.HashTypedData(td)
+		if err != nil {
+			fatalf("hashing typed data: %s\n", err)
+		}
+		h = *th
+	} else if personal {
+		h = seth.HashPersonalMessage(buf)
+	} else if hashed {
 		if len(buf) != len(h[:]) {
 			fatalf("input length %d is not a keccak256 hash\n", len(buf))
 		}
@@ -0,0 +1,168 @@
+package seth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownEvent is returned by ABI.DecodeLog when
+// a log doesn't match any of the events in the ABI.
+var ErrUnknownEvent = errors.New("seth: log does not match any known event")
+
+// Topic returns the first topic of logs emitted by
+// the event described by d, which is the hash of its
+// signature. (Anonymous events don't emit this topic.)
+func (d *ABIDescriptor) Topic() Hash {
+	return HashString(d.Signature())
+}
+
+// DecodeLog decodes the arguments of the event described
+// by d from l into dst, which should be a pointer to a
+// struct or a map with string keys. Struct fields are
+// matched to event arguments in the same way as tuple
+// members (see EncodeParams), and map values are decoded
+// as described in DecodeParams.
+//
+// Indexed arguments are decoded from the log topics, and
+// the rest are decoded from the log data. Indexed arguments
+// of dynamic types (strings, bytes, arrays, and tuples) are
+// stored in logs as the hash of their encoding, so they can
+// only be decoded into Hash, [32]byte, or a byte slice.
+func (d *ABIDescriptor) DecodeLog(l *Log, dst interface{}) error {
+	if d.Type != "event" {
+		return fmt.Errorf("%s is not an event", d.Name)
+	}
+	t, err := paramsType(d.Inputs)
+	if err != nil {
+		return err
+	}
+	topics := l.Topics
+	if !d.Anonymous {
+		topic := d.Topic()
+		if len(topics) == 0 || !bytes.Equal(topics[0], topic[:]) {
+			return fmt.Errorf("log is not a %s event", d.Signature())
+		}
+		topics = topics[1:]
+	}
+	indexed := 0
+	for i := range d.Inputs {
+		if d.Inputs[i].Indexed {
+			indexed++
+		}
+	}
+	if indexed != len(topics) {
+		return fmt.Errorf("event %s has %d indexed arguments, but the log has %d topics", d.Name, indexed, len(topics))
+	}
+
+	// unnamed arguments are named by position
+	for i := range t.names {
+		if t.names[i] == "" {
+			t.names[i] = "arg" + strconv.Itoa(i)
+		}
+	}
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("cannot decode into non-pointer %T", dst)
+	}
+	dec := abiDecoder{data: l.Data}
+	vals, done, err := dec.members(t, 0, deref(v), d.Name, func() error {
+		return fmt.Errorf("cannot decode event %s into %T", d.Name, dst)
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		types []*abiType
+		dvals []reflect.Value
+		names []string
+	)
+	for i := range d.Inputs {
+		path := d.Name + "." + t.names[i]
+		if !d.Inputs[i].Indexed {
+			types = append(types, t.elems[i])
+			dvals = append(dvals, vals[i])
+			names = append(names, path)
+			continue
+		}
+		et := t.elems[i]
+		switch et.kind {
+		case abiBytes, abiString, abiSlice, abiArray, abiTuple:
+			et = &abiType{kind: abiFixedBytes, size: 32}
+		}
+		tdec := abiDecoder{data: topics[0]}
+		if err := tdec.decode(et, 0, vals[i], path); err != nil {
+			return err
+		}
+		topics = topics[1:]
+	}
+	err = dec.decodeSeq(types, 0, dvals, func(i int) string { return names[i] })
+	if err != nil {
+		return err
+	}
+	done()
+	return nil
+}
+
+// Event finds an event by its name or signature.
+func (a *ABI) Event(name string) (*ABIDescriptor, error) {
+	var match []*ABIDescriptor
+	if strings.IndexByte(name, '(') != -1 {
+		ename, t, err := parseSignature(strings.Join(strings.Fields(name), ""))
+		if err != nil {
+			return nil, err
+		}
+		name = ename + t.String()
+	}
+	for i := range a.Descriptors {
+		d := &a.Descriptors[i]
+		if d.Type == "event" && (d.Name == name || d.Signature() == name) {
+			match = append(match, d)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return nil, fmt.Errorf("no event %q", name)
+	case 1:
+		return match[0], nil
+	default:
+		return nil, fmt.Errorf("event %q is overloaded (%s)", name, signatures(match))
+	}
+}
+
+// DecodeLog finds the event in the ABI that emitted l, decodes
+// its arguments into dst (see ABIDescriptor.DecodeLog), and
+// returns the event descriptor. Logs are matched to events by
+// their first topic. Logs that don't match any event are tried
+// against each of the anonymous events in the ABI, and they are
+// decoded as the only anonymous event that can decode them.
+//
+// If no event matches the log, ErrUnknownEvent is returned.
+func (a *ABI) DecodeLog(l *Log, dst interface{}) (*ABIDescriptor, error) {
+	if len(l.Topics) > 0 && len(l.Topics[0]) == len(Hash{}) {
+		var h Hash
+		copy(h[:], l.Topics[0])
+		if d := a.events[h]; d != nil {
+			return d, d.DecodeLog(l, dst)
+		}
+	}
+	var match []*ABIDescriptor
+	for _, d := range a.anon {
+		var m map[string]interface{}
+		if d.DecodeLog(l, &m) == nil {
+			match = append(match, d)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return nil, ErrUnknownEvent
+	case 1:
+		return match[0], match[0].DecodeLog(l, dst)
+	default:
+		return nil, fmt.Errorf("log matches more than one anonymous event (%s)", signatures(match))
+	}
+}
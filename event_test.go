@@ -0,0 +1,109 @@
+package seth
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const eventABI = `[
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[
+		{"name":"from","type":"address","indexed":true},
+		{"name":"to","type":"address","indexed":true},
+		{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Named","anonymous":false,"inputs":[
+		{"name":"name","type":"string","indexed":true},
+		{"name":"id","type":"int32","indexed":true},
+		{"name":"","type":"string","indexed":false},
+		{"name":"tags","type":"bytes4[]","indexed":false}]},
+	{"type":"event","name":"Ping","anonymous":true,"inputs":[
+		{"name":"who","type":"address","indexed":true},
+		{"name":"seq","type":"uint64","indexed":false}]}
+]`
+
+func topic(b []byte) Data {
+	d := make(Data, 32)
+	copy(d[32-len(b):], b)
+	return d
+}
+
+func TestDecodeLog(t *testing.T) {
+	t.Parallel()
+	a := MustParseABI(eventABI)
+
+	var from, to Address
+	from[19], to[19] = 1, 2
+	tr, err := a.Event("Transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Topic() != ERC20Transfer {
+		t.Errorf("bad Transfer topic %s", tr.Topic())
+	}
+	th := tr.Topic()
+	l := &Log{
+		Topics: []Data{th[:], topic(from[:]), topic(to[:])},
+		Data:   topic([]byte{0x10}),
+	}
+	var out struct {
+		From, To Address
+		Value    big.Int
+	}
+	d, err := a.DecodeLog(l, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != tr || out.From != from || out.To != to || out.Value.Int64() != 16 {
+		t.Errorf("got %+v", out)
+	}
+
+	// indexed dynamic types are hashed
+	named, err := a.Event("Named(string,int32,string,bytes4[])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nh, sh := named.Topic(), HashString("bob")
+	data, err := EncodeParams([]ABIParam{{Type: "string"}, {Type: "bytes4[]"}}, "hi", [][]byte{[]byte("abcd")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l = &Log{
+		Topics: []Data{nh[:], sh[:], words(t, "", strings.Repeat("f", 64))},
+		Data:   data,
+	}
+	var m map[string]interface{}
+	if _, err := a.DecodeLog(l, &m); err != nil {
+		t.Fatal(err)
+	}
+	if h, ok := m["name"].(Data); !ok || string(h) != string(sh[:]) {
+		t.Errorf("name: got %v", m["name"])
+	}
+	if id := m["id"].(*big.Int); id.Int64() != -1 {
+		t.Errorf("id: got %v", id)
+	}
+	if m["arg2"] != "hi" {
+		t.Errorf("arg2: got %v", m["arg2"])
+	}
+	if tags := m["tags"].([]interface{}); len(tags) != 1 || string(tags[0].(Data)) != "abcd" {
+		t.Errorf("tags: got %v", m["tags"])
+	}
+
+	// anonymous events have no signature topic
+	l = &Log{Topics: []Data{topic(from[:])}, Data: topic([]byte{7})}
+	var ping struct {
+		Who Address
+		Seq uint64
+	}
+	d, err = a.DecodeLog(l, &ping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "Ping" || ping.Who != from || ping.Seq != 7 {
+		t.Errorf("got %+v", ping)
+	}
+
+	l = &Log{Topics: []Data{topic([]byte{1}), topic([]byte{2})}}
+	if _, err := a.DecodeLog(l, &m); err != ErrUnknownEvent {
+		t.Errorf("expected ErrUnknownEvent; got %v", err)
+	}
+}
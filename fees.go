@@ -0,0 +1,235 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Fees are the fees offered by a transaction. Legacy
+// transactions set GasPrice, and EIP-1559 transactions
+// set MaxFeePerGas and MaxPriorityFeePerGas.
+type Fees struct {
+	GasPrice             *Int
+	MaxFeePerGas         *Int
+	MaxPriorityFeePerGas *Int
+}
+
+// feesOf returns the fees set in opts
+func feesOf(opts *CallOpts) Fees {
+	return Fees{
+		GasPrice:             opts.GasPrice,
+		MaxFeePerGas:         opts.MaxFeePerGas,
+		MaxPriorityFeePerGas: opts.MaxPriorityFeePerGas,
+	}
+}
+
+// set sets the fees in opts
+func (f *Fees) set(opts *CallOpts) {
+	opts.GasPrice = f.GasPrice
+	opts.MaxFeePerGas = f.MaxFeePerGas
+	opts.MaxPriorityFeePerGas = f.MaxPriorityFeePerGas
+}
+
+// gwei formats an amount of wei in gwei
+func gwei(i *Int) string {
+	r := new(big.Rat).SetFrac(i.Big(), big.NewInt(1e9))
+	s := r.FloatString(9)
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	return s + " gwei"
+}
+
+// String returns a description of the fees in gwei.
+func (f *Fees) String() string {
+	if f.GasPrice != nil {
+		return "gas price " + gwei(f.GasPrice)
+	}
+	if f.MaxFeePerGas == nil || f.MaxPriorityFeePerGas == nil {
+		return "default fees"
+	}
+	return "max fee " + gwei(f.MaxFeePerGas) + ", priority fee " + gwei(f.MaxPriorityFeePerGas)
+}
+
+// A FeeStrategy chooses the fees offered by transactions.
+type FeeStrategy interface {
+	// Fees returns the fees to offer for a
+	// transaction that is about to be sent
+	// using the client c.
+	Fees(ctx context.Context, c *Client) (*Fees, error)
+}
+
+// FixedFees is a FeeStrategy that always chooses the same fees.
+type FixedFees Fees
+
+// Fees implements FeeStrategy
+func (f *FixedFees) Fees(ctx context.Context, c *Client) (*Fees, error) {
+	fees := Fees(*f)
+	return &fees, nil
+}
+
+// NodeGasPrice is a FeeStrategy for legacy transactions
+// that offers the gas price suggested by the node (see
+// (*Client).GasPrice) times Multiplier. A Multiplier of
+// zero is treated as one.
+type NodeGasPrice struct {
+	Multiplier float64
+}
+
+// Fees implements FeeStrategy
+func (n *NodeGasPrice) Fees(ctx context.Context, c *Client) (*Fees, error) {
+	var price Int
+	if err := c.DoContext(ctx, "eth_gasPrice", nil, &price); err != nil {
+		return nil, err
+	}
+	if n.Multiplier != 0 {
+		mul(&price, n.Multiplier)
+	}
+	return &Fees{GasPrice: &price}, nil
+}
+
+// mul multiplies i by f, rounding down
+func mul(i *Int, f float64) {
+	v := new(big.Float).SetInt(i.Big())
+	v.Mul(v, big.NewFloat(f))
+	v.Int(i.Big())
+}
+
+// Defaults for HistoryFees.
+const (
+	DefaultFeeBlocks     = 10
+	DefaultFeePercentile = 50
+)
+
+// HistoryFees is a FeeStrategy for EIP-1559 transactions
+// that chooses fees based on the fee history of recent
+// blocks (see (*Client).FeeHistory). The priority fee is
+// the median, over the last Blocks blocks, of the Percentile'th
+// percentile of the priority fees paid in each block. The
+// maximum fee is the priority fee plus twice the base fee
+// of the pending block, which covers the base fee rising
+// for several full blocks in a row.
+type HistoryFees struct {
+	Blocks     int     // number of blocks; DefaultFeeBlocks if zero
+	Percentile float64 // percentile of priority fees; DefaultFeePercentile if zero
+}
+
+// Fees implements FeeStrategy
+func (h *HistoryFees) Fees(ctx context.Context, c *Client) (*Fees, error) {
+	blocks, pct := h.Blocks, h.Percentile
+	if blocks <= 0 {
+		blocks = DefaultFeeBlocks
+	}
+	if pct == 0 {
+		pct = DefaultFeePercentile
+	}
+	hist, err := c.FeeHistoryContext(ctx, blocks, Latest, pct)
+	if err != nil {
+		return nil, err
+	}
+	if len(hist.BaseFee) == 0 {
+		return nil, errors.New("seth: empty fee history")
+	}
+	var tips []*big.Int
+	for i := range hist.Reward {
+		if len(hist.Reward[i]) > 0 {
+			tips = append(tips, hist.Reward[i][0].Big())
+		}
+	}
+	tip := new(Int)
+	if len(tips) > 0 {
+		sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+		tip.Big().Set(tips[len(tips)/2])
+	}
+	// the last base fee is that of the pending block
+	max := new(Int)
+	max.Big().Lsh(hist.BaseFee[len(hist.BaseFee)-1].Big(), 1)
+	max.Big().Add(max.Big(), tip.Big())
+	return &Fees{MaxFeePerGas: max, MaxPriorityFeePerGas: tip}, nil
+}
+
+// CappedFees is a FeeStrategy that limits the
+// fees chosen by another strategy to Max, which
+// caps the gas price of legacy transactions and
+// the maximum fee of EIP-1559 transactions.
+type CappedFees struct {
+	FeeStrategy
+	Max *Int
+}
+
+// Fees implements FeeStrategy
+func (c *CappedFees) Fees(ctx context.Context, cl *Client) (*Fees, error) {
+	f, err := c.FeeStrategy.Fees(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	fees := *f
+	capped := func(v *Int) *Int {
+		if v != nil && v.Cmp(c.Max) > 0 {
+			return c.Max
+		}
+		return v
+	}
+	fees.GasPrice = capped(fees.GasPrice)
+	fees.MaxFeePerGas = capped(fees.MaxFeePerGas)
+	fees.MaxPriorityFeePerGas = capped(fees.MaxPriorityFeePerGas)
+	return &fees, nil
+}
+
+// FeeHistory is the fee history of a range of blocks.
+type FeeHistory struct {
+	OldestBlock Uint64 `json:"oldestBlock"`
+	// BaseFee holds the base fee of each block,
+	// followed by that of the block after the newest.
+	BaseFee      []Int     `json:"baseFeePerGas"`
+	GasUsedRatio []float64 `json:"gasUsedRatio"`
+	// Reward holds the requested percentiles
+	// of the priority fees paid in each block.
+	Reward [][]Int `json:"reward"`
+}
+
+// FeeHistory gets the fee history of the given number of blocks
+// up to and including the newest block, with the given percentiles
+// of the priority fees paid in each block.
+func (c *Client) FeeHistory(blocks int, newest int64, percentiles ...float64) (*FeeHistory, error) {
+	return c.FeeHistoryContext(context.Background(), blocks, newest, percentiles...)
+}
+
+// FeeHistoryContext is like FeeHistory, but it takes a context.
+func (c *Client) FeeHistoryContext(ctx context.Context, blocks int, newest int64, percentiles ...float64) (*FeeHistory, error) {
+	if percentiles == nil {
+		percentiles = []float64{}
+	}
+	pcts, _ := json.Marshal(percentiles)
+	params := []json.RawMessage{itox(int64(blocks)), itobs(newest), pcts}
+	h := new(FeeHistory)
+	if err := c.DoContext(ctx, "eth_feeHistory", params, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// chooseFees sets the fees in opts if none have been
+// specified, and returns the fees that opts offers
+func (s *Sender) chooseFees(ctx context.Context, opts *CallOpts) (Fees, error) {
+	if opts.GasPrice != nil || opts.MaxFeePerGas != nil || opts.MaxPriorityFeePerGas != nil {
+		return feesOf(opts), nil
+	}
+	if s.Fees == nil {
+		s.setFees(opts)
+		return feesOf(opts), nil
+	}
+	f, err := s.Fees.Fees(ctx, s.Client)
+	if err != nil {
+		return Fees{}, fmt.Errorf("seth: choosing fees: %s", err)
+	}
+	f.set(opts)
+	return *f, nil
+}
@@ -0,0 +1,85 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// newFeeNode returns a fake node that serves
+// the given gas price and fee history
+func newFeeNode(price int64, hist string) *testNode {
+	n := newTestNode()
+	n.handle("eth_gasPrice", func([]json.RawMessage) (interface{}, error) {
+		return NewInt(price), nil
+	})
+	n.handle("eth_feeHistory", func([]json.RawMessage) (interface{}, error) {
+		return json.RawMessage(hist), nil
+	})
+	return n
+}
+
+func TestFeeStrategies(t *testing.T) {
+	node := newFeeNode(20e9, `{"oldestBlock":"0x10",`+
+		`"baseFeePerGas":["0x2540be400","0x2540be400","0x2540be400","0x2540be400"],`+
+		`"gasUsedRatio":[0.5,0.5,0.5],`+
+		`"reward":[["0x3b9aca00"],["0x77359400"],["0xb2d05e00"]]}`)
+	c := NewClientTransport(node)
+	ctx := context.Background()
+	gwei := func(g int64) *Int { return NewInt(g * 1e9) }
+
+	tests := []struct {
+		fs   FeeStrategy
+		want Fees
+	}{
+		{&FixedFees{GasPrice: gwei(7)}, Fees{GasPrice: gwei(7)}},
+		{&NodeGasPrice{}, Fees{GasPrice: gwei(20)}},
+		{&NodeGasPrice{Multiplier: 1.5}, Fees{GasPrice: gwei(30)}},
+		// median tip of 2 gwei, plus twice the 10 gwei base fee
+		{&HistoryFees{}, Fees{MaxFeePerGas: gwei(22), MaxPriorityFeePerGas: gwei(2)}},
+		{&CappedFees{FeeStrategy: &NodeGasPrice{Multiplier: 2}, Max: gwei(25)}, Fees{GasPrice: gwei(25)}},
+		{&CappedFees{FeeStrategy: &HistoryFees{}, Max: gwei(15)}, Fees{MaxFeePerGas: gwei(15), MaxPriorityFeePerGas: gwei(2)}},
+	}
+	for i, test := range tests {
+		f, err := test.fs.Fees(ctx, c)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if f.String() != test.want.String() {
+			t.Errorf("%d: got %s, want %s", i, f, &test.want)
+		}
+	}
+
+	// the sender logs the fees chosen for a transaction
+	c.SetChainID(1)
+	key := GenPrivateKey()
+	s := NewSender(c, key.Address())
+	s.Signer = key.Signer()
+	s.Fees = &HistoryFees{Percentile: 90}
+	var logged string
+	s.Logf = func(format string, args ...interface{}) {
+		logged = fmt.Sprintf(format, args...)
+	}
+	to := Address{1}
+	h, err := s.Call(&CallOpts{To: &to, Gas: NewInt(21000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := node.last()
+	if tx.MaxFeePerGas == nil || tx.MaxFeePerGas.Cmp(gwei(22)) != 0 ||
+		tx.MaxPriorityFeePerGas == nil || tx.MaxPriorityFeePerGas.Cmp(gwei(2)) != 0 {
+		t.Errorf("transaction sent with fees %v and %v", tx.MaxFeePerGas, tx.MaxPriorityFeePerGas)
+	}
+	if want := h.String() + ": max fee 22 gwei, priority fee 2 gwei"; logged != want {
+		t.Errorf("logged %q, want %q", logged, want)
+	}
+
+	// fees given in the call options are left alone
+	if _, err := s.Call(&CallOpts{To: &to, Gas: NewInt(21000), GasPrice: gwei(3)}); err != nil {
+		t.Fatal(err)
+	}
+	if tx := node.last(); tx.GasPrice.Cmp(gwei(3)) != 0 || tx.MaxFeePerGas != nil {
+		t.Errorf("transaction sent with gas price %v", &tx.GasPrice)
+	}
+}
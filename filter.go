@@ -2,31 +2,81 @@ package seth
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
-	"math"
 	"math/big"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultPollInterval is the default interval at which
+// a filter polls the node for new logs when the client's
+// transport doesn't support subscriptions, and at which
+// a Sender checks on a pending transaction.
+const DefaultPollInterval = time.Second
+
+// maxReinstall is the number of times a filter
+// tries to reinstall itself before giving up
+const maxReinstall = 5
+
+// errFilterClosed is returned from (*Filter).reinstall
+// when the filter is closed while it is retrying
+var errFilterClosed = errors.New("seth: filter closed")
+
 // Filter represents a Log filter
 type Filter struct {
-	id  int64
-	c   *Client
-	out chan *Log
+	c    *Client
+	req  *newFilterReq // used to reinstall the filter
+	out  chan *Log
+	exit chan struct{}
+
+	// owned by frecv after the filter is created
+	id        int64
+	sub       *Subscription
+	subscribe bool   // use sub rather than polling id
+	next      logPos // first log not yet delivered
 
 	lock   sync.Mutex // guards below
-	exit   chan struct{}
 	err    error
 	closed bool
 	poll   bool // continue polling after first fetch
 }
 
+// SetPollInterval sets the interval at which filters poll
+// for new logs when the transport doesn't support subscriptions,
+// and at which senders check on pending transactions. It only
+// affects filters created after it is called.
+func (c *Client) SetPollInterval(d time.Duration) {
+	atomic.StoreInt64(&c.pollint, int64(d))
+}
+
+func (c *Client) pollInterval() time.Duration {
+	if d := atomic.LoadInt64(&c.pollint); d > 0 {
+		return time.Duration(d)
+	}
+	return DefaultPollInterval
+}
+
+// logPos is the position of a log in the chain
+type logPos struct {
+	block, index uint64
+}
+
+func (p logPos) less(q logPos) bool {
+	return p.block < q.block || (p.block == q.block && p.index < q.index)
+}
+
 // Out returns the channel of output logs.
 // The output channel will be closed when the
 // filter is closed, or when the filter encounters
 // an error, in which case (*Filter).Err() will be non-nil.
+//
+// When the filter is backed by a subscription, the
+// subscription fails if the logs aren't read quickly
+// enough (see ErrSubscriptionOverflow).
 func (f *Filter) Out() <-chan *Log { return f.out }
 
 func (f *Filter) seterr(err error) {
@@ -53,50 +103,332 @@ func (f *Filter) Close() {
 	if !f.closed {
 		close(f.exit)
 		f.closed = true
-		f.c.deleteFilter(f.id)
 	}
 	f.lock.Unlock()
 }
 
+// A LogQuery selects logs by the address of the
+// contract that emitted them and by their topics.
+type LogQuery struct {
+	Address AddressList // if empty, logs from any address match
+	Topics  TopicList
+}
+
+// An AddressList is a set of addresses in a log query.
+// A log matches the list if it was emitted by any of
+// the addresses in it, or if the list is empty.
+type AddressList []Address
+
+// MarshalJSON implements json.Marshaler.
+// A list with one address is encoded as
+// that address rather than as an array.
+func (a AddressList) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(&a[0])
+	}
+	return json.Marshal([]Address(a))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *AddressList) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		*a = make(AddressList, 1)
+		return json.Unmarshal(b, &(*a)[0])
+	}
+	return json.Unmarshal(b, (*[]Address)(a))
+}
+
+// Match returns whether addr is in the list
+// or the list is empty.
+func (a AddressList) Match(addr *Address) bool {
+	if len(a) == 0 {
+		return true
+	}
+	for i := range a {
+		if a[i] == *addr {
+			return true
+		}
+	}
+	return false
+}
+
+// A TopicList is the set of topics in a log query.
+// A log matches the list if, for each position in
+// the list, the log's topic at that position is any
+// of the alternatives in it. (An empty position
+// matches any topic.)
+type TopicList [][]Hash
+
+// Topics returns a TopicList that matches exactly the
+// given topics. A nil topic matches any value.
+func Topics(topics ...*Hash) TopicList {
+	t := make(TopicList, len(topics))
+	for i, h := range topics {
+		if h != nil {
+			t[i] = []Hash{*h}
+		}
+	}
+	return t
+}
+
+// MarshalJSON implements json.Marshaler.
+// Empty positions are encoded as null, and positions
+// with one alternative are encoded as that alternative
+// rather than as an array.
+func (t TopicList) MarshalJSON() ([]byte, error) {
+	out := make([]interface{}, len(t))
+	for i := range t {
+		switch len(t[i]) {
+		case 0:
+		case 1:
+			out[i] = &t[i][0]
+		default:
+			out[i] = t[i]
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *TopicList) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*t = make(TopicList, len(raw))
+	for i, r := range raw {
+		switch {
+		case len(r) > 0 && r[0] == '"':
+			(*t)[i] = make([]Hash, 1)
+			if err := json.Unmarshal(r, &(*t)[i][0]); err != nil {
+				return err
+			}
+		default:
+			if err := json.Unmarshal(r, &(*t)[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Match returns whether the topic at position i
+// is one of the alternatives at that position.
+func (t TopicList) Match(i int, topic *Hash) bool {
+	if i >= len(t) || len(t[i]) == 0 {
+		return true
+	}
+	for j := range t[i] {
+		if t[i][j] == *topic {
+			return true
+		}
+	}
+	return false
+}
+
 type newFilterReq struct {
+	BlockHash *Hash           `json:"blockHash,omitempty"`
 	FromBlock json.RawMessage `json:"fromBlock,omitempty"`
 	ToBlock   json.RawMessage `json:"toBlock,omitempty"`
-	Address   *Address        `json:"address,omitempty"`
-	Topics    []*Hash         `json:"topics,omitempty"`
+	Address   AddressList     `json:"address,omitempty"`
+	Topics    TopicList       `json:"topics,omitempty"`
 }
 
 func frecv(f *Filter) {
+	defer close(f.out)
+	defer func() {
+		// don't bother uninstalling a filter
+		// that the node has already lost
+		// (subscriptions know whether or not
+		// they are still installed)
+		if f.subscribe || f.Err() == nil {
+			f.uninstall()
+		}
+	}()
+	if f.subscribe {
+		f.recvsub()
+		return
+	}
 	logs, err := f.c.getLogs(f.id)
 	if err != nil {
 		f.seterr(err)
-		goto done
+		return
 	}
-	for i := range logs {
-		f.out <- &logs[i]
-	}
-	if !f.poll {
-		goto done
+	if !f.send(logs) || !f.poll {
+		return
 	}
+	ticker := time.NewTicker(f.c.pollInterval())
+	defer ticker.Stop()
 	for {
-		ticker := time.NewTicker(time.Second)
 		select {
 		case <-f.exit:
-			ticker.Stop()
-			goto done
+			return
 		case <-ticker.C:
-			logs, err := f.c.getUpdates(f.id)
-			if err != nil {
+		}
+		logs, err := f.c.getUpdates(f.id)
+		if isFilterNotFound(err) {
+			// the node has forgotten about the filter
+			// (it may have restarted), so reinstall it
+			err = f.reinstall()
+		}
+		if err == errFilterClosed {
+			return
+		} else if err != nil {
+			f.seterr(err)
+			return
+		}
+		if !f.send(logs) {
+			return
+		}
+	}
+}
+
+// recvsub receives logs from a subscription
+func (f *Filter) recvsub() {
+	for {
+		var msg json.RawMessage
+		var ok bool
+		select {
+		case <-f.exit:
+			return
+		case msg, ok = <-f.sub.Out():
+		}
+		if !ok {
+			err := f.sub.Err()
+			if err != ErrSubscriptionOverflow {
+				// the connection was lost; resubscribe
+				err = f.reinstall()
+			}
+			if err == errFilterClosed {
+				return
+			} else if err != nil {
 				f.seterr(err)
-				ticker.Stop()
-				goto done
+				return
 			}
-			for i := range logs {
-				f.out <- &logs[i]
+			continue
+		}
+		l := new(Log)
+		if err := json.Unmarshal(msg, l); err != nil {
+			f.seterr(err)
+			return
+		}
+		if !f.deliver(l) {
+			return
+		}
+	}
+}
+
+// send sends logs to the output channel and
+// returns false if the filter was closed first
+func (f *Filter) send(logs []Log) bool {
+	for i := range logs {
+		if !f.deliver(&logs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// deliver sends l to the output channel unless it has
+// already been delivered, and returns false if the filter
+// was closed first
+func (f *Filter) deliver(l *Log) bool {
+	if l.BlockNumber != nil && l.LogIndex != nil {
+		pos := logPos{block: uint64(*l.BlockNumber), index: uint64(*l.LogIndex)}
+		switch {
+		case l.Removed:
+			// the logs from here on have been
+			// reorganized out of the chain
+			if pos.less(f.next) {
+				f.next = pos
 			}
+		case pos.less(f.next):
+			// seen both by a backfill and
+			// by the reinstalled filter
+			return true
+		default:
+			f.next = logPos{block: pos.block, index: pos.index + 1}
+		}
+	}
+	select {
+	case f.out <- l:
+		return true
+	case <-f.exit:
+		return false
+	}
+}
+
+// install creates the filter or subscription on the node
+func (f *Filter) install() error {
+	if f.subscribe {
+		sub, err := f.c.Subscribe("logs", &newFilterReq{Address: f.req.Address, Topics: f.req.Topics})
+		if err != nil {
+			return err
 		}
+		f.sub = sub
+		return nil
+	}
+	buf, err := json.Marshal(f.req)
+	if err != nil {
+		return err
 	}
-done:
-	close(f.out)
+	var out Int
+	err = f.c.Do("eth_newFilter", []json.RawMessage{buf}, &out)
+	if err != nil {
+		return err
+	}
+	f.id = (*big.Int)(&out).Int64()
+	return nil
+}
+
+// reinstall tries to install the filter again, waiting
+// between attempts, and then delivers the logs that were
+// missed while the filter wasn't installed
+func (f *Filter) reinstall() error {
+	var err error
+	for i := 0; i < maxReinstall; i++ {
+		if i > 0 {
+			select {
+			case <-f.exit:
+				return errFilterClosed
+			case <-time.After(f.c.pollInterval()):
+			}
+		}
+		if err = f.install(); err == nil {
+			return f.backfill()
+		}
+	}
+	return err
+}
+
+// backfill delivers the logs from the first block
+// that may not have been delivered up to the latest
+// block (logs that were already delivered, and logs
+// that the new filter delivers again, are skipped)
+func (f *Filter) backfill() error {
+	s := f.c.GetLogs(&LogQuery{Address: f.req.Address, Topics: f.req.Topics}, &ScanOptions{From: int64(f.next.block), To: -1})
+	defer s.Close()
+	for l := range s.Out() {
+		if !f.deliver(l) {
+			return errFilterClosed
+		}
+	}
+	return s.Err()
+}
+
+func (f *Filter) uninstall() {
+	if f.subscribe {
+		f.sub.Close()
+	} else {
+		f.c.deleteFilter(f.id)
+	}
+}
+
+// isFilterNotFound returns whether or not err
+// indicates that the node doesn't know about a filter
+func isFilterNotFound(err error) bool {
+	e, ok := err.(*RPCError)
+	return ok && strings.Contains(strings.ToLower(e.Message), "filter not found")
 }
 
 func (c *Client) getLogs(id int64) ([]Log, error) {
@@ -135,10 +467,33 @@ func itox(i int64) json.RawMessage {
 // If 'start' and 'end' are non-negative, then they specify the range of blocks in which to
 // search. Otherwise, the filter starts at the latest block.
 func (c *Client) FilterTopics(topics []*Hash, addr *Address, start, end int64) (*Filter, error) {
-	_ = math.MaxInt64
+	q := &LogQuery{Topics: Topics(topics...)}
+	if addr != nil {
+		q.Address = AddressList{*addr}
+	}
+	return c.Filter(q, start, end)
+}
+
+// Filter creates a log filter that matches the given query.
+// If 'start' and 'end' are non-negative, then they specify the range of blocks in which to
+// search. Otherwise, the filter starts at the latest block.
+//
+// A filter that starts at the latest block and has no end
+// continues to yield new logs until it is closed. If the client's
+// transport supports subscriptions, those logs are pushed to the
+// filter with eth_subscribe (and only logs from new blocks are
+// yielded); otherwise, the filter polls for them (see SetPollInterval).
+// If the node forgets about the filter (or the subscription is
+// lost), the filter is reinstalled, and the logs that it missed in
+// the meantime are fetched with GetLogs.
+//
+// Nodes often limit the number of logs returned for a range
+// of blocks, so large historical ranges should be scanned
+// with GetLogs instead.
+func (c *Client) Filter(q *LogQuery, start, end int64) (*Filter, error) {
 	req := &newFilterReq{
-		Address: addr,
-		Topics:  topics,
+		Address: q.Address,
+		Topics:  q.Topics,
 	}
 	poll := false
 	if start < 0 {
@@ -152,17 +507,27 @@ func (c *Client) FilterTopics(topics []*Hash, addr *Address, start, end int64) (
 	} else {
 		req.ToBlock = itox(end)
 	}
-	buf, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	f := &Filter{c: c, req: req, out: make(chan *Log, 20), exit: make(chan struct{}), poll: poll}
+	if _, ok := c.tport.(subscriber); ok && poll && end < 0 {
+		f.subscribe = true
+	}
+	if poll {
+		// the filter only yields logs from later blocks
+		head, err := c.BlockNumber()
+		if err != nil {
+			return nil, err
+		}
+		f.next.block = uint64(head) + 1
+	}
+	err := f.install()
+	if _, ok := err.(*RPCError); ok && f.subscribe {
+		// the node doesn't support subscriptions
+		f.subscribe = false
+		err = f.install()
 	}
-	var out Int
-	err = c.Do("eth_newFilter", []json.RawMessage{buf}, &out)
 	if err != nil {
 		return nil, err
 	}
-	id := (*big.Int)(&out).Int64()
-	f := &Filter{c: c, out: make(chan *Log, 20), exit: make(chan struct{}, 1), id: id, poll: poll}
 	go frecv(f)
 	return f, nil
 }
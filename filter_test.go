@@ -0,0 +1,233 @@
+package seth
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// filterNode is a fake node that mines a block with a few
+// logs every time one of its filters is polled
+type filterNode struct {
+	*testNode
+	head      uint64
+	filters   map[int64]uint64 // last block reported, by filter
+	installed int
+}
+
+func newFilterNode() *filterNode {
+	f := &filterNode{testNode: newTestNode(), head: 10, filters: make(map[int64]uint64)}
+	f.handle("eth_blockNumber", func([]json.RawMessage) (interface{}, error) {
+		return Uint64(f.head), nil
+	})
+	f.handle("eth_getBlockByNumber", func([]json.RawMessage) (interface{}, error) {
+		head := Uint64(f.head)
+		return &Block{Number: &head}, nil
+	})
+	f.handle("eth_newFilter", func([]json.RawMessage) (interface{}, error) {
+		f.installed++
+		// the filter starts a block early, as it
+		// may when the head changes under it
+		f.filters[int64(f.installed)] = f.head - 1
+		return NewInt(int64(f.installed)), nil
+	})
+	changes := func(params []json.RawMessage) (interface{}, error) {
+		id := f.id(params)
+		last, ok := f.filters[id]
+		if !ok {
+			return nil, &RPCError{Code: -32000, Message: "filter not found"}
+		}
+		f.head++
+		f.filters[id] = f.head
+		return f.logs(last+1, f.head), nil
+	}
+	f.handle("eth_getFilterLogs", changes)
+	f.handle("eth_getFilterChanges", changes)
+	f.handle("eth_getLogs", func(params []json.RawMessage) (interface{}, error) {
+		var req struct{ FromBlock, ToBlock Uint64 }
+		json.Unmarshal(params[0], &req)
+		return f.logs(uint64(req.FromBlock), uint64(req.ToBlock)), nil
+	})
+	f.handle("eth_uninstallFilter", func(params []json.RawMessage) (interface{}, error) {
+		id := f.id(params)
+		_, ok := f.filters[id]
+		delete(f.filters, id)
+		return ok, nil
+	})
+	return f
+}
+
+func (f *filterNode) id(params []json.RawMessage) int64 {
+	var id Int
+	json.Unmarshal(params[0], &id)
+	return id.Int64()
+}
+
+// forget simulates a node restart,
+// during which a few blocks are mined
+func (f *filterNode) forget() {
+	f.with(func() {
+		f.filters = make(map[int64]uint64)
+		f.head += 3
+	})
+}
+
+// logs returns the logs in blocks [from, to],
+// of which there are three in each block
+func (f *filterNode) logs(from, to uint64) []Log {
+	var out []Log
+	for b := from; b <= to; b++ {
+		for i := 0; i < 3; i++ {
+			block, idx := Uint64(b), Uint64(i)
+			out = append(out, Log{BlockNumber: &block, LogIndex: &idx})
+		}
+	}
+	return out
+}
+
+func TestFilterPoll(t *testing.T) {
+	tp := newFilterNode()
+	c := NewClientTransport(tp)
+	c.SetPollInterval(time.Millisecond)
+
+	f, err := c.FilterTopics(nil, nil, -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every log after the head at which the
+	// filter was created, without gaps or repeats
+	next := logPos{block: 11}
+	read := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			select {
+			case l, ok := <-f.Out():
+				if !ok {
+					t.Fatalf("filter closed: %v", f.Err())
+				}
+				got := logPos{block: uint64(*l.BlockNumber), index: uint64(*l.LogIndex)}
+				if got != next {
+					t.Fatalf("got log %v; want %v", got, next)
+				}
+				if next.index++; next.index == 3 {
+					next = logPos{block: next.block + 1}
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("timed out waiting for logs")
+			}
+		}
+	}
+	read(12)
+
+	// the filter should be reinstalled when the
+	// node forgets about it, and the logs that it
+	// missed in the meantime should be backfilled
+	tp.forget()
+	read(30)
+	if installed := tp.count("eth_newFilter"); installed != 2 {
+		t.Errorf("filter installed %d times", installed)
+	}
+	if tp.count("eth_getLogs") == 0 {
+		t.Error("missed logs weren't backfilled")
+	}
+
+	// stop reading so that the filter blocks
+	// on its output; Close should still work
+	time.Sleep(20 * time.Millisecond)
+	f.Close()
+	timeout := time.After(10 * time.Second)
+	for done := false; !done; {
+		select {
+		case _, ok := <-f.Out():
+			done = !ok
+		case <-timeout:
+			t.Fatal("filter did not close")
+		}
+	}
+	if err := f.Err(); err != nil {
+		t.Fatal(err)
+	}
+	tp.with(func() {
+		if len(tp.filters) != 0 {
+			t.Error("filter not uninstalled")
+		}
+	})
+}
+
+func TestFilterSubscribe(t *testing.T) {
+	srv := &wsServer{t: t, unsubs: make(chan string, 1)}
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	c := NewWSClient(wsURL(hs))
+
+	f, err := c.FilterTopics(nil, nil, -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		select {
+		case l := <-f.Out():
+			if l == nil || uint64(*l.LogIndex) != uint64(i) {
+				t.Fatalf("unexpected log %v", l)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for logs")
+		}
+	}
+	f.Close()
+	if id := <-srv.unsubs; id != "0xabc" {
+		t.Errorf("unsubscribed from %q", id)
+	}
+}
+
+func TestLogQueryJSON(t *testing.T) {
+	a, b := Address{1}, Address{2}
+	x, y := Hash{3}, Hash{4}
+	cases := []struct {
+		req  newFilterReq
+		want string
+	}{
+		{newFilterReq{}, `{}`},
+		{
+			newFilterReq{Address: AddressList{a}, Topics: Topics(&x, nil, &y)},
+			`{"address":"` + a.String() + `","topics":["` + x.String() + `",null,"` + y.String() + `"]}`,
+		},
+		{
+			newFilterReq{Address: AddressList{a, b}, Topics: TopicList{nil, {x, y}}},
+			`{"address":["` + a.String() + `","` + b.String() + `"],"topics":[null,["` + x.String() + `","` + y.String() + `"]]}`,
+		},
+	}
+	for _, c := range cases {
+		buf, err := json.Marshal(&c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != c.want {
+			t.Errorf("got %s; want %s", buf, c.want)
+		}
+		var out newFilterReq
+		if err := json.Unmarshal(buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out.Address) != len(c.req.Address) || len(out.Topics) != len(c.req.Topics) {
+			t.Fatalf("%s didn't round-trip", buf)
+		}
+		for i := range c.req.Topics {
+			if len(out.Topics[i]) != len(c.req.Topics[i]) {
+				t.Fatalf("%s didn't round-trip", buf)
+			}
+		}
+	}
+
+	topics := TopicList{nil, {x, y}}
+	z := Hash{5}
+	if !topics.Match(0, &z) || !topics.Match(1, &y) || topics.Match(1, &z) || !topics.Match(2, &z) {
+		t.Error("unexpected topic match")
+	}
+	addrs := AddressList{a, b}
+	c := Address{3}
+	if !addrs.Match(&b) || addrs.Match(&c) || !AddressList(nil).Match(&c) {
+		t.Error("unexpected address match")
+	}
+}
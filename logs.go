@@ -0,0 +1,238 @@
+package seth
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultScanSpan is the default number of
+	// blocks in each eth_getLogs request made by
+	// (*Client).GetLogs.
+	DefaultScanSpan = 1000
+	// DefaultScanConcurrency is the default number
+	// of concurrent requests made by (*Client).GetLogs.
+	DefaultScanConcurrency = 4
+)
+
+// ScanOptions are the options for (*Client).GetLogs.
+type ScanOptions struct {
+	// From is the first block to scan, which is
+	// typically a checkpoint from an earlier scan.
+	From int64
+	// To is the last block to scan. If it is
+	// negative, the scan ends at the latest block.
+	To int64
+	// Span is the number of blocks in each request.
+	// Requests are split in half (repeatedly) if the
+	// node responds that there are too many results.
+	Span int64
+	// Concurrency is the maximum
+	// number of concurrent requests.
+	Concurrency int
+}
+
+// A LogScan delivers the logs found by (*Client).GetLogs.
+type LogScan struct {
+	out  chan *Log
+	exit chan struct{}
+
+	lock       sync.Mutex // guards below
+	checkpoint int64
+	err        error
+	closed     bool
+}
+
+// Out returns the channel of logs, which are delivered
+// in order. The channel is closed when every log has been
+// delivered, or when the scan is closed or encounters an
+// error, in which case (*LogScan).Err() will be non-nil.
+func (s *LogScan) Out() <-chan *Log { return s.out }
+
+// Err returns the error that stopped the scan, if any.
+func (s *LogScan) Err() error {
+	s.lock.Lock()
+	err := s.err
+	s.lock.Unlock()
+	return err
+}
+
+// Checkpoint returns the block number from which the scan
+// can be resumed: every log in the blocks before it has been
+// received from the output channel. (Logs in the checkpoint
+// block itself may have been received, and if so, they will
+// be delivered again by a scan that resumes from it.)
+func (s *LogScan) Checkpoint() int64 {
+	s.lock.Lock()
+	n := s.checkpoint
+	s.lock.Unlock()
+	return n
+}
+
+// Close stops the scan and closes its output
+// channel. Close is safe to call from any goroutine.
+func (s *LogScan) Close() {
+	s.lock.Lock()
+	if !s.closed {
+		close(s.exit)
+		s.closed = true
+	}
+	s.lock.Unlock()
+}
+
+func (s *LogScan) setcheckpoint(n int64) {
+	s.lock.Lock()
+	s.checkpoint = n
+	s.lock.Unlock()
+}
+
+type logChunk struct {
+	to   int64
+	logs []Log
+	err  error
+}
+
+// GetLogs scans the given range of blocks for logs matching q
+// using eth_getLogs. The range is split into requests of
+// opts.Span blocks, which are made concurrently, and any request
+// for which the node responds that there are too many results
+// is split in half until it succeeds. Logs are delivered in order.
+func (c *Client) GetLogs(q *LogQuery, opts *ScanOptions) *LogScan {
+	o := *opts
+	if o.Span <= 0 {
+		o.Span = DefaultScanSpan
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultScanConcurrency
+	}
+	s := &LogScan{
+		out:        make(chan *Log),
+		exit:       make(chan struct{}),
+		checkpoint: o.From,
+	}
+	go c.scan(s, q, &o)
+	return s
+}
+
+func (c *Client) scan(s *LogScan, q *LogQuery, o *ScanOptions) {
+	defer close(s.out)
+	// stop the producer below if the scan ends early
+	defer s.Close()
+	if o.To < 0 {
+		b, err := c.GetBlock(Latest, false)
+		if err != nil {
+			s.lock.Lock()
+			s.err = err
+			s.lock.Unlock()
+			return
+		}
+		o.To = int64(*b.Number)
+	}
+
+	// the chunks are queued in order, and at most
+	// o.Concurrency of them are in flight at once
+	// (one being waited on plus the queued ones)
+	chunks := make(chan chan logChunk, o.Concurrency-1)
+	go func() {
+		defer close(chunks)
+		for from := o.From; from <= o.To; from += o.Span {
+			to := from + o.Span - 1
+			if to > o.To {
+				to = o.To
+			}
+			ch := make(chan logChunk, 1)
+			select {
+			case chunks <- ch:
+			case <-s.exit:
+				return
+			}
+			go func(from, to int64) {
+				logs, err := c.fetchLogs(q, from, to)
+				ch <- logChunk{to: to, logs: logs, err: err}
+			}(from, to)
+		}
+	}()
+
+	for ch := range chunks {
+		var chunk logChunk
+		select {
+		case chunk = <-ch:
+		case <-s.exit:
+			return
+		}
+		if chunk.err != nil {
+			s.lock.Lock()
+			s.err = chunk.err
+			s.lock.Unlock()
+			return
+		}
+		for i := range chunk.logs {
+			if bn := chunk.logs[i].BlockNumber; bn != nil {
+				s.setcheckpoint(int64(*bn))
+			}
+			select {
+			case s.out <- &chunk.logs[i]:
+			case <-s.exit:
+				return
+			}
+		}
+		s.setcheckpoint(chunk.to + 1)
+	}
+}
+
+// fetchLogs gets the logs matching q in [from, to],
+// splitting the range when there are too many of them
+func (c *Client) fetchLogs(q *LogQuery, from, to int64) ([]Log, error) {
+	buf, err := json.Marshal(&newFilterReq{
+		FromBlock: itox(from),
+		ToBlock:   itox(to),
+		Address:   q.Address,
+		Topics:    q.Topics,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var logs []Log
+	err = c.Do("eth_getLogs", []json.RawMessage{buf}, &logs)
+	if err == nil || from == to || !isTooManyResults(err) {
+		return logs, err
+	}
+	mid := from + (to-from)/2
+	first, err := c.fetchLogs(q, from, mid)
+	if err != nil {
+		return nil, err
+	}
+	rest, err := c.fetchLogs(q, mid+1, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, rest...), nil
+}
+
+// tooMany are fragments of the errors that providers
+// return when a query covers too many blocks or logs
+// (but not when requests are being rate limited, which
+// splitting the query would only make worse)
+var tooMany = []string{
+	"query returned more than",
+	"response size exceeded",
+	"too many results",
+	"block range",
+}
+
+// isTooManyResults returns whether err indicates
+// that an eth_getLogs query should be narrowed
+func isTooManyResults(err error) bool {
+	e, ok := err.(*RPCError)
+	if !ok {
+		return false
+	}
+	msg := strings.ToLower(e.Message)
+	for _, s := range tooMany {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
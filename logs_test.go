@@ -0,0 +1,109 @@
+package seth
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+// newRangeNode returns a fake node with two logs per block
+// that refuses queries with more than limit results, or
+// every query for exceeding a rate limit if ratelimit is set
+func newRangeNode(limit int, ratelimit bool) *testNode {
+	n := newTestNode()
+	n.handle("eth_getLogs", func(params []json.RawMessage) (interface{}, error) {
+		var q newFilterReq
+		json.Unmarshal(params[0], &q)
+		var from, to Uint64
+		json.Unmarshal(q.FromBlock, &from)
+		json.Unmarshal(q.ToBlock, &to)
+		if ratelimit {
+			return nil, &RPCError{Code: -32005, Message: "daily request limit exceeded"}
+		}
+		if n := 2 * int(to-from+1); n > limit {
+			return nil, &RPCError{Code: -32005, Message: "query returned more than " + strconv.Itoa(limit) + " results"}
+		}
+		var logs []Log
+		for b := from; b <= to; b++ {
+			for i := 0; i < 2; i++ {
+				bn, idx := b, Uint64(i)
+				logs = append(logs, Log{BlockNumber: &bn, LogIndex: &idx})
+			}
+		}
+		return logs, nil
+	})
+	return n
+}
+
+func TestGetLogs(t *testing.T) {
+	tp := newRangeNode(10, false)
+	c := NewClientTransport(tp)
+
+	s := c.GetLogs(&LogQuery{}, &ScanOptions{From: 10, To: 109, Span: 40, Concurrency: 3})
+	defer s.Close()
+	next := 10
+	var idx uint64
+	for l := range s.Out() {
+		if int(*l.BlockNumber) != next || uint64(*l.LogIndex) != idx {
+			t.Fatalf("got log %d/%d; want %d/%d", *l.BlockNumber, *l.LogIndex, next, idx)
+		}
+		if idx++; idx == 2 {
+			next, idx = next+1, 0
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if next != 110 {
+		t.Fatalf("scan stopped before block %d", next)
+	}
+	if cp := s.Checkpoint(); cp != 110 {
+		t.Errorf("checkpoint is %d; want 110", cp)
+	}
+
+	// resume a scan that was stopped part of the way through
+	s = c.GetLogs(&LogQuery{}, &ScanOptions{From: 0, To: 99, Span: 5, Concurrency: 2})
+	for i := 0; i < 25; i++ {
+		<-s.Out()
+	}
+	s.Close()
+	cp := s.Checkpoint()
+	if cp != 12 {
+		t.Fatalf("checkpoint is %d after 25 logs", cp)
+	}
+	s = c.GetLogs(&LogQuery{}, &ScanOptions{From: cp, To: 99, Span: 5})
+	l, ok := <-s.Out()
+	if !ok || int64(*l.BlockNumber) != cp || *l.LogIndex != 0 {
+		t.Fatalf("resumed scan started with %v", l)
+	}
+	s.Close()
+
+	// a single block with too many logs is an error
+	c = NewClientTransport(newRangeNode(1, false))
+	s = c.GetLogs(&LogQuery{}, &ScanOptions{From: 0, To: 3})
+	for range s.Out() {
+		t.Fatal("unexpected log")
+	}
+	if !isTooManyResults(s.Err()) {
+		t.Errorf("unexpected error %v", s.Err())
+	}
+	select {
+	case <-s.exit:
+	default:
+		t.Error("scan wasn't stopped after an error")
+	}
+
+	// rate limiting isn't mistaken for too many results
+	tp = newRangeNode(1000, true)
+	c = NewClientTransport(tp)
+	s = c.GetLogs(&LogQuery{}, &ScanOptions{From: 0, To: 99, Span: 100})
+	for range s.Out() {
+		t.Fatal("unexpected log")
+	}
+	if s.Err() == nil || isTooManyResults(s.Err()) {
+		t.Errorf("unexpected error %v", s.Err())
+	}
+	if n := tp.count("eth_getLogs"); n != 1 {
+		t.Errorf("rate-limited request was split into %d requests", n)
+	}
+}
@@ -0,0 +1,127 @@
+package seth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDropped is returned when a pending transaction
+// disappears from the node without being mined, as
+// happens when it is evicted from the transaction pool
+// or replaced by a pending transaction with the same nonce.
+var ErrDropped = errors.New("seth: transaction dropped")
+
+// TxError is the error returned when
+// a mined transaction failed.
+type TxError struct {
+	Receipt *Receipt
+
+	// Revert is the reason that the transaction
+	// reverted, or nil if it couldn't be determined
+	// (for instance, because it ran out of gas).
+	Revert *RevertError
+}
+
+// Error implements error
+func (e *TxError) Error() string {
+	msg := "txhash " + e.Receipt.Hash.String() + ": transaction failed"
+	if e.Revert != nil {
+		msg += ": " + e.Revert.Error()
+	}
+	return msg
+}
+
+// WaitMined waits for the transaction with the given hash to be
+// mined and then buried under confirmations-1 more blocks, and
+// returns its receipt. Confirmations less than one are treated as
+// one. If the transaction is reorganized out of the chain while
+// waiting, WaitMined goes back to waiting for it to be mined.
+//
+// If the transaction failed, the receipt is returned along with a
+// *TxError, whose revert reason is determined by replaying the
+// transaction with eth_call on top of the block before the one in
+// which it was mined. (The replay doesn't see the effects of the
+// transactions that preceded it in its block, so the reason is
+// only an approximation.)
+// If the nonce of the transaction is used by a different
+// transaction, ErrReplaced is returned, and if the transaction
+// disappears from the node without being mined, ErrDropped is
+// returned.
+func (s *Sender) WaitMined(ctx context.Context, h *Hash, confirmations int) (*Receipt, error) {
+	if confirmations < 1 {
+		confirmations = 1
+	}
+	var tx *Transaction
+	for {
+		// the nonce is read before the receipt, so that
+		// if the nonce has been used by this transaction,
+		// its receipt is seen
+		var nonce int64
+		if tx != nil {
+			var err error
+			if nonce, err = s.GetNonceAtContext(ctx, tx.From, Latest); err != nil {
+				return nil, err
+			}
+		}
+		r, err := s.GetReceiptContext(ctx, h)
+		switch {
+		case err == nil:
+			head, err := s.BlockNumberContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if head-int64(r.BlockNumber)+1 < int64(confirmations) {
+				break
+			}
+			if !r.Threw() {
+				return r, nil
+			}
+			if tx == nil {
+				if tx, err = s.GetTransactionContext(ctx, h); err != nil {
+					return r, &TxError{Receipt: r}
+				}
+			}
+			return r, &TxError{Receipt: r, Revert: s.replay(ctx, tx, int64(r.BlockNumber)-1)}
+		case err != ErrNotFound:
+			return nil, err
+		case tx != nil && uint64(nonce) > uint64(tx.Nonce):
+			return nil, ErrReplaced
+		default:
+			t, err := s.GetTransactionContext(ctx, h)
+			switch {
+			case err == nil:
+				tx = t
+			case err != ErrNotFound:
+				return nil, err
+			case tx != nil:
+				// it was seen before, so the node has
+				// dropped it rather than not having
+				// heard of it yet
+				return nil, ErrDropped
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.pollInterval()):
+		}
+	}
+}
+
+// replay executes tx with eth_call at the given block
+// and returns the reason that it reverts, if any
+func (s *Sender) replay(ctx context.Context, tx *Transaction, block int64) *RevertError {
+	opts := CallOpts{
+		From: tx.From,
+		To:   tx.To,
+		Gas:  NewInt(int64(tx.Gas)),
+		Data: tx.Input,
+	}
+	if tx.Value.Big().Sign() != 0 {
+		opts.Value = &tx.Value
+	}
+	var ret Data
+	re, _ := s.ConstCallAtContext(ctx, &opts, &ret, block).(*RevertError)
+	return re
+}
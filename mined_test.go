@@ -0,0 +1,118 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// minedNode is a fake node that serves a single
+// transaction, whose fate is decided by step, which
+// is called each time its receipt is requested
+type minedNode struct {
+	*testNode
+	head    uint64   // advanced on each eth_blockNumber
+	receipt *Receipt // nil if not mined
+	revert  Data     // eth_call revert data
+	callAt  string   // block of the last eth_call
+	step    func(n *minedNode, poll int)
+}
+
+func newMinedNode(head uint64, step func(n *minedNode, poll int)) *minedNode {
+	n := &minedNode{testNode: newTestNode(), head: head, step: step}
+	n.handle("eth_blockNumber", func([]json.RawMessage) (interface{}, error) {
+		n.head++
+		return Uint64(n.head), nil
+	})
+	n.handle("eth_getTransactionReceipt", func([]json.RawMessage) (interface{}, error) {
+		n.step(n, n.calls["eth_getTransactionReceipt"])
+		return n.receipt, nil
+	})
+	n.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+		json.Unmarshal(params[1], &n.callAt)
+		data, _ := json.Marshal(&n.revert)
+		return nil, &RPCError{Code: 3, Message: "execution reverted", Data: data}
+	})
+	return n
+}
+
+func TestWaitMined(t *testing.T) {
+	from, to := Address{1}, Address{2}
+	h := Hash{3}
+	wait := func(n *minedNode, confirmations int) (*Receipt, error) {
+		n.txs[h] = &Transaction{Hash: h, From: &from, To: &to, Gas: 50000, Input: Data("call")}
+		c := NewClientTransport(n)
+		c.SetPollInterval(time.Millisecond)
+		s := NewSender(c, &from)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return s.WaitMined(ctx, &h, confirmations)
+	}
+	mine := func(n *minedNode, status uint64) {
+		n.receipt = &Receipt{Hash: h, BlockNumber: Uint64(n.head), Status: Uint64(status)}
+	}
+
+	// confirmations are counted from the
+	// block that the transaction ends up in
+	n := newMinedNode(10, func(n *minedNode, poll int) {
+		switch poll {
+		case 2:
+			mine(n, 1)
+		case 3:
+			n.receipt = nil // reorganized out
+		case 5:
+			mine(n, 1)
+		}
+	})
+	r, err := wait(n, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.with(func() {
+		if r.BlockNumber != 11 || n.head != 13 {
+			t.Errorf("mined in block %d with head %d", r.BlockNumber, n.head)
+		}
+	})
+
+	// failed transactions are replayed for a revert reason
+	n = newMinedNode(20, func(n *minedNode, poll int) {
+		if poll == 2 {
+			mine(n, 0)
+		}
+	})
+	n.revert = words(t, "08c379a0", word("20"), word("2"), rword("6e6f"))
+	r, err = wait(n, 0)
+	te, ok := err.(*TxError)
+	if !ok || te.Receipt != r || te.Revert == nil || te.Revert.Reason != "no" {
+		t.Fatalf("got %v instead of a revert", err)
+	}
+	n.with(func() {
+		if n.callAt != "0x13" {
+			t.Errorf("replayed at block %s", n.callAt)
+		}
+	})
+	if msg := err.Error(); msg != "txhash "+h.String()+": transaction failed: execution reverted: no" {
+		t.Errorf("got error %q", msg)
+	}
+
+	// the nonce is used by another transaction
+	n = newMinedNode(0, func(n *minedNode, poll int) {
+		if poll == 3 {
+			n.nonce++
+		}
+	})
+	if _, err := wait(n, 1); err != ErrReplaced {
+		t.Errorf("got %v instead of ErrReplaced", err)
+	}
+
+	// the transaction disappears
+	n = newMinedNode(0, func(n *minedNode, poll int) {
+		if poll == 3 {
+			delete(n.txs, h)
+		}
+	})
+	if _, err := wait(n, 1); err != ErrDropped {
+		t.Errorf("got %v instead of ErrDropped", err)
+	}
+}
@@ -0,0 +1,132 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+)
+
+// MulticallAddress is the address of the Multicall3
+// aggregator, which is deployed at the same address
+// on most chains.
+var MulticallAddress = Address{
+	0xca, 0x11, 0xbd, 0xe0, 0x59, 0x77, 0xb3, 0x63, 0x11, 0x67,
+	0x02, 0x88, 0x62, 0xbe, 0x2a, 0x17, 0x39, 0x76, 0xca, 0x11,
+}
+
+// MulticallCode is the runtime code of a minimal aggregator
+// that implements Multicall3's aggregate3 function (and nothing
+// else). Multicall substitutes it for the code at MulticallAddress
+// on chains where no aggregator has been deployed.
+var MulticallCode = func() Data {
+	code, err := hexparse([]byte("0x" +
+		"7c0100000000000000000000000000000000000000000000000000000000600035046382ad56cb1461003057600080fd" +
+		"5b600435600401803580602052610120526020016040526020610100526020516020026101400160605260006000525b" +
+		"602051600051101561011e57604051806000516020020135018060805280604001350180358060a05290602001606051" +
+		"606001376000600060a0516060516060016000608051355af1806100c257608051602001356100c2573d6000803e3d60" +
+		"00fd5b610140606051036000516020026101400152606051526040606051602001523d606051604001523d6000606051" +
+		"6060013e60003d60605160600101526060513d601f01601f19160160600160605260005160010160005261005f565b61" +
+		"010060605103610100f3"))
+	if err != nil {
+		panic(err)
+	}
+	return code
+}()
+
+var multicallABI = MustParseABI(`[{"type":"function","name":"aggregate3","stateMutability":"payable",` +
+	`"inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],` +
+	`"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`)
+
+// multicall is an aggregate3 call
+type multicall struct {
+	Target       Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicallResult is an aggregate3 result
+type multicallResult struct {
+	Success    bool
+	ReturnData Bytes
+}
+
+// A MulticallElem is a contract read
+// in a multicall (see (*Client).Multicall).
+type MulticallElem struct {
+	To   *Address
+	Data []byte // call data, e.g. from ABIEncode or (*ABI).Pack
+	// Result holds pointers to the values into which the
+	// return data is decoded, as with DecodeABI. If Result
+	// is empty, the return data is left undecoded.
+	Result []interface{}
+
+	// Success is set by (*Client).Multicall to
+	// whether the call succeeded, and Return is
+	// set to the data that the call returned.
+	Success bool
+	Return  Bytes
+	// Error is set by (*Client).Multicall if the call
+	// failed (in which case it is a *RevertError), or
+	// if the return data couldn't be decoded into Result.
+	Error error
+}
+
+// Multicall makes the calls in elems with a single eth_call
+// to the aggregator at MulticallAddress in the given block.
+// If nothing is deployed at MulticallAddress, the eth_call is
+// made again with the code at MulticallAddress overridden with
+// MulticallCode, which requires a node that supports state
+// overrides.
+//
+// The error returned by Multicall is the failure of the calls
+// as a whole. The failure of each call is reported in its Error.
+func (c *Client) Multicall(elems []MulticallElem, block int64) error {
+	return c.MulticallContext(context.Background(), elems, block)
+}
+
+// MulticallContext is like Multicall, but it takes a context.
+func (c *Client) MulticallContext(ctx context.Context, elems []MulticallElem, block int64) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	calls := make([]multicall, len(elems))
+	for i := range elems {
+		calls[i] = multicall{Target: *elems[i].To, AllowFailure: true, CallData: elems[i].Data}
+	}
+	data, err := multicallABI.Pack("aggregate3", calls)
+	if err != nil {
+		return err
+	}
+	to := MulticallAddress
+	opts := &CallOpts{To: &to, Data: data}
+	var out Data
+	if err := c.ConstCallAtContext(ctx, opts, &out, block); err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		// calls to an account without
+		// code succeed and return nothing
+		code := MulticallCode
+		state := StateOverride{to: {Code: &code}}
+		if err := c.ConstCallOverrideContext(ctx, opts, &out, block, state, nil); err != nil {
+			return err
+		}
+	}
+	var res []multicallResult
+	if err := multicallABI.Unpack("aggregate3", out, &res); err != nil {
+		return err
+	}
+	if len(res) != len(elems) {
+		return fmt.Errorf("seth: multicall returned %d results for %d calls", len(res), len(elems))
+	}
+	for i := range elems {
+		e := &elems[i]
+		e.Success, e.Return, e.Error = res[i].Success, res[i].ReturnData, nil
+		switch {
+		case !e.Success:
+			e.Error = DecodeRevert(e.Return, nil)
+		case len(e.Result) > 0:
+			e.Error = DecodeABI(e.Return, e.Result...)
+		}
+	}
+	return nil
+}
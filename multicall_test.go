@@ -0,0 +1,102 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// newMulticallNode returns a fake node that answers aggregate3
+// calls by calling the functions in contracts; it only has an
+// aggregator if deployed is set, and otherwise honors code
+// overrides at MulticallAddress
+func newMulticallNode(deployed bool, contracts map[Address]func(in []byte) ([]byte, bool)) *testNode {
+	n := newTestNode()
+	n.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+		var opts CallOpts
+		json.Unmarshal(params[0], &opts)
+		deployed := deployed
+		if len(params) > 2 {
+			var state StateOverride
+			json.Unmarshal(params[2], &state)
+			code := state[MulticallAddress].Code
+			deployed = code != nil && bytes.Equal(*code, MulticallCode)
+		}
+		if *opts.To != MulticallAddress || !deployed {
+			return Data{}, nil
+		}
+		d, _ := multicallABI.Method("aggregate3")
+		sel := d.Selector()
+		if !bytes.HasPrefix(opts.Data, sel[:]) {
+			return nil, &RPCError{Code: 3, Message: "execution reverted"}
+		}
+		var calls []multicall
+		if err := DecodeParams(d.Inputs, opts.Data[4:], &calls); err != nil {
+			return nil, err
+		}
+		out := make([]multicallResult, len(calls))
+		for i := range calls {
+			ret, ok := contracts[calls[i].Target](calls[i].CallData)
+			out[i] = multicallResult{Success: ok, ReturnData: ret}
+		}
+		buf, err := EncodeParams(d.Outputs, out)
+		return Data(buf), err
+	})
+	return n
+}
+
+func TestMulticall(t *testing.T) {
+	token, bad := Address{1}, Address{2}
+	holders := []Address{{3}, {4}, {5}}
+	balanceOf := func(in []byte) ([]byte, bool) {
+		var who Address
+		if DecodeABI(in[4:], &who) != nil {
+			return nil, false
+		}
+		return ABIEncode("f(uint256)", NewInt(int64(who[0])*100))[4:], true
+	}
+	contracts := map[Address]func([]byte) ([]byte, bool){
+		token: balanceOf,
+		bad: func([]byte) ([]byte, bool) {
+			return ABIEncode("Error(string)", &Bytes{}), false
+		},
+	}
+
+	for _, deployed := range []bool{true, false} {
+		node := newMulticallNode(deployed, contracts)
+		c := NewClientTransport(node)
+		bals := make([]big.Int, len(holders))
+		var elems []MulticallElem
+		for i := range holders {
+			elems = append(elems, MulticallElem{
+				To:     &token,
+				Data:   ABIEncode("balanceOf(address)", &holders[i]),
+				Result: []interface{}{&bals[i]},
+			})
+		}
+		elems = append(elems, MulticallElem{To: &bad, Data: ABIEncode("f()")})
+		if err := c.Multicall(elems, Latest); err != nil {
+			t.Fatal(err)
+		}
+		for i := range holders {
+			if !elems[i].Success || elems[i].Error != nil {
+				t.Errorf("call %d failed: %v", i, elems[i].Error)
+			}
+			if want := int64(holders[i][0]) * 100; bals[i].Int64() != want {
+				t.Errorf("balance %d is %s; want %d", i, &bals[i], want)
+			}
+		}
+		last := elems[len(elems)-1]
+		if _, ok := last.Error.(*RevertError); last.Success || !ok {
+			t.Errorf("expected a revert; got %v", last.Error)
+		}
+		want := 1
+		if !deployed {
+			want = 2
+		}
+		if n := node.count("eth_call"); n != want {
+			t.Errorf("deployed=%v: %d calls to eth_call", deployed, n)
+		}
+	}
+}
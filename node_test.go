@@ -0,0 +1,192 @@
+package seth
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// testHandler serves a request to a testNode.
+// An *RPCError is reported as the node's error.
+type testHandler func(params []json.RawMessage) (interface{}, error)
+
+// testNode is the fake node used by tests. Each request
+// is served by the handler registered for its method while
+// the node is locked, so handlers and tests (through with)
+// can share state freely. Methods without a handler are
+// rejected as unsupported.
+//
+// By default, the node keeps the transactions of a single
+// account: raw transactions are checked against its nonce,
+// pending transactions can be replaced with the minimum fee
+// bump, and transactions are mined as soon as the gap before
+// them is filled (and they offer mineAt, if it is set).
+type testNode struct {
+	lock     sync.Mutex
+	handlers map[string]testHandler
+	calls    map[string]int // requests received, by method
+
+	nonce   uint64                  // account nonce
+	mineAt  *Int                    // gas price at which transactions are mined
+	txs     map[Hash]*Transaction   // known transactions
+	pending map[uint64]*Transaction // pending transactions by nonce
+	mined   map[uint64]*Transaction // mined transactions by nonce
+	sent    []*Transaction          // raw transactions received
+}
+
+func newTestNode() *testNode {
+	n := &testNode{
+		handlers: make(map[string]testHandler),
+		calls:    make(map[string]int),
+		txs:      make(map[Hash]*Transaction),
+		pending:  make(map[uint64]*Transaction),
+		mined:    make(map[uint64]*Transaction),
+	}
+	n.handlers["eth_getTransactionCount"] = func([]json.RawMessage) (interface{}, error) {
+		return Uint64(n.nonce), nil
+	}
+	n.handlers["eth_estimateGas"] = func([]json.RawMessage) (interface{}, error) {
+		return Uint64(21000), nil
+	}
+	n.handlers["eth_getTransactionByHash"] = func(params []json.RawMessage) (interface{}, error) {
+		var h Hash
+		json.Unmarshal(params[0], &h)
+		return n.txs[h], nil
+	}
+	n.handlers["eth_sendRawTransaction"] = n.sendRaw
+	return n
+}
+
+func (n *testNode) sendRaw(params []json.RawMessage) (interface{}, error) {
+	var raw Data
+	json.Unmarshal(params[0], &raw)
+	tx, _, err := ParseRawTransaction(raw)
+	if err != nil {
+		return nil, err
+	}
+	nonce := uint64(tx.Nonce)
+	switch {
+	case n.txs[tx.Hash] != nil:
+		return nil, &RPCError{Code: -32000, Message: "known transaction: " + tx.Hash.String()}
+	case nonce < n.nonce:
+		return nil, &RPCError{Code: -32000, Message: fmt.Sprintf("nonce too low: tx: %d state: %d", nonce, n.nonce)}
+	}
+	if old := n.pending[nonce]; old != nil {
+		if price(tx).Cmp(bump(price(old), MinBump)) < 0 {
+			return nil, &RPCError{Code: -32000, Message: "replacement transaction underpriced"}
+		}
+		n.drop(nonce)
+	}
+	n.sent = append(n.sent, tx)
+	n.txs[tx.Hash] = tx
+	n.pending[nonce] = tx
+	n.mine()
+	return &tx.Hash, nil
+}
+
+// price returns the maximum gas price offered by tx
+func price(tx *Transaction) *Int {
+	if tx.MaxFeePerGas != nil {
+		return tx.MaxFeePerGas
+	}
+	return &tx.GasPrice
+}
+
+// drop drops the pending transaction with the given nonce
+func (n *testNode) drop(nonce uint64) {
+	if old := n.pending[nonce]; old != nil {
+		delete(n.txs, old.Hash)
+		delete(n.pending, nonce)
+	}
+}
+
+// mine mines pending transactions in nonce order until
+// it reaches a gap or a transaction that offers too little
+func (n *testNode) mine() {
+	for {
+		tx := n.pending[n.nonce]
+		if tx == nil || (n.mineAt != nil && price(tx).Cmp(n.mineAt) < 0) {
+			return
+		}
+		idx := Uint64(0)
+		tx.TxIndex = &idx
+		delete(n.pending, n.nonce)
+		n.mined[n.nonce] = tx
+		n.nonce++
+	}
+}
+
+// tx returns the transaction with the given hash
+func (n *testNode) tx(h Hash) *Transaction {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.txs[h]
+}
+
+// sentTxs returns the raw transactions received
+func (n *testNode) sentTxs() []*Transaction {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return append([]*Transaction(nil), n.sent...)
+}
+
+// last returns the last raw transaction received
+func (n *testNode) last() *Transaction {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.sent[len(n.sent)-1]
+}
+
+// minedAt returns the hash of the transaction
+// mined with the given nonce, if any
+func (n *testNode) minedAt(nonce uint64) Hash {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if tx := n.mined[nonce]; tx != nil {
+		return tx.Hash
+	}
+	return Hash{}
+}
+
+// handle sets the handler for a method
+func (n *testNode) handle(method string, h testHandler) {
+	n.lock.Lock()
+	n.handlers[method] = h
+	n.lock.Unlock()
+}
+
+// with calls fn with the node locked
+func (n *testNode) with(fn func()) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	fn()
+}
+
+// count returns the number of requests for a method
+func (n *testNode) count(method string) int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.calls[method]
+}
+
+func (n *testNode) Execute(req *RPCRequest, res *RPCResponse) error {
+	res.ID = req.ID
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.calls[req.Method]++
+	h := n.handlers[req.Method]
+	if h == nil {
+		res.Error.Code = -32601
+		res.Error.Message = "unsupported method"
+		return nil
+	}
+	ret, err := h(req.Params)
+	if e, ok := err.(*RPCError); ok {
+		res.Error = *e
+		return nil
+	} else if err != nil {
+		return err
+	}
+	res.Result, err = json.Marshal(ret)
+	return err
+}
@@ -0,0 +1,209 @@
+package seth
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGapTimeout is the default time for which the
+// nonce of an address must stay put, while the node doesn't
+// know about all of the nonces handed out by a NonceManager,
+// before the first missing nonce is handed out again.
+const DefaultGapTimeout = time.Minute
+
+// A NonceManager hands out the nonces of transactions
+// sent from an address without asking the node for each
+// one, so that transactions sent concurrently from the
+// same address are given distinct nonces.
+//
+// Each nonce returned by Next must be given back to Done
+// once the transaction using it has been broadcast (or has
+// failed to be). Nonces of transactions that weren't broadcast
+// are reused. When no nonces are outstanding, Next checks
+// the pending nonce of the address with the node, which
+// catches transactions sent by others. If the node's pending
+// nonce stays behind the nonces handed out while its latest
+// nonce doesn't move for GapTimeout, the transaction with the
+// pending nonce is assumed to have been dropped (leaving a gap
+// that the transactions after it are stuck behind), and its
+// nonce is handed out again.
+type NonceManager struct {
+	// GapTimeout, if set, is used
+	// instead of DefaultGapTimeout.
+	GapTimeout time.Duration
+
+	client *Client
+	lock   sync.Mutex // guards accts
+	accts  map[Address]*nonceState
+}
+
+// nonceState is the state of the nonces of an address
+type nonceState struct {
+	lock     sync.Mutex // held while syncing with the node
+	synced   bool       // next is known to be consistent with the node
+	base     uint64     // pending nonce as of the last sync
+	next     uint64     // next new nonce
+	inflight int        // nonces handed out by Next but not yet passed to Done
+	free     []uint64   // nonces below next that can be reused, in ascending order
+	latest   uint64     // latest nonce while the node is behind next
+	since    time.Time  // when latest was first seen, or zero
+}
+
+// NewNonceManager constructs a NonceManager
+// that reads pending nonces from c.
+func NewNonceManager(c *Client) *NonceManager {
+	return &NonceManager{client: c, accts: make(map[Address]*nonceState)}
+}
+
+// state returns the state of addr
+func (n *NonceManager) state(addr *Address) *nonceState {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	st := n.accts[*addr]
+	if st == nil {
+		st = new(nonceState)
+		n.accts[*addr] = st
+	}
+	return st
+}
+
+func (n *NonceManager) gapTimeout() time.Duration {
+	if n.GapTimeout > 0 {
+		return n.GapTimeout
+	}
+	return DefaultGapTimeout
+}
+
+// Next returns the nonce to use for the next
+// transaction from addr.
+func (n *NonceManager) Next(ctx context.Context, addr *Address) (uint64, error) {
+	st := n.state(addr)
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if !st.synced || st.inflight == 0 {
+		pending, err := n.client.GetNonceAtContext(ctx, addr, Pending)
+		if err != nil {
+			return 0, err
+		}
+		p := uint64(pending)
+		st.sync(p)
+		switch {
+		case p == st.next:
+			st.since = time.Time{}
+		case st.inflight == 0:
+			// the node doesn't know about some of the nonces
+			// that were handed out: either it lags behind, or
+			// a transaction was dropped and the ones after it
+			// are stuck, which is assumed once the latest
+			// nonce hasn't moved for a while
+			latest, err := n.client.GetNonceAtContext(ctx, addr, Latest)
+			if err != nil {
+				return 0, err
+			}
+			switch l := uint64(latest); {
+			case st.since.IsZero() || l != st.latest:
+				st.latest, st.since = l, time.Now()
+			case time.Since(st.since) >= n.gapTimeout():
+				if len(st.free) == 0 || st.free[0] != p {
+					st.free = append([]uint64{p}, st.free...)
+				}
+				st.since = time.Time{}
+			}
+		}
+	}
+	var nonce uint64
+	if len(st.free) > 0 {
+		nonce, st.free = st.free[0], st.free[1:]
+	} else {
+		nonce = st.next
+		st.next++
+	}
+	st.inflight++
+	return nonce, nil
+}
+
+// sync merges the pending nonce p reported by the node.
+// The node may lag behind the nonces handed out, so next
+// only moves forward.
+func (st *nonceState) sync(p uint64) {
+	// free nonces that the node has seen
+	// used can't be handed out again
+	i := sort.Search(len(st.free), func(i int) bool { return st.free[i] >= p })
+	st.free = st.free[i:]
+	if p > st.next {
+		st.next = p
+	}
+	st.base = p
+	st.synced = true
+}
+
+// Done reports the result of broadcasting a transaction
+// from addr that used a nonce returned by Next. If err is
+// nil, the nonce is considered used. If err indicates that
+// the nonce was already used (see IsNonceError) or that the
+// node already has the transaction (see IsKnownTransaction),
+// or if err is a context error, in which case the transaction
+// may have been broadcast anyway, the nonce is considered used
+// and the nonces of addr are resynced with the node. Otherwise,
+// the nonce is reused by a later call to Next.
+func (n *NonceManager) Done(addr *Address, nonce uint64, err error) {
+	st := n.state(addr)
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.inflight--
+	switch {
+	case err == nil:
+	case IsNonceError(err), IsKnownTransaction(err), err == context.Canceled, err == context.DeadlineExceeded:
+		st.synced = false
+	case st.synced && nonce >= st.base && nonce < st.next:
+		// nonces from before the last sync
+		// may have been used by now
+		i := sort.Search(len(st.free), func(i int) bool { return st.free[i] >= nonce })
+		st.free = append(st.free, 0)
+		copy(st.free[i+1:], st.free[i:])
+		st.free[i] = nonce
+		// give back free nonces at the end of the range
+		for len(st.free) > 0 && st.free[len(st.free)-1] == st.next-1 {
+			st.free = st.free[:len(st.free)-1]
+			st.next--
+		}
+	}
+}
+
+// Reset forgets the nonces of addr, so that the next
+// call to Next takes the pending nonce from the node
+// as it is. (While nonces of addr are outstanding, the
+// pending nonce can only move the next nonce forward.)
+func (n *NonceManager) Reset(addr *Address) {
+	st := n.state(addr)
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.synced = false
+	if st.inflight == 0 {
+		st.next, st.free, st.since = 0, nil, time.Time{}
+	}
+}
+
+// IsNonceError returns whether err is an error
+// returned by a node for a transaction whose nonce
+// has already been used.
+func IsNonceError(err error) bool {
+	e, ok := err.(*RPCError)
+	return ok && strings.Contains(strings.ToLower(e.Message), "nonce too low")
+}
+
+// IsKnownTransaction returns whether err is an error
+// returned by a node for a transaction that it already
+// has (which means that the transaction was broadcast).
+func IsKnownTransaction(err error) bool {
+	e, ok := err.(*RPCError)
+	if !ok {
+		return false
+	}
+	msg := strings.ToLower(e.Message)
+	return strings.Contains(msg, "known transaction") ||
+		strings.Contains(msg, "already known")
+}
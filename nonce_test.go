@@ -0,0 +1,245 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNonceManager(t *testing.T) {
+	node := newTestNode()
+	c := NewClientTransport(node)
+	c.SetChainID(1)
+	key := GenPrivateKey()
+	s := NewSender(c, key.Address())
+	s.Signer = key.Signer()
+	s.Nonces = NewNonceManager(c)
+	to := Address{1}
+	send := func() (Hash, error) {
+		return s.Call(&CallOpts{To: &to, Gas: NewInt(21000)})
+	}
+
+	const workers = 20
+	hashes := make([]Hash, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if hashes[i], err = send(); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	node.with(func() {
+		if node.nonce != workers || len(node.pending) != 0 {
+			t.Fatalf("nonce %d with %d pending transactions", node.nonce, len(node.pending))
+		}
+	})
+
+	// nonces used by someone else are resynced
+	held, err := s.Nonces.Next(context.Background(), s.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.with(func() { node.nonce += 5 })
+	h, err := send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.minedAt(workers+5) != h {
+		t.Errorf("transaction wasn't mined with nonce %d", workers+5)
+	}
+	s.Nonces.Done(s.Addr, held, errors.New("not sent"))
+
+	// a nonce that was never broadcast is reused
+	n, err := s.Nonces.Next(context.Background(), s.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Nonces.Done(s.Addr, n, errors.New("not sent"))
+	if h, err = send(); err != nil {
+		t.Fatal(err)
+	}
+	if node.minedAt(n) != h {
+		t.Errorf("transaction wasn't mined with nonce %d", n)
+	}
+
+	// a node that lags behind doesn't move the nonce back
+	n, err = s.Nonces.Next(context.Background(), s.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Nonces.Done(s.Addr, n, errors.New("not sent"))
+	node.with(func() { node.nonce -= 2 })
+	if next, err := s.Nonces.Next(context.Background(), s.Addr); err != nil || next != n {
+		t.Errorf("got nonce %d (%v) from a lagging node; want %d", next, err, n)
+	} else {
+		s.Nonces.Done(s.Addr, next, errors.New("not sent"))
+	}
+	node.with(func() { node.nonce += 2 })
+
+	// a gap left by a dropped transaction is filled
+	// once the nonce has been stuck for GapTimeout
+	s.Nonces.GapTimeout = 10 * time.Millisecond
+	n, err = s.Nonces.Next(context.Background(), s.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Nonces.Done(s.Addr, n, nil)
+	if h, err = send(); err != nil {
+		t.Fatal(err)
+	}
+	if node.minedAt(n+1) != (Hash{}) || node.tx(h) == nil {
+		t.Fatalf("transaction %s wasn't held behind the gap", h.String())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if h, err = send(); err != nil {
+		t.Fatal(err)
+	}
+	if node.minedAt(n) != h || node.minedAt(n+1) == (Hash{}) {
+		t.Errorf("the gap at nonce %d wasn't filled", n)
+	}
+
+	// a transaction that the node already has
+	// is taken as sent rather than being retried
+	to2 := Address{2}
+	node.with(func() { node.mineAt = NewInt(1e18) })
+	node.handle("eth_sendRawTransaction", func(params []json.RawMessage) (interface{}, error) {
+		// the first attempt reaches the node,
+		// but the response is lost
+		node.handlers["eth_sendRawTransaction"] = node.sendRaw
+		node.sendRaw(params)
+		return nil, errors.New("connection reset")
+	})
+	if _, err = s.Call(&CallOpts{To: &to2, Gas: NewInt(21000)}); err == nil {
+		t.Fatal("expected an error")
+	}
+	sent := len(node.sentTxs())
+	if h, err = s.Call(&CallOpts{To: &to2, Gas: NewInt(21000)}); err != nil {
+		t.Fatal(err)
+	}
+	if node.last().Hash != h || len(node.sentTxs()) != sent {
+		t.Errorf("got hash %s for a known transaction", h.String())
+	}
+	node.with(func() { node.mineAt = nil })
+
+	// a nonce whose transaction may have been broadcast
+	// before the context expired isn't reused
+	if held, err = s.Nonces.Next(context.Background(), s.Addr); err != nil {
+		t.Fatal(err)
+	}
+	if n, err = s.Nonces.Next(context.Background(), s.Addr); err != nil {
+		t.Fatal(err)
+	}
+	node.with(func() { node.nonce = n + 1 })
+	s.Nonces.Done(s.Addr, n, context.DeadlineExceeded)
+	next, err := s.Nonces.Next(context.Background(), s.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != n+1 {
+		t.Errorf("got nonce %d after %d timed out", next, n)
+	}
+	s.Nonces.Done(s.Addr, held, nil)
+	s.Nonces.Done(s.Addr, next, nil)
+}
+
+func TestNonceManagerResync(t *testing.T) {
+	node := newTestNode()
+	c := NewClientTransport(node)
+	addr := &Address{1}
+	m := NewNonceManager(c)
+
+	var lock sync.Mutex
+	held := make(map[uint64]bool)
+	next := func(n int) {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				nonce, err := m.Next(context.Background(), addr)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				if held[nonce] {
+					t.Errorf("nonce %d handed out twice", nonce)
+				}
+				held[nonce] = true
+			}()
+		}
+		wg.Wait()
+	}
+	next(10)
+
+	// someone else uses nonce 0 while 1-9 are still in flight
+	node.with(func() { node.nonce = 1 })
+	delete(held, 0)
+	m.Done(addr, 0, &RPCError{Code: -32000, Message: "nonce too low"})
+
+	next(10)
+	for n := uint64(1); n < 20; n++ {
+		if !held[n] {
+			t.Errorf("nonce %d wasn't handed out", n)
+		}
+	}
+}
+
+// stallNode holds up requests for the
+// nonce of an address until it is released
+type stallNode struct {
+	*testNode
+	addr    Address
+	stalled chan struct{}
+	release chan struct{}
+}
+
+func (n *stallNode) Execute(req *RPCRequest, res *RPCResponse) error {
+	var addr Address
+	if req.Method == "eth_getTransactionCount" && json.Unmarshal(req.Params[0], &addr) == nil && addr == n.addr {
+		close(n.stalled)
+		<-n.release
+	}
+	return n.testNode.Execute(req, res)
+}
+
+func TestNonceManagerAddresses(t *testing.T) {
+	node := &stallNode{testNode: newTestNode(), addr: Address{1}, stalled: make(chan struct{}), release: make(chan struct{})}
+	m := NewNonceManager(NewClientTransport(node))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.Next(context.Background(), &node.addr)
+		done <- err
+	}()
+	<-node.stalled
+
+	// a slow lookup for one address
+	// doesn't hold up the others
+	other := make(chan error, 1)
+	go func() {
+		_, err := m.Next(context.Background(), &Address{2})
+		other <- err
+	}()
+	select {
+	case err := <-other:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Next blocked on another address")
+	}
+	close(node.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
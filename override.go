@@ -0,0 +1,78 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// An OverrideAccount describes changes to the
+// state of an account that are made for the
+// duration of a call (see (*Client).ConstCallOverride).
+type OverrideAccount struct {
+	Balance *Int    `json:"balance,omitempty"`
+	Nonce   *Uint64 `json:"nonce,omitempty"`
+	Code    *Data   `json:"code,omitempty"`
+	// State, if non-nil, replaces all of the account's
+	// storage; slots that aren't present read as zero.
+	// An empty State clears the account's storage.
+	State map[Hash]Hash `json:"state,omitempty"`
+	// StateDiff, if non-nil, replaces individual
+	// storage slots and leaves the rest alone.
+	// It may not be used together with State.
+	StateDiff map[Hash]Hash `json:"stateDiff,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// State and StateDiff are omitted only if
+// they are nil, since an empty State
+// is different from no State at all.
+func (o OverrideAccount) MarshalJSON() ([]byte, error) {
+	type account OverrideAccount
+	out := struct {
+		*account
+		State     *map[Hash]Hash `json:"state,omitempty"`
+		StateDiff *map[Hash]Hash `json:"stateDiff,omitempty"`
+	}{account: (*account)(&o)}
+	if o.State != nil {
+		out.State = &o.State
+	}
+	if o.StateDiff != nil {
+		out.StateDiff = &o.StateDiff
+	}
+	return json.Marshal(&out)
+}
+
+// StateOverride maps addresses to the
+// changes made to the state of their accounts.
+type StateOverride map[Address]OverrideAccount
+
+// BlockOverrides describes changes to the
+// block in which a call is executed.
+type BlockOverrides struct {
+	Number    *Uint64 `json:"number,omitempty"`
+	Timestamp *Uint64 `json:"time,omitempty"`
+	BaseFee   *Int    `json:"baseFeePerGas,omitempty"`
+}
+
+// ConstCallOverride is like ConstCallAt, but the call is
+// executed with the given state and block overrides, either
+// of which may be nil. The node must support geth's extra
+// eth_call parameters.
+func (c *Client) ConstCallOverride(opts *CallOpts, out interface{}, block int64, state StateOverride, overrides *BlockOverrides) error {
+	return c.ConstCallOverrideContext(context.Background(), opts, out, block, state, overrides)
+}
+
+// ConstCallOverrideContext is like ConstCallOverride, but it takes a context.
+func (c *Client) ConstCallOverrideContext(ctx context.Context, opts *CallOpts, out interface{}, block int64, state StateOverride, overrides *BlockOverrides) error {
+	buf, _ := json.Marshal(opts)
+	args := []json.RawMessage{buf, itobs(block)}
+	if state != nil || overrides != nil {
+		buf, _ = json.Marshal(state)
+		args = append(args, buf)
+	}
+	if overrides != nil {
+		buf, _ = json.Marshal(overrides)
+		args = append(args, buf)
+	}
+	return c.DoContext(ctx, "eth_call", args, out)
+}
@@ -0,0 +1,65 @@
+package seth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConstCallOverride(t *testing.T) {
+	// the node records the parameters of the last call
+	var params []json.RawMessage
+	node := newTestNode()
+	node.handle("eth_call", func(p []json.RawMessage) (interface{}, error) {
+		params = p
+		return Data{}, nil
+	})
+	c := NewClientTransport(node)
+	to := Address{1}
+	opts := &CallOpts{To: &to}
+	var out Data
+
+	check := func(want ...string) {
+		t.Helper()
+		if len(params) != len(want)+2 {
+			t.Fatalf("%d params; want %d", len(params), len(want)+2)
+		}
+		for i := range want {
+			if got := string(params[i+2]); got != want[i] {
+				t.Errorf("param %d is %s; want %s", i+2, got, want[i])
+			}
+		}
+	}
+
+	if err := c.ConstCallOverride(opts, &out, Latest, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	check()
+
+	nonce := Uint64(7)
+	code := Data{0x60, 0x00}
+	state := StateOverride{to: {
+		Balance:   NewInt(100),
+		Nonce:     &nonce,
+		Code:      &code,
+		StateDiff: map[Hash]Hash{{31: 1}: {31: 2}},
+	}}
+	if err := c.ConstCallOverride(opts, &out, Latest, state, nil); err != nil {
+		t.Fatal(err)
+	}
+	check(`{"0x0100000000000000000000000000000000000000":{"balance":"0x64","nonce":"0x7","code":"0x6000",` +
+		`"stateDiff":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000002"}}}`)
+
+	// an empty state is sent, since it clears the storage
+	state = StateOverride{to: {State: map[Hash]Hash{}}}
+	if err := c.ConstCallOverride(opts, &out, Latest, state, nil); err != nil {
+		t.Fatal(err)
+	}
+	check(`{"0x0100000000000000000000000000000000000000":{"state":{}}}`)
+
+	num, ts := Uint64(10), Uint64(1500000000)
+	bo := &BlockOverrides{Number: &num, Timestamp: &ts, BaseFee: NewInt(1e9)}
+	if err := c.ConstCallOverride(opts, &out, Latest, nil, bo); err != nil {
+		t.Fatal(err)
+	}
+	check(`null`, `{"number":"0xa","time":"0x59682f00","baseFeePerGas":"0x3b9aca00"}`)
+}
@@ -3,6 +3,7 @@ package seth
 import (
 	"encoding/json"
 	"errors"
+	"strconv"
 )
 
 var PasswordDenied = errors.New("password denied")
@@ -22,3 +23,51 @@ func (c *Client) UnlockAccount(addr *Address, password string) error {
 	}
 	return nil
 }
+
+// HashPersonalMessage returns the EIP-191 (version 0x45) hash of
+// msg, which is what personal_sign and eth_sign produce signatures
+// of. This is the Keccak-256 hash of
+//
+//	"\x19Ethereum Signed Message:\n" + len(msg) + msg
+func HashPersonalMessage(msg []byte) Hash {
+	buf := []byte("\x19Ethereum Signed Message:\n")
+	buf = strconv.AppendInt(buf, int64(len(msg)), 10)
+	return HashBytes(append(buf, msg...))
+}
+
+// HashValidatorMessage returns the EIP-191 version 0x00
+// hash of msg, which is data intended for the given
+// validator contract. This is the Keccak-256 hash of
+//
+//	0x19 ‖ 0x00 ‖ validator ‖ msg
+func HashValidatorMessage(validator *Address, msg []byte) Hash {
+	buf := make([]byte, 0, 2+len(validator)+len(msg))
+	buf = append(buf, 0x19, 0x00)
+	buf = append(buf, validator[:]...)
+	return HashBytes(append(buf, msg...))
+}
+
+// SignPersonal signs msg as a personal message (see HashPersonalMessage).
+// Note that the v value of the signature is 0 or 1; many other tools
+// add 27 to it.
+func SignPersonal(msg []byte, sign Signer) (*Signature, error) {
+	h := HashPersonalMessage(msg)
+	return sign(&h)
+}
+
+// VerifyPersonal returns whether or not sig is a signature of
+// the personal message msg (see HashPersonalMessage) produced by
+// the private key for addr. The v value of the signature may be
+// either 0 or 1 or (as produced by most other tools) 27 or 28.
+func VerifyPersonal(addr *Address, msg []byte, sig *Signature) bool {
+	h := HashPersonalMessage(msg)
+	s := *sig
+	if s[64] == 27 || s[64] == 28 {
+		s[64] -= 27
+	}
+	pub, err := s.Recover(&h)
+	if err != nil {
+		return false
+	}
+	return *pub.Address() == *addr
+}
@@ -0,0 +1,43 @@
+package seth
+
+import (
+	"testing"
+)
+
+func TestPersonalMessage(t *testing.T) {
+	h := HashPersonalMessage([]byte("Hello World"))
+	if h != hashOf(t, "0xa1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2") {
+		t.Errorf("HashPersonalMessage: got %s", &h)
+	}
+
+	key := GenPrivateKey()
+	msg := []byte("log in to example.com at 2018-10-01T00:00:00Z")
+	sig, err := SignPersonal(msg, key.Signer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyPersonal(key.Address(), msg, sig) {
+		t.Error("signature doesn't verify")
+	}
+	sig27 := *sig
+	sig27[64] += 27
+	if !VerifyPersonal(key.Address(), msg, &sig27) {
+		t.Error("signature with v+27 doesn't verify")
+	}
+	if VerifyPersonal(key.Address(), msg[1:], sig) {
+		t.Error("signature verified for the wrong message")
+	}
+	if VerifyPersonal(GenPrivateKey().Address(), msg, sig) {
+		t.Error("signature verified for the wrong address")
+	}
+	if VerifyPersonal(key.Address(), msg, new(Signature)) {
+		t.Error("invalid signature verified")
+	}
+
+	var validator Address
+	validator[19] = 1
+	want := HashBytes(append(append([]byte{0x19, 0x00}, validator[:]...), msg...))
+	if h := HashValidatorMessage(&validator, msg); h != want {
+		t.Errorf("HashValidatorMessage: got %s", &h)
+	}
+}
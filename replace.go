@@ -0,0 +1,243 @@
+package seth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// MinBump is the minimum percentage by which the fees of a
+// transaction have to be raised for nodes to accept it as a
+// replacement for a pending transaction with the same nonce.
+const MinBump = 10
+
+// ErrMined is returned when attempting to replace
+// a transaction that has already been mined.
+var ErrMined = errors.New("seth: transaction already mined")
+
+// ErrReplaced is returned when a transaction is replaced by
+// a different transaction with the same nonce, such as one
+// that was sent by another client using the same key.
+var ErrReplaced = errors.New("seth: transaction replaced by another transaction")
+
+// bump returns fee raised by pct percent, rounded up
+func bump(fee *Int, pct int) *Int {
+	if fee == nil {
+		return nil
+	}
+	v := new(big.Int).Mul(fee.Big(), big.NewInt(int64(100+pct)))
+	v.Add(v, big.NewInt(99))
+	v.Div(v, big.NewInt(100))
+	return (*Int)(v)
+}
+
+// dynamic returns whether tx is an EIP-1559 transaction
+func dynamic(tx *Transaction) bool {
+	return tx.Type == TxDynamicFee || tx.MaxFeePerGas != nil
+}
+
+// replacement returns the options of a transaction
+// that replaces tx, offering fees pct percent higher
+func replacement(tx *Transaction, pct int) *CallOpts {
+	nonce, value := tx.Nonce, tx.Value
+	opts := &CallOpts{
+		From:       tx.From,
+		To:         tx.To,
+		Gas:        NewInt(int64(tx.Gas)),
+		Value:      &value,
+		Data:       tx.Input,
+		Nonce:      &nonce,
+		AccessList: tx.AccessList,
+	}
+	if dynamic(tx) {
+		opts.MaxFeePerGas = bump(tx.MaxFeePerGas, pct)
+		opts.MaxPriorityFeePerGas = bump(tx.MaxPriorityFeePerGas, pct)
+	} else {
+		opts.GasPrice = bump(&tx.GasPrice, pct)
+	}
+	return opts
+}
+
+// SpeedUp replaces the pending transaction with the given hash
+// with a copy that offers fees that are bump percent higher (or
+// MinBump percent, if bump is less than that), and returns the
+// hash of the new transaction. Either transaction may end up
+// being mined.
+func (s *Sender) SpeedUp(h *Hash, bump int) (Hash, error) {
+	return s.SpeedUpContext(context.Background(), h, bump)
+}
+
+// SpeedUpContext is like SpeedUp, but it takes a context.
+func (s *Sender) SpeedUpContext(ctx context.Context, h *Hash, bump int) (Hash, error) {
+	tx, err := s.GetTransactionContext(ctx, h)
+	if err != nil {
+		return Hash{}, err
+	} else if tx.TxIndex != nil {
+		return Hash{}, ErrMined
+	}
+	if bump < MinBump {
+		bump = MinBump
+	}
+	return s.CallContext(ctx, replacement(tx, bump))
+}
+
+// DefaultReplaceTimeout is the default time that a Replacer
+// waits for a transaction to be mined before replacing it.
+const DefaultReplaceTimeout = 2 * time.Minute
+
+// A Replacer watches pending transactions sent by a Sender
+// and speeds up (see Sender.SpeedUp) those that haven't been
+// mined after a timeout, raising their fees again after each
+// timeout until a transaction is mined or the fees reach a cap.
+type Replacer struct {
+	Sender *Sender
+
+	// Timeout is the time to wait for a transaction to be mined
+	// before replacing it. If it is zero, DefaultReplaceTimeout
+	// is used.
+	Timeout time.Duration
+
+	// Bump is the percentage by which each replacement raises
+	// the fees. Values less than MinBump are treated as MinBump.
+	Bump int
+
+	// MaxFee, if set, caps the gas price (or, for EIP-1559
+	// transactions, the maximum fee per gas) of replacements.
+	MaxFee *Int
+
+	// Interval is the time between checks for whether
+	// a transaction has been mined. If it is zero,
+	// transactions are checked every 2 seconds.
+	Interval time.Duration
+}
+
+// NewReplacer constructs a Replacer with sane defaults.
+func NewReplacer(s *Sender) *Replacer {
+	return &Replacer{Sender: s, Timeout: DefaultReplaceTimeout, Bump: MinBump}
+}
+
+// next returns the options of a transaction replacing tx,
+// or nil if the cap on fees doesn't allow a replacement
+func (r *Replacer) next(tx *Transaction) *CallOpts {
+	pct := r.Bump
+	if pct < MinBump {
+		pct = MinBump
+	}
+	opts := replacement(tx, pct)
+	if r.MaxFee == nil {
+		return opts
+	}
+	capped := func(fee, old *Int) (*Int, bool) {
+		if fee == nil || fee.Cmp(r.MaxFee) <= 0 {
+			return fee, true
+		}
+		return r.MaxFee, r.MaxFee.Cmp(bump(old, MinBump)) >= 0
+	}
+	var ok1, ok2 bool
+	if dynamic(tx) {
+		opts.MaxFeePerGas, ok1 = capped(opts.MaxFeePerGas, tx.MaxFeePerGas)
+		opts.MaxPriorityFeePerGas, ok2 = capped(opts.MaxPriorityFeePerGas, tx.MaxPriorityFeePerGas)
+	} else {
+		opts.GasPrice, ok1 = capped(opts.GasPrice, &tx.GasPrice)
+		ok2 = true
+	}
+	if !ok1 || !ok2 {
+		return nil
+	}
+	return opts
+}
+
+// Wait waits for the transaction with the given hash, or one
+// of its replacements, to be mined, and returns the hash of
+// the transaction that was mined.
+func (r *Replacer) Wait(h *Hash) (Hash, error) {
+	return r.WaitContext(context.Background(), h)
+}
+
+// WaitContext is like Wait, but it takes a context.
+func (r *Replacer) WaitContext(ctx context.Context, h *Hash) (Hash, error) {
+	s := r.Sender
+	tx, err := s.GetTransactionContext(ctx, h)
+	if err != nil {
+		return Hash{}, err
+	}
+	if tx.TxIndex != nil {
+		return *h, nil
+	}
+	timeout, interval := r.Timeout, r.Interval
+	if timeout == 0 {
+		timeout = DefaultReplaceTimeout
+	}
+	if interval == 0 {
+		interval = 2 * time.Second
+	}
+	hashes := []Hash{*h}
+	sent := time.Now()
+	for {
+		// the nonce is read before the transactions are
+		// checked, so that if it has been used, one of our
+		// transactions is seen to be mined if it was
+		nonce, err := s.GetNonceAtContext(ctx, tx.From, Latest)
+		if err != nil {
+			return Hash{}, err
+		}
+		for i := len(hashes) - 1; i >= 0; i-- {
+			t, err := s.GetTransactionContext(ctx, &hashes[i])
+			if err == ErrNotFound {
+				// dropped in favor of a replacement
+				continue
+			} else if err != nil {
+				return Hash{}, err
+			}
+			if t.TxIndex != nil {
+				return hashes[i], nil
+			}
+		}
+		if uint64(nonce) > uint64(tx.Nonce) {
+			return Hash{}, ErrReplaced
+		}
+		if time.Since(sent) >= timeout {
+			if opts := r.next(tx); opts != nil {
+				nh, err := s.CallContext(ctx, opts)
+				switch {
+				case err == nil:
+					if s.Logf != nil {
+						s.Logf("%s: replaced by %s", hashes[len(hashes)-1].String(), nh.String())
+					}
+					hashes = append(hashes, nh)
+					tx = opts.Transaction()
+				case IsNonceError(err):
+					// mined in the meantime
+				default:
+					return Hash{}, err
+				}
+			}
+			sent = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return Hash{}, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Replaced is the outcome of a transaction watched by a Replacer.
+type Replaced struct {
+	Hash Hash  // hash of the transaction that was mined
+	Err  error // error waiting for the transaction, if any
+}
+
+// Watch waits in the background for the transaction with
+// the given hash to be mined, as described in Wait. The
+// outcome is sent on the returned channel.
+func (r *Replacer) Watch(ctx context.Context, h *Hash) <-chan Replaced {
+	out := make(chan Replaced, 1)
+	hash := *h
+	go func() {
+		mined, err := r.WaitContext(ctx, &hash)
+		out <- Replaced{Hash: mined, Err: err}
+	}()
+	return out
+}
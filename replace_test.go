@@ -0,0 +1,142 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpeedUp(t *testing.T) {
+	gwei := func(g float64) *Int { return NewInt(int64(math.Round(g * 1e9))) }
+	node := newTestNode()
+	node.mineAt = gwei(100)
+	c := NewClientTransport(node)
+	c.SetChainID(1)
+	key := GenPrivateKey()
+	s := NewSender(c, key.Address())
+	s.Signer = key.Signer()
+	to := Address{1}
+	opts := &CallOpts{
+		To:                   &to,
+		Gas:                  NewInt(21000),
+		Data:                 Data("payload"),
+		MaxFeePerGas:         gwei(20),
+		MaxPriorityFeePerGas: gwei(2),
+	}
+	h, err := s.Call(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bumps below the minimum are raised to it
+	h, err = s.SpeedUp(&h, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := node.tx(h)
+	if tx.MaxFeePerGas.Cmp(gwei(22)) != 0 || tx.MaxPriorityFeePerGas.Cmp(gwei(2.2)) != 0 {
+		t.Errorf("sped up with fees %v and %v", tx.MaxFeePerGas, tx.MaxPriorityFeePerGas)
+	}
+	if string(tx.Input) != "payload" || tx.Nonce != 0 || *tx.To != to {
+		t.Errorf("sped up transaction differs: %+v", tx)
+	}
+	if h, err = s.SpeedUp(&h, 50); err != nil {
+		t.Fatal(err)
+	}
+	if tx = node.tx(h); tx.MaxFeePerGas.Cmp(gwei(33)) != 0 {
+		t.Errorf("sped up with max fee %v", tx.MaxFeePerGas)
+	}
+
+	// cancellation offers enough to replace the transaction
+	s.GasPrice = *gwei(1)
+	h, err = s.Cancel(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx = node.tx(h); tx.GasPrice.Cmp(gwei(36.3)) != 0 || *tx.To != *s.Addr || len(tx.Input) != 0 {
+		t.Errorf("cancelled with %+v", tx)
+	}
+
+	// speeding up mined transactions fails
+	node.with(func() { node.mineAt = gwei(39) })
+	if h, err = s.SpeedUp(&h, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.SpeedUp(&h, 10); err != ErrMined {
+		t.Errorf("got %v instead of ErrMined", err)
+	}
+}
+
+func TestReplacer(t *testing.T) {
+	gwei := func(g float64) *Int { return NewInt(int64(math.Round(g * 1e9))) }
+	node := newTestNode()
+	node.mineAt = gwei(14)
+	c := NewClientTransport(node)
+	c.SetChainID(1)
+	key := GenPrivateKey()
+	s := NewSender(c, key.Address())
+	s.Signer = key.Signer()
+	to := Address{1}
+	send := func() Hash {
+		h, err := s.Call(&CallOpts{To: &to, Gas: NewInt(21000), GasPrice: gwei(10)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	r := NewReplacer(s)
+	r.Timeout = time.Millisecond
+	r.Interval = time.Millisecond
+	ctx := context.Background()
+
+	// 10 -> 11 -> 12.1 -> 13.31 -> 14.641 gwei
+	h := send()
+	res := <-r.Watch(ctx, &h)
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	sent := node.sentTxs()
+	if len(sent) != 5 {
+		t.Errorf("sent %d transactions", len(sent))
+	}
+	if tx := sent[len(sent)-1]; res.Hash != tx.Hash || tx.TxIndex == nil {
+		t.Errorf("got hash %s instead of %s", &res.Hash, &tx.Hash)
+	}
+
+	// replacements stop at the cap; the node stops
+	// the watcher once it sees the capped price
+	node.with(func() { node.sent = nil })
+	r.MaxFee = gwei(12.5)
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	node.handle("eth_sendRawTransaction", func(params []json.RawMessage) (interface{}, error) {
+		ret, err := node.sendRaw(params)
+		if err == nil && price(node.sent[len(node.sent)-1]).Cmp(gwei(12.1)) == 0 {
+			cancel()
+		}
+		return ret, err
+	})
+	h = send()
+	if _, err := r.WaitContext(cctx, &h); err != context.Canceled {
+		t.Errorf("got %v instead of cancellation", err)
+	}
+	if sent := node.sentTxs(); len(sent) != 3 || price(sent[2]).Cmp(gwei(12.1)) != 0 {
+		t.Errorf("sent %d transactions", len(sent))
+	}
+	node.handle("eth_sendRawTransaction", node.sendRaw)
+
+	// the nonce is used by some other transaction
+	node.with(func() {
+		node.drop(node.nonce)
+		node.nonce++
+	})
+	r.MaxFee = nil
+	r.Timeout = time.Hour
+	h = send()
+	node.with(func() { node.nonce++ })
+	if _, err := r.Wait(&h); err != ErrReplaced {
+		t.Errorf("got %v instead of ErrReplaced", err)
+	}
+}
@@ -0,0 +1,147 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	errorSelector = [4]byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = [4]byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// panicNames are the descriptions of the
+// panic codes emitted by the solidity compiler
+var panicNames = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop from empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to uninitialized function",
+}
+
+// RevertError is the error returned when an EVM
+// call reverts. Calls that revert with an Error(string)
+// (as revert("reason") and require(cond, "reason") do)
+// have Name set to "Error" and Reason set to the reason.
+// Calls that fail with a Panic(uint256) (as failed
+// assertions and checked arithmetic do) have Name set
+// to "Panic" and Code set to the panic code. Custom
+// errors are decoded when the error is declared in the
+// ABI passed to DecodeRevert; Name is set to the name
+// of the custom error and Args holds its arguments.
+type RevertError struct {
+	Data Data   // raw revert data
+	Name string // error name, or "" if the data couldn't be decoded
+
+	Reason string // Error(string) reason
+	Code   uint64 // Panic(uint256) code
+
+	Descriptor *ABIDescriptor         // custom error descriptor
+	Args       map[string]interface{} // custom error arguments
+}
+
+// DecodeRevert decodes the return data of a reverted
+// call. Custom errors are matched against the error
+// descriptors in a, which may be nil. DecodeRevert never
+// fails; revert data that can't be decoded is simply
+// stored in the returned error's Data field.
+func DecodeRevert(data []byte, a *ABI) *RevertError {
+	e := &RevertError{Data: Data(data)}
+	if len(data) < 4 {
+		return e
+	}
+	var sel [4]byte
+	copy(sel[:], data)
+	args := data[4:]
+	switch sel {
+	case errorSelector:
+		if DecodeABI(args, &e.Reason) == nil {
+			e.Name = "Error"
+		}
+	case panicSelector:
+		var code big.Int
+		if DecodeABI(args, &code) == nil && code.IsUint64() {
+			e.Name, e.Code = "Panic", code.Uint64()
+		}
+	default:
+		if a == nil {
+			break
+		}
+		d := a.errors[sel]
+		if d == nil {
+			break
+		}
+		t, err := paramsType(d.Inputs)
+		if err != nil {
+			break
+		}
+		for i := range t.names {
+			if t.names[i] == "" {
+				t.names[i] = fmt.Sprintf("arg%d", i)
+			}
+		}
+		var m map[string]interface{}
+		if decodeArgs(args, t, []interface{}{&m}) == nil {
+			e.Name, e.Descriptor, e.Args = d.Name, d, m
+		}
+	}
+	return e
+}
+
+// PanicName returns a description of the panic
+// code of a Panic(uint256) revert.
+func (e *RevertError) PanicName() string {
+	if s, ok := panicNames[e.Code]; ok {
+		return s
+	}
+	return "unknown panic"
+}
+
+// Error implements error
+func (e *RevertError) Error() string {
+	switch {
+	case e.Name == "Error":
+		return "execution reverted: " + e.Reason
+	case e.Name == "Panic":
+		return fmt.Sprintf("execution reverted: panic 0x%x (%s)", e.Code, e.PanicName())
+	case e.Descriptor != nil:
+		args := make([]string, len(e.Descriptor.Inputs))
+		for i := range e.Descriptor.Inputs {
+			name := e.Descriptor.Inputs[i].Name
+			if name == "" {
+				name = fmt.Sprintf("arg%d", i)
+			}
+			args[i] = fmt.Sprintf("%s: %v", name, e.Args[name])
+		}
+		return "execution reverted: " + e.Name + "(" + strings.Join(args, ", ") + ")"
+	case len(e.Data) > 0:
+		return "execution reverted (data " + e.Data.String() + ")"
+	}
+	return "execution reverted"
+}
+
+// revertError returns the RevertError described
+// by an RPC error, or nil if the error doesn't
+// describe a reverted call
+func revertError(e *RPCError) *RevertError {
+	if len(e.Data) == 0 || bytes.Equal(e.Data, rawnull) {
+		return nil
+	}
+	if e.Code != 3 && !strings.Contains(e.Message, "revert") {
+		return nil
+	}
+	var data Data
+	if json.Unmarshal(e.Data, &data) != nil {
+		return nil
+	}
+	return DecodeRevert(data, nil)
+}
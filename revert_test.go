@@ -0,0 +1,53 @@
+package seth
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+type revertTransport Data
+
+func (r revertTransport) Execute(req *RPCRequest, res *RPCResponse) error {
+	d := Data(r)
+	res.Error.Code = 3
+	res.Error.Message = "execution reverted"
+	res.Error.Data, _ = json.Marshal(&d)
+	return nil
+}
+
+func TestDecodeRevert(t *testing.T) {
+	t.Parallel()
+	a := MustParseABI(`[{"type":"error","name":"Short","inputs":[{"name":"have","type":"uint256"},{"name":"want","type":"uint256"}]}]`)
+
+	e := DecodeRevert(words(t, "08c379a0", word("20"), word("2"), rword("6e6f")), nil)
+	if e.Name != "Error" || e.Reason != "no" || e.Error() != "execution reverted: no" {
+		t.Errorf("Error(string): got %+v", e)
+	}
+	e = DecodeRevert(words(t, "4e487b71", word("11")), nil)
+	if e.Name != "Panic" || e.Code != 0x11 || e.PanicName() != "arithmetic overflow or underflow" {
+		t.Errorf("Panic(uint256): got %+v", e)
+	}
+
+	custom := words(t, "", word("1"), word("2"))
+	sel := a.Descriptors[0].Selector()
+	custom = append(sel[:], custom...)
+	e = DecodeRevert(custom, nil)
+	if e.Name != "" || len(e.Data) != len(custom) {
+		t.Errorf("custom error without ABI: got %+v", e)
+	}
+	e = DecodeRevert(custom, a)
+	if e.Name != "Short" || e.Args["want"].(*big.Int).Int64() != 2 {
+		t.Errorf("custom error: got %+v", e)
+	}
+	if s := e.Error(); s != "execution reverted: Short(have: 1, want: 2)" {
+		t.Errorf("custom error: got %q", s)
+	}
+
+	c := NewClientTransport(revertTransport(words(t, "4e487b71", word("1"))))
+	var out Data
+	err := c.ConstCall(&CallOpts{}, &out, false)
+	if re, ok := err.(*RevertError); !ok || re.Name != "Panic" || re.Code != 1 {
+		t.Errorf("Client.Do: got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package rlp
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Errors returned by a Decoder.
+var (
+	ErrShort        = errors.New("rlp: unexpected end of input")
+	ErrNonCanonical = errors.New("rlp: non-canonical encoding")
+	ErrNotString    = errors.New("rlp: expected a string")
+	ErrNotList      = errors.New("rlp: expected a list")
+	ErrTrailing     = errors.New("rlp: trailing data")
+	ErrIntSize      = errors.New("rlp: integer too large")
+	ErrFixedSize    = errors.New("rlp: wrong length for fixed-size value")
+	ErrTooLarge     = errors.New("rlp: item too large")
+)
+
+// maxSize is the largest item size that can be decoded
+const maxSize = int(^uint(0) >> 1)
+
+// A Decoder decodes RLP values from the front of a byte slice,
+// so Decoder(b) decodes the values encoded in b. Only canonical
+// encodings are accepted: single bytes below 0x80 must be
+// encoded as themselves, lengths must use the shortest possible
+// header, and integers must not have leading zeros.
+type Decoder []byte
+
+// Empty returns whether or not all of the input has been consumed.
+func (d *Decoder) Empty() bool {
+	return len(*d) == 0
+}
+
+// next consumes the next item, returning its contents
+// and whether or not it is a list.
+func (d *Decoder) next() (content []byte, list bool, err error) {
+	b := *d
+	if len(b) == 0 {
+		return nil, false, ErrShort
+	}
+	h := b[0]
+	var size, hlen int
+	switch {
+	case h < 0x80:
+		*d = b[1:]
+		return b[:1], false, nil
+	case h < 0xB8:
+		size, hlen = int(h-0x80), 1
+		if size == 1 && len(b) > 1 && b[1] < 0x80 {
+			return nil, false, ErrNonCanonical
+		}
+	case h < 0xC0:
+		size, hlen, err = length(b, int(h-0xB7))
+	case h < 0xF8:
+		size, hlen, list = int(h-0xC0), 1, true
+	default:
+		size, hlen, err = length(b, int(h-0xF7))
+		list = true
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(b)-hlen < size {
+		return nil, false, ErrShort
+	}
+	*d = b[hlen+size:]
+	return b[hlen : hlen+size], list, nil
+}
+
+// length decodes the n-byte length of a long
+// string or list, returning the length and the
+// total size of the item header
+func length(b []byte, n int) (size, hlen int, err error) {
+	if n > 4 {
+		return 0, 0, ErrTooLarge
+	}
+	if len(b) < 1+n {
+		return 0, 0, ErrShort
+	}
+	if b[1] == 0 {
+		return 0, 0, ErrNonCanonical
+	}
+	var u uint64
+	for _, c := range b[1 : 1+n] {
+		u = u<<8 | uint64(c)
+	}
+	if u > uint64(maxSize) {
+		// possible where int is 32 bits
+		return 0, 0, ErrTooLarge
+	}
+	if u < 56 {
+		return 0, 0, ErrNonCanonical
+	}
+	return int(u), 1 + n, nil
+}
+
+// DecodeString decodes a string. The returned
+// slice refers to the input of the decoder.
+func (d *Decoder) DecodeString() ([]byte, error) {
+	b, list, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	if list {
+		return nil, ErrNotString
+	}
+	return b, nil
+}
+
+// DecodeList decodes a list, returning
+// a decoder for the list items.
+func (d *Decoder) DecodeList() (Decoder, error) {
+	b, list, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	if !list {
+		return nil, ErrNotList
+	}
+	return Decoder(b), nil
+}
+
+// decodeInt decodes the big-endian bytes of an integer.
+func (d *Decoder) decodeInt() ([]byte, error) {
+	b, err := d.DecodeString()
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > 0 && b[0] == 0 {
+		return nil, ErrNonCanonical
+	}
+	return b, nil
+}
+
+// DecodeUint decodes an integer that fits in a uint64.
+func (d *Decoder) DecodeUint() (uint64, error) {
+	b, err := d.decodeInt()
+	if err != nil {
+		return 0, err
+	}
+	if len(b) > 8 {
+		return 0, ErrIntSize
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// DecodeBig decodes an arbitrary-precision integer.
+func (d *Decoder) DecodeBig() (*big.Int, error) {
+	b, err := d.decodeInt()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// DecodeFixed decodes a string of exactly len(dst)
+// bytes (such as an address or a hash) into dst.
+func (d *Decoder) DecodeFixed(dst []byte) error {
+	b, err := d.DecodeString()
+	if err != nil {
+		return err
+	}
+	if len(b) != len(dst) {
+		return ErrFixedSize
+	}
+	copy(dst, b)
+	return nil
+}
@@ -0,0 +1,121 @@
+package rlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(strings.Replace(s, " ", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeUint(t *testing.T) {
+	for _, n := range []uint64{0, 1, 0x7f, 0x80, 0x400, 0xffffff, 0xffffffffffffffff} {
+		enc, _ := Encode(n)
+		d := Decoder(enc)
+		got, err := d.DecodeUint()
+		if err != nil {
+			t.Errorf("%#x: %s", n, err)
+		} else if got != n || !d.Empty() {
+			t.Errorf("%#x: got %#x", n, got)
+		}
+	}
+	d := Decoder(unhex(t, "820400"))
+	if b, err := d.DecodeBig(); err != nil || b.Int64() != 0x400 {
+		t.Errorf("DecodeBig: got %v, %v", b, err)
+	}
+}
+
+func TestDecodeString(t *testing.T) {
+	for _, s := range [][]byte{{}, {0}, {0x7f}, {0x80}, []byte("dog"), bytes.Repeat([]byte{'a'}, 56), bytes.Repeat([]byte{'b'}, 1024)} {
+		enc, _ := Encode(s)
+		d := Decoder(enc)
+		got, err := d.DecodeString()
+		if err != nil {
+			t.Errorf("%x: %s", s, err)
+		} else if !bytes.Equal(got, s) || !d.Empty() {
+			t.Errorf("%x: got %x", s, got)
+		}
+	}
+	var addr [20]byte
+	d := Decoder(unhex(t, "94"+strings.Repeat("00", 19)+"01"))
+	if err := d.DecodeFixed(addr[:]); err != nil || addr[19] != 1 {
+		t.Errorf("DecodeFixed: got %x, %v", addr, err)
+	}
+	var hash [32]byte
+	d = Decoder(unhex(t, "83646f67"))
+	if err := d.DecodeFixed(hash[:]); err != ErrFixedSize {
+		t.Errorf("DecodeFixed: got error %v", err)
+	}
+}
+
+func TestDecodeList(t *testing.T) {
+	// ["cat", ["dog"], 1024]
+	d := Decoder(unhex(t, "CC 83636174 C4 83646F67 820400"))
+	l, err := d.DecodeList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := l.DecodeString(); err != nil || string(s) != "cat" {
+		t.Errorf("got %q, %v", s, err)
+	}
+	inner, err := l.DecodeList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := inner.DecodeString(); err != nil || string(s) != "dog" || !inner.Empty() {
+		t.Errorf("got %q, %v", s, err)
+	}
+	if n, err := l.DecodeUint(); err != nil || n != 1024 {
+		t.Errorf("got %d, %v", n, err)
+	}
+	if !l.Empty() || !d.Empty() {
+		t.Error("expected all input to be consumed")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		int   bool
+		err   error
+	}{
+		{input: "", err: ErrShort},
+		{input: "83 6161", err: ErrShort},
+		{input: "81 05", err: ErrNonCanonical},            // single byte with a header
+		{input: "B8 05 0102030405", err: ErrNonCanonical}, // short string with a long header
+		{input: "B9 0005", err: ErrNonCanonical},          // length with a leading zero
+		{input: "BC 0100000000", err: ErrTooLarge},        // 5-byte length
+		{input: "FC 0100000000", err: ErrTooLarge},
+		{input: "C2 8161", err: ErrNotString},
+		{input: "82 0001", int: true, err: ErrNonCanonical}, // integer with a leading zero
+		{input: "00", int: true, err: ErrNonCanonical},      // zero is the empty string
+		{input: "89 010203040506070809", int: true, err: ErrIntSize},
+	}
+	for i, test := range tests {
+		d := Decoder(unhex(t, test.input))
+		var err error
+		if test.int {
+			_, err = d.DecodeUint()
+		} else {
+			_, err = d.DecodeString()
+		}
+		if err != test.err {
+			t.Errorf("test %d: got error %v; want %v", i, err, test.err)
+		}
+	}
+	d := Decoder(unhex(t, "8161"))
+	if _, err := d.DecodeList(); err != ErrNonCanonical {
+		t.Errorf("got error %v", err)
+	}
+	d = Decoder(unhex(t, "83616161"))
+	if _, err := d.DecodeList(); err != ErrNotList {
+		t.Errorf("got error %v", err)
+	}
+}
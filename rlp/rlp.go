@@ -0,0 +1,297 @@
+// Package rlp implements the Recursive Length Prefix
+// encoding used by Ethereum to serialize transactions,
+// block headers, and the nodes of Merkle-Patricia tries.
+package rlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// An Encoder is a type that knows how to encode itself.
+// Pointer types that implement Encoder have EncodeRLP
+// called even when they are nil.
+type Encoder interface {
+	// EncodeRLP appends the encoding of
+	// the receiver to dst and returns the
+	// extended buffer.
+	EncodeRLP(dst []byte) ([]byte, error)
+}
+
+// Raw is an encoded RLP value. It is
+// written to the output as-is.
+type Raw []byte
+
+var (
+	encoderType = reflect.TypeOf((*Encoder)(nil)).Elem()
+	bigType     = reflect.TypeOf(big.Int{})
+	rawType     = reflect.TypeOf(Raw(nil))
+)
+
+// ErrNegative is returned when encoding a negative
+// integer, since RLP has no representation for them.
+var ErrNegative = errors.New("rlp: cannot encode a negative integer")
+
+// Encode returns the RLP encoding of v.
+//
+// Byte slices, byte arrays (including types like
+// seth.Address and seth.Hash), and strings are encoded
+// as RLP strings. Unsigned integers, big.Int, and bool
+// are encoded as big-endian integers without leading
+// zeros. Other slices and arrays are encoded as lists
+// of their elements, and structs are encoded as lists of
+// their exported fields in the order that they are declared.
+// Pointers are encoded as the value they point to, and nil
+// pointers are encoded as an empty list if they point to a
+// struct, slice, or array type that would be encoded as a list,
+// or as an empty string otherwise. A nil interface is encoded
+// as an empty list.
+//
+// Struct fields may be annotated with an "rlp" tag:
+//
+//	`rlp:"-"`        the field is ignored
+//	`rlp:"optional"` the field is omitted if it and all of the
+//	                 fields that follow it are zero values; only
+//	                 optional fields may follow an optional field
+//	`rlp:"tail"`     the field must be the last field and must be
+//	                 a slice, and its elements are encoded directly
+//	                 into the struct's list rather than as a list
+//
+// Signed integers, floats, maps, channels, and functions
+// cannot be encoded.
+func Encode(v interface{}) ([]byte, error) {
+	return Append(nil, v)
+}
+
+// Append is like Encode, but it appends the encoding of v to dst.
+func Append(dst []byte, v interface{}) ([]byte, error) {
+	if v == nil {
+		return append(dst, 0xC0), nil
+	}
+	return appendValue(dst, reflect.ValueOf(v))
+}
+
+// AppendString appends the encoding of the string b to dst.
+func AppendString(dst, b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return append(dst, b[0])
+	}
+	return append(appendHeader(dst, 0x80, len(b)), b...)
+}
+
+// AppendUint appends the encoding of n to dst.
+func AppendUint(dst []byte, n uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return AppendString(dst, trim(buf[:]))
+}
+
+// AppendBig appends the encoding of i to dst.
+// A nil i is encoded as zero.
+func AppendBig(dst []byte, i *big.Int) ([]byte, error) {
+	if i == nil {
+		return append(dst, 0x80), nil
+	}
+	if i.Sign() < 0 {
+		return nil, ErrNegative
+	}
+	return AppendString(dst, i.Bytes()), nil
+}
+
+// AppendList appends a list to dst. The
+// items of the list must already be encoded
+// and concatenated together.
+func AppendList(dst, items []byte) []byte {
+	return append(appendHeader(dst, 0xC0, len(items)), items...)
+}
+
+// trim strips leading zeros from b
+func trim(b []byte) []byte {
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+func appendHeader(dst []byte, base byte, size int) []byte {
+	if size < 56 {
+		return append(dst, base+byte(size))
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(size))
+	n := trim(buf[:])
+	dst = append(dst, base+55+byte(len(n)))
+	return append(dst, n...)
+}
+
+// isBytes returns whether or not t is
+// a byte slice or array
+func isBytes(t reflect.Type) bool {
+	k := t.Kind()
+	return (k == reflect.Slice || k == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// appendNil appends the encoding of a nil pointer to t
+func appendNil(dst []byte, t reflect.Type) []byte {
+	switch t.Kind() {
+	case reflect.Struct:
+		if t != bigType {
+			return append(dst, 0xC0)
+		}
+	case reflect.Slice, reflect.Array:
+		if !isBytes(t) {
+			return append(dst, 0xC0)
+		}
+	}
+	return append(dst, 0x80)
+}
+
+func appendValue(dst []byte, v reflect.Value) ([]byte, error) {
+	t := v.Type()
+	switch {
+	case t == rawType:
+		return append(dst, v.Bytes()...), nil
+	case t.Implements(encoderType):
+		return v.Interface().(Encoder).EncodeRLP(dst)
+	case t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(encoderType):
+		if !v.CanAddr() {
+			p := reflect.New(t)
+			p.Elem().Set(v)
+			v = p.Elem()
+		}
+		return v.Addr().Interface().(Encoder).EncodeRLP(dst)
+	case t == bigType:
+		i := v.Interface().(big.Int)
+		return AppendBig(dst, &i)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return appendNil(dst, t.Elem()), nil
+		}
+		return appendValue(dst, v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return append(dst, 0xC0), nil
+		}
+		return appendValue(dst, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return append(dst, 0x01), nil
+		}
+		return append(dst, 0x80), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return AppendUint(dst, v.Uint()), nil
+	case reflect.String:
+		return AppendString(dst, []byte(v.String())), nil
+	case reflect.Slice, reflect.Array:
+		if isBytes(t) {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return AppendString(dst, b), nil
+		}
+		items, err := appendItems(nil, v)
+		if err != nil {
+			return nil, err
+		}
+		return AppendList(dst, items), nil
+	case reflect.Struct:
+		return appendStruct(dst, v)
+	}
+	return nil, fmt.Errorf("rlp: cannot encode type %s", t)
+}
+
+// appendItems appends the encodings of
+// the elements of a slice or array
+func appendItems(dst []byte, v reflect.Value) ([]byte, error) {
+	var err error
+	for i := 0; i < v.Len(); i++ {
+		if dst, err = appendValue(dst, v.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// A field is an encoded struct field.
+type field struct {
+	index    int
+	optional bool
+	tail     bool
+}
+
+// fields returns the encoded fields of a struct type
+func fields(t reflect.Type) ([]field, error) {
+	var out []field
+	optional := false
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		f := field{index: i}
+		for _, opt := range strings.Split(sf.Tag.Get("rlp"), ",") {
+			switch opt {
+			case "":
+			case "-":
+				f.index = -1
+			case "optional":
+				f.optional = true
+			case "tail":
+				f.tail = true
+			default:
+				return nil, fmt.Errorf("rlp: unknown tag %q on %s.%s", opt, t, sf.Name)
+			}
+		}
+		if f.index < 0 {
+			continue
+		}
+		if f.tail && sf.Type.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("rlp: tail field %s.%s is not a slice", t, sf.Name)
+		}
+		if len(out) > 0 && out[len(out)-1].tail {
+			return nil, fmt.Errorf("rlp: tail field of %s is not the last field", t)
+		}
+		if optional && !f.optional && !f.tail {
+			return nil, fmt.Errorf("rlp: field %s.%s must be optional because it follows an optional field", t, sf.Name)
+		}
+		optional = optional || f.optional
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func appendStruct(dst []byte, v reflect.Value) ([]byte, error) {
+	fs, err := fields(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	// trailing optional fields with zero values are omitted
+	end := len(fs)
+	for end > 0 {
+		f := fs[end-1]
+		if !f.optional && !f.tail || !v.Field(f.index).IsZero() {
+			break
+		}
+		end--
+	}
+
+	var items []byte
+	for _, f := range fs[:end] {
+		fv := v.Field(f.index)
+		if f.tail {
+			items, err = appendItems(items, fv)
+		} else {
+			items, err = appendValue(items, fv)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return AppendList(dst, items), nil
+}
@@ -0,0 +1,158 @@
+package rlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+type simple struct {
+	A uint
+	B string
+}
+
+type optional struct {
+	A uint
+	B uint   `rlp:"optional"`
+	C []byte `rlp:"optional"`
+}
+
+type tail struct {
+	A    uint
+	skip uint
+	S    string `rlp:"-"`
+	Rest []uint `rlp:"tail"`
+}
+
+type encoder struct{}
+
+func (e *encoder) EncodeRLP(dst []byte) ([]byte, error) {
+	if e == nil {
+		return append(dst, 0x80), nil
+	}
+	return append(dst, 0x42), nil
+}
+
+type address [20]byte
+
+func bigint(s string) *big.Int {
+	i, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		panic(s)
+	}
+	return i
+}
+
+func TestEncode(t *testing.T) {
+	var nilbig *big.Int
+	var nilstruct *simple
+	var niladdr *address
+	var nilenc *encoder
+	tests := []struct {
+		in  interface{}
+		out string
+	}{
+		{uint(0), "80"},
+		{uint8(0x7f), "7f"},
+		{uint16(0x80), "8180"},
+		{uint64(0x0400), "820400"},
+		{uint64(0xffffffffffffffff), "88ffffffffffffffff"},
+		{true, "01"},
+		{false, "80"},
+		{"", "80"},
+		{"dog", "83646f67"},
+		{[]byte{0}, "00"},
+		{[]byte{0x80}, "8180"},
+		{strings.Repeat("a", 56), "b838" + strings.Repeat("61", 56)},
+		{big.NewInt(0), "80"},
+		{big.NewInt(0x0400), "820400"},
+		{bigint("0x100102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"), "a0100102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"},
+		{*big.NewInt(127), "7f"},
+		{nilbig, "80"},
+		{address{19: 1}, "94" + strings.Repeat("00", 19) + "01"},
+		{niladdr, "80"},
+		{[]string{"cat", "dog"}, "c88363617483646f67"},
+		{[]uint{}, "c0"},
+		{[]interface{}{[]interface{}{}, []interface{}{[]interface{}{}}, []interface{}{[]interface{}{}, []interface{}{[]interface{}{}}}}, "c7c0c1c0c3c0c1c0"},
+		{[]interface{}{uint(1), "a", nil}, "c30161c0"},
+		{[3]uint16{1, 2, 3}, "c3010203"},
+		{[]string{strings.Repeat("a", 60)}, "f83eb83c" + strings.Repeat("61", 60)},
+		{simple{A: 1, B: "cat"}, "c50183636174"},
+		{&simple{A: 1}, "c20180"},
+		{nilstruct, "c0"},
+		{optional{A: 1}, "c101"},
+		{optional{A: 1, B: 2}, "c20102"},
+		{optional{A: 1, C: []byte{3}}, "c3018003"},
+		{tail{A: 1, skip: 5, S: "x", Rest: []uint{2, 3}}, "c3010203"},
+		{tail{A: 1}, "c101"},
+		{&encoder{}, "42"},
+		{nilenc, "80"},
+		{[]encoder{{}}, "c142"},
+		{Raw{0xc1, 0x01}, "c101"},
+		{[]interface{}{Raw{0xc1, 0x01}, uint(2)}, "c3c10102"},
+	}
+	for i, test := range tests {
+		got, err := Encode(test.in)
+		if err != nil {
+			t.Errorf("test %d (%T): %s", i, test.in, err)
+			continue
+		}
+		want, err := hex.DecodeString(test.out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("test %d (%T): got %x; want %x", i, test.in, got, want)
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	type badtail struct {
+		Rest []uint `rlp:"tail"`
+		A    uint
+	}
+	type badoptional struct {
+		A uint `rlp:"optional"`
+		B uint
+	}
+	type badtag struct {
+		A uint `rlp:"bogus"`
+	}
+	tests := []interface{}{
+		1,
+		big.NewInt(-1),
+		[]interface{}{uint(1), 1.5},
+		map[string]uint{},
+		badtail{},
+		badoptional{},
+		badtag{},
+	}
+	for i, in := range tests {
+		if b, err := Encode(in); err == nil {
+			t.Errorf("test %d (%T): expected an error; got %x", i, in, b)
+		}
+	}
+	if _, err := Encode(big.NewInt(-1)); err != ErrNegative {
+		t.Errorf("expected ErrNegative; got %v", err)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	items := AppendUint(nil, 1)
+	items = AppendString(items, []byte("cat"))
+	items, err := AppendBig(items, big.NewInt(0x0400))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := AppendList([]byte{0xff}, items)
+	want := []byte{0xff, 0xc8, 0x01, 0x83, 'c', 'a', 't', 0x82, 0x04, 0x00}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x; want %x", got, want)
+	}
+	if got, _ := Append(got[:1], []uint{1}); !bytes.Equal(got, []byte{0xff, 0xc1, 0x01}) {
+		t.Errorf("Append: got %x", got)
+	}
+}
@@ -1,11 +1,10 @@
 package seth
 
 import (
-	"encoding/binary"
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"strings"
 )
 
 // EtherType represents a type in the
@@ -73,12 +72,6 @@ func (b *Bytes) EncodeABI(v []byte) []byte {
 func (b *Bytes) Len() int  { return len(*b) }
 func (b *Bytes) internal() {}
 
-func padint(i int, v []byte) []byte {
-	var w [32]byte
-	binary.BigEndian.PutUint64(w[32-8:], uint64(i))
-	return append(v, w[:]...)
-}
-
 func padright(b []byte) []byte {
 	p := make([]byte, (len(b)+31)&-32)
 	copy(p, b)
@@ -138,16 +131,27 @@ type CallOpts struct {
 	Value    *Int     `json:"value,omitempty"`    // Value to send
 	Data     Data     `json:"data"`               // Input to the call
 	Nonce    *Uint64  `json:"nonce,omitempty"`    // Nonce of the call
+
+	MaxFeePerGas         *Int       `json:"maxFeePerGas,omitempty"`         // EIP-1559 fee cap
+	MaxPriorityFeePerGas *Int       `json:"maxPriorityFeePerGas,omitempty"` // EIP-1559 tip cap
+	AccessList           AccessList `json:"accessList,omitempty"`           // EIP-2930 access list
 }
 
 // Transaction returns a transaction structure representing this call.
+// If either of the EIP-1559 fee fields are set, the transaction is an
+// EIP-1559 transaction. Otherwise, if an access list is present, the
+// transaction is an EIP-2930 transaction, and if not, it is a legacy
+// transaction.
 func (o *CallOpts) Transaction() *Transaction {
 	tx := &Transaction{
-		From:     o.From,
-		To:       o.To,
-		Gas:      Uint64(o.Gas.Uint64()),
-		GasPrice: *o.GasPrice,
-		Input:    o.Data,
+		From:       o.From,
+		To:         o.To,
+		Gas:        Uint64(o.Gas.Uint64()),
+		Input:      o.Data,
+		AccessList: o.AccessList,
+	}
+	if o.GasPrice != nil {
+		tx.GasPrice = *o.GasPrice
 	}
 	if o.Value != nil {
 		tx.Value = *o.Value
@@ -155,6 +159,14 @@ func (o *CallOpts) Transaction() *Transaction {
 	if o.Nonce != nil {
 		tx.Nonce = *o.Nonce
 	}
+	switch {
+	case o.MaxFeePerGas != nil || o.MaxPriorityFeePerGas != nil:
+		tx.Type = TxDynamicFee
+		tx.MaxFeePerGas = o.MaxFeePerGas
+		tx.MaxPriorityFeePerGas = o.MaxPriorityFeePerGas
+	case o.AccessList != nil:
+		tx.Type = TxAccessList
+	}
 	return tx
 }
 
@@ -162,74 +174,43 @@ const illegal = " \t\n\b-+/~!@#$%^&*=|;:\"<>\\?"
 
 // check that the given arguments correspond
 // to the arguments given in the function signature 'f'
-// where 'f' is of the form
-//  name(type0,type1,type2)
-func typecheck(f string, args []EtherType) {
-	if strings.ContainsAny(f, illegal) {
-		panic("illegal characters in function signature string")
-	}
-	lparen := strings.IndexByte(f, '(')
-	if lparen == -1 {
-		panic(f + " has no left paren")
-	}
-	rparen := strings.IndexByte(f, ')')
-	if rparen != len(f)-1 {
-		panic(f + " has a bad right paren")
-	}
-	var argstrings []string
-	if strings.Contains(f[lparen+1:rparen], ",") {
-		argstrings = strings.Split(f[lparen+1:rparen], ",")
-		if len(argstrings) != len(args) {
-			panic(fmt.Sprintf("mismatched argument lists: %d args vs %d given", len(argstrings), len(args)))
-		}
+// where 'f' is of the form 'name(type0,type1,type2)',
+// and return the tuple of argument types
+func typecheck(f string, args []EtherType) *abiType {
+	_, t, err := parseSignature(f)
+	if err != nil {
+		panic(err.Error())
 	}
-	for i := range argstrings {
-		switch argstrings[i] {
-		case "address":
-			if _, ok := args[i].(*Address); !ok {
-				panic("address argument not an address")
-			}
-		case "uint", "uint256", "int", "int256":
-			if _, ok := args[i].(*Int); !ok {
-				panic(argstrings[i] + " argument not an Int")
-			}
-		case "bytes32":
-			if _, ok := args[i].(*Data); !ok {
-				if _, ok = args[i].(*Int); !ok {
-					panic(argstrings[i] + "argument not an Int or Data")
-				}
-			}
-		default:
-			if strings.HasSuffix(argstrings[i], "[]") {
-				if _, ok := args[i].(EtherSlice); !ok {
-					panic("argument not a slice")
-				}
-			}
-			// TODO: more typechecking
-		}
+	if len(t.elems) != len(args) {
+		panic(fmt.Sprintf("mismatched argument lists: %d args vs %d given", len(t.elems), len(args)))
 	}
+	return t
 }
 
-// ABIEncode encodes a function and its arguments
+// ABIEncode encodes a function and its arguments.
+// The function signature may use any of the types
+// supported by EncodeParams, including tuples, which
+// are written as parenthesized lists of member types,
+// e.g. "f((uint256,address)[],bytes)".
+//
+// ABIEncode panics if the arguments do not match
+// the function signature.
 func ABIEncode(fn string, args ...EtherType) []byte {
-	typecheck(fn, args)
+	t := typecheck(fn, args)
 
 	buf := make([]byte, 4, 4+len(args)*32)
 	fhash := HashString(fn)
 	copy(buf[:4], fhash[:4])
 
-	var dyn []byte
-	dynoff := len(args) * 32
-	for _, a := range args {
-		if es, ok := a.(EtherSlice); ok {
-			buf = padint(dynoff+len(dyn), buf)
-			dyn = padint(es.Len(), dyn)
-			dyn = a.EncodeABI(dyn)
-			continue
-		}
-		buf = a.EncodeABI(buf)
+	vals := make([]interface{}, len(args))
+	for i := range args {
+		vals[i] = args[i]
 	}
-	return append(buf, dyn...)
+	buf, err := encodeArgs(buf, t, vals)
+	if err != nil {
+		panic(fn + ": " + err.Error())
+	}
+	return buf
 }
 
 // EncodeCall sets up c.Data so that it reflects
@@ -245,24 +226,51 @@ func (c *CallOpts) EncodeCall(fn string, args ...EtherType) {
 	c.Data = Data(ABIEncode(fn, args...))
 }
 
+// Pack sets up c.Data as a call to method in the
+// contract ABI a with the given arguments.
+// See ABI.Pack for how method and args are interpreted.
+func (c *CallOpts) Pack(a *ABI, method string, args ...interface{}) error {
+	buf, err := a.Pack(method, args...)
+	if err != nil {
+		return err
+	}
+	c.Data = Data(buf)
+	return nil
+}
+
 // Call makes a transaction call using the given CallOpts.
 func (c *Client) Call(opts *CallOpts) (tx Hash, err error) {
+	return c.CallContext(context.Background(), opts)
+}
+
+// CallContext is like Call, but it takes a context.
+func (c *Client) CallContext(ctx context.Context, opts *CallOpts) (tx Hash, err error) {
 	buf, _ := json.Marshal(opts)
-	err = c.Do("eth_sendTransaction", []json.RawMessage{buf}, &tx)
+	err = c.DoContext(ctx, "eth_sendTransaction", []json.RawMessage{buf}, &tx)
 	return
 }
 
 // RawCall makes a transaction call using the given CallOpts.
 func (c *Client) RawCall(raw []byte) (tx Hash, err error) {
+	return c.RawCallContext(context.Background(), raw)
+}
+
+// RawCallContext is like RawCall, but it takes a context.
+func (c *Client) RawCallContext(ctx context.Context, raw []byte) (tx Hash, err error) {
 	buf, _ := json.Marshal(Data(raw))
-	err = c.Do("eth_sendRawTransaction", []json.RawMessage{buf}, &tx)
+	err = c.DoContext(ctx, "eth_sendRawTransaction", []json.RawMessage{buf}, &tx)
 	return
 }
 
 // EstimateGas estimates the gas cost of mining this call into the blockchain.
 func (c *Client) EstimateGas(opts *CallOpts) (gas Int, err error) {
+	return c.EstimateGasContext(context.Background(), opts)
+}
+
+// EstimateGasContext is like EstimateGas, but it takes a context.
+func (c *Client) EstimateGasContext(ctx context.Context, opts *CallOpts) (gas Int, err error) {
 	buf, _ := json.Marshal(opts)
-	err = c.Do("eth_estimateGas", []json.RawMessage{buf, rawpending}, &gas)
+	err = c.DoContext(ctx, "eth_estimateGas", []json.RawMessage{buf, rawpending}, &gas)
 	return
 }
 
@@ -280,9 +288,14 @@ func (c *Client) ConstCall(opts *CallOpts, out interface{}, pending bool) error
 
 // ConstCallAt executes a call in the given block.
 func (c *Client) ConstCallAt(opts *CallOpts, out interface{}, block int64) error {
+	return c.ConstCallAtContext(context.Background(), opts, out, block)
+}
+
+// ConstCallAtContext is like ConstCallAt, but it takes a context.
+func (c *Client) ConstCallAtContext(ctx context.Context, opts *CallOpts, out interface{}, block int64) error {
 	buf, _ := json.Marshal(opts)
 	args := []json.RawMessage{buf, itobs(block)}
-	return c.Do("eth_call", args, out)
+	return c.DoContext(ctx, "eth_call", args, out)
 }
 
 // StorageAt reads contract storage from a contract at a particular 256-bit address.
@@ -327,173 +340,57 @@ func (d *ABIDecoder) UnmarshalText(v []byte) error {
 }
 
 // DecodeABI decodes a solidity return value into its
-// constituent arguments.
+// constituent arguments. The ABI type of each argument
+// is inferred from its Go type. (Use DecodeParams to
+// decode values whose types are known explicitly.)
 //
-// NOTE: Not all values are supported. Currently,
-// supported types are:
+// Supported types are:
 //
 //  - integers -> all Go integer types, plus big.Int and seth.Int
 //  - bool -> bool
 //  - string -> string
 //  - address -> seth.Address
+//  - bytes32 -> seth.Data or seth.Hash
 //  - uint256[] -> seth.IntSlice
 //  - address[] -> seth.AddrSlice
 //  - bytes32[] -> seth.DataSlice
 //  - bytes -> []byte or seth.Bytes
 //
 func DecodeABI(v []byte, args ...interface{}) error {
-	var spare big.Int
-	cur := v
-	offset := int64(0)
-	for i, v := range args {
-		if len(cur[offset:]) == 0 {
-			return fmt.Errorf("no argument returned at position %d", i)
-		}
-		buf := cur[offset:]
-		if len(buf) > 32 {
-			buf = buf[:32]
-		}
-
-		// easy cases that don't involve converting types
-		// or handling variable-length types
-		did := true
-		switch v := v.(type) {
-		case *Data:
-			if len(*v) < 32 {
-				*v = make([]byte, 32)
-			}
-			copy(*v, cur)
-		case *Address:
-			copy(v[:], cur[12:])
-		case *Int:
-			(*big.Int)(v).SetBytes(buf)
-		case *big.Int:
-			v.SetBytes(buf)
-		default:
-			did = false
-		}
-		if did {
-			offset += 32
-			continue
+	t := &abiType{kind: abiTuple, elems: make([]*abiType, len(args)), names: make([]string, len(args))}
+	for i := range args {
+		t.elems[i] = inferType(args[i])
+		if t.elems[i] == nil {
+			return fmt.Errorf("unrecognized type %T", args[i])
 		}
+	}
+	return decodeArgs(v, t, args)
+}
 
-		spare.SetBytes(buf)
-		switch v := v.(type) {
-		case *string:
-			doff := spare.Int64()
-			if doff < offset+32 || doff >= int64(len(cur)-32) {
-				return fmt.Errorf("bad string offset %d for data length returned (%d)", doff, len(cur))
-			}
-			spare.SetBytes(cur[doff : doff+32])
-			length := spare.Int64()
-			dpos := doff + 32
-			if dpos+length >= int64(len(cur)) {
-				return fmt.Errorf("bad string length %d for data length returned (%d)", length, len(cur))
-			}
-			*v = string(cur[dpos : dpos+length])
-		case *[]byte:
-			doff := spare.Int64()
-			if doff < offset+32 || doff >= int64(len(cur)-32) {
-				return fmt.Errorf("bad bytes offset %d for data length returned (%d)", doff, len(cur))
-			}
-			spare.SetBytes(cur[doff : doff+32])
-			length := spare.Int64()
-			dpos := doff + 32
-			if dpos+length > int64(len(cur)) {
-				return fmt.Errorf("bad bytes length %d for data length returned (%d)", length, len(cur))
-			}
-			*v = make([]byte, length)
-			copy(*v, cur[dpos:dpos+length])
-		case *Bytes:
-			doff := spare.Int64()
-			if doff < offset+32 || doff >= int64(len(cur)-32) {
-				return fmt.Errorf("bad bytes offset %d for data length returned (%d)", doff, len(cur))
-			}
-			spare.SetBytes(cur[doff : doff+32])
-			length := spare.Int64()
-			dpos := doff + 32
-			if dpos+length > int64(len(cur)) {
-				return fmt.Errorf("bad bytes length %d for data length returned (%d)", length, len(cur))
-			}
-			*v = make([]byte, length)
-			copy(*v, cur[dpos:dpos+length])
-		case *IntSlice:
-			doff := spare.Int64()
-			if doff < offset+32 || doff >= int64(len(cur)-32) {
-				return fmt.Errorf("bad slice offset %d for data length returned (%d)", doff, len(cur))
-			}
-			spare.SetBytes(cur[doff : doff+32])
-			length := spare.Int64()
-			dpos := doff + 32
-			if dpos+(length*32) > int64(len(cur)) {
-				return fmt.Errorf("bad slice offset %d for data length returned (%d)", doff, len(cur))
-			}
-			s := make([]Int, length)
-			for i := range s {
-				o := int(dpos) + i*32
-				(*big.Int)(&s[i]).SetBytes(cur[o : o+32])
-			}
-			*v = IntSlice(s)
-		case *AddrSlice:
-			doff := spare.Int64()
-			if doff < offset+32 || doff >= int64(len(cur)-32) {
-				return fmt.Errorf("bad slice offset %d for data length returned (%d)", doff, len(cur))
-			}
-			spare.SetBytes(cur[doff : doff+32])
-			length := spare.Int64()
-			dpos := doff + 32
-			if dpos+(length*32) > int64(len(cur)) {
-				return fmt.Errorf("bad slice offset %d for data length returned (%d)", doff, len(cur))
-			}
-			s := make([]Address, length)
-			for i := range s {
-				o := int(dpos) + i*32
-				copy(s[i][:], cur[o+12:o+32])
-			}
-			*v = AddrSlice(s)
-		case *DataSlice:
-			doff := spare.Int64()
-			if doff < offset+32 || doff >= int64(len(cur)-32) {
-				return fmt.Errorf("bad slice offset %d for data length returned (%d)", doff, len(cur))
-			}
-			spare.SetBytes(cur[doff : doff+32])
-			length := spare.Int64()
-			dpos := doff + 32
-			if dpos+(length*32) > int64(len(cur)) {
-				return fmt.Errorf("bad slice offset %d for data length returned (%d)", doff, len(cur))
-			}
-			s := make([]Data, length)
-			for i := range s {
-				o := int(dpos) + i*32
-				copy(s[i][:], cur[o+12:o+32])
-			}
-			*v = DataSlice(s)
-		case *bool:
-			*v = spare.Sign() != 0
-		case *uint8:
-			*v = uint8(spare.Uint64())
-		case *uint16:
-			*v = uint16(spare.Uint64())
-		case *uint32:
-			*v = uint32(spare.Uint64())
-		case *uint64:
-			*v = uint64(spare.Uint64())
-		case *int8:
-			*v = int8(spare.Int64())
-		case *int16:
-			*v = int16(spare.Int64())
-		case *int32:
-			*v = int32(spare.Int64())
-		case *int64:
-			*v = int64(spare.Int64())
-		case *int:
-			*v = int(spare.Int64())
-		case *uint:
-			*v = uint(spare.Uint64())
-		default:
-			return fmt.Errorf("unrecognized type %T", v)
-		}
-		offset += 32
+// inferType returns the ABI type implied by a
+// pointer argument to DecodeABI, or nil
+func inferType(arg interface{}) *abiType {
+	switch arg.(type) {
+	case *Data, *Hash:
+		return &abiType{kind: abiFixedBytes, size: 32}
+	case *Address:
+		return &abiType{kind: abiAddress}
+	case *Int, *big.Int, *uint8, *uint16, *uint32, *uint64, *uint:
+		return &abiType{kind: abiUint, size: 256}
+	case *int8, *int16, *int32, *int64, *int:
+		return &abiType{kind: abiInt, size: 256}
+	case *bool:
+		return &abiType{kind: abiBool}
+	case *string:
+		return &abiType{kind: abiString}
+	case *[]byte, *Bytes:
+		return &abiType{kind: abiBytes}
+	case *IntSlice:
+		return &abiType{kind: abiSlice, elem: &abiType{kind: abiUint, size: 256}}
+	case *AddrSlice:
+		return &abiType{kind: abiSlice, elem: &abiType{kind: abiAddress}}
+	case *DataSlice:
+		return &abiType{kind: abiSlice, elem: &abiType{kind: abiFixedBytes, size: 32}}
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package seth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
@@ -31,18 +32,73 @@ type Sender struct {
 	}
 
 	// GasPrice is the gas price offered for each transaction.
+	// For EIP-1559 transactions, this is the maximum fee per gas.
 	GasPrice Int
+
+	// PriorityFee is the maximum priority fee per gas
+	// (the miner tip) offered for EIP-1559 transactions.
+	PriorityFee Int
+
+	// Legacy, if set, causes the sender to send legacy
+	// (pre-EIP-1559) transactions by default.
+	Legacy bool
+
+	// Nonces, if set, assigns the nonces of raw transactions
+	// that don't specify one, instead of the node being asked
+	// for the pending nonce of every transaction. Transactions
+	// that are rejected because their nonce was already used
+	// are retried with a new nonce, and transactions that the
+	// node already has are taken as sent.
+	Nonces *NonceManager
+
+	// Fees, if set, chooses the fees of transactions
+	// that don't specify any, instead of GasPrice,
+	// PriorityFee and Legacy.
+	Fees FeeStrategy
+
+	// Logf, if set, is used to log the fees
+	// offered by each transaction that is sent.
+	Logf func(format string, args ...interface{})
+
+	// Simulate, if set, simulates each transaction right
+	// before it is broadcast. Transactions that fail in
+	// simulation are not broadcast, and a *SimulationError
+	// is returned instead. Raw transactions are simulated
+	// exactly as they were signed.
+	Simulate Simulator
 }
 
+// nonceRetries is the number of times that a
+// transaction is retried with a new nonce
+const nonceRetries = 3
+
 // NewSender constructs a Sender with sane defaults.
 func NewSender(c *Client, from *Address) *Sender {
 	s := &Sender{Client: c, Addr: from}
 	s.GasRatio.Num = 6
 	s.GasRatio.Denom = 5
-	(*big.Int)(&s.GasPrice).SetString("50000000000", 10) // 50 Gwei
+	(*big.Int)(&s.GasPrice).SetString("50000000000", 10)   // 50 Gwei
+	(*big.Int)(&s.PriorityFee).SetString("1000000000", 10) // 1 Gwei
 	return s
 }
 
+// setFees sets the default gas price or EIP-1559
+// fees in opts if no fees have been specified
+func (s *Sender) setFees(opts *CallOpts) {
+	if opts.GasPrice != nil || opts.MaxFeePerGas != nil || opts.MaxPriorityFeePerGas != nil {
+		return
+	}
+	if s.Legacy {
+		opts.GasPrice = &s.GasPrice
+		return
+	}
+	opts.MaxFeePerGas = &s.GasPrice
+	opts.MaxPriorityFeePerGas = &s.PriorityFee
+	if s.PriorityFee.Cmp(&s.GasPrice) > 0 {
+		opts.MaxPriorityFeePerGas = &s.GasPrice
+	}
+}
+
 func (s *Sender) pad(gas *Int) *Int {
 	if gas == nil {
 		return nil
@@ -59,31 +115,69 @@ func (s *Sender) pad(gas *Int) *Int {
 }
 
 func (s *Sender) ConstCall(to *Address, method string, out interface{}, args ...EtherType) error {
+	return s.ConstCallContext(context.Background(), to, method, out, args...)
+}
+
+// ConstCallContext is like ConstCall, but it takes a context.
+func (s *Sender) ConstCallContext(ctx context.Context, to *Address, method string, out interface{}, args ...EtherType) error {
 	opts := CallOpts{To: to, From: s.Addr, GasPrice: &s.GasPrice}
 	opts.EncodeCall(method, args...)
-	return s.Client.ConstCall(&opts, out, true)
+	return s.Client.ConstCallAtContext(ctx, &opts, out, Pending)
+}
+
+// ConstCallABI is like ConstCall, but the call is encoded
+// using the contract ABI a, and the return values are decoded
+// into out as described in DecodeParams.
+func (s *Sender) ConstCallABI(to *Address, a *ABI, method string, args []interface{}, out ...interface{}) error {
+	return s.ConstCallABIContext(context.Background(), to, a, method, args, out...)
+}
+
+// ConstCallABIContext is like ConstCallABI, but it takes a context.
+func (s *Sender) ConstCallABIContext(ctx context.Context, to *Address, a *ABI, method string, args []interface{}, out ...interface{}) error {
+	d, err := a.Resolve(method, args...)
+	if err != nil {
+		return err
+	}
+	buf, err := d.Pack(args...)
+	if err != nil {
+		return err
+	}
+	opts := CallOpts{To: to, From: s.Addr, GasPrice: &s.GasPrice, Data: Data(buf)}
+	var ret Data
+	if err := s.Client.ConstCallAtContext(ctx, &opts, &ret, Pending); err != nil {
+		return err
+	}
+	return DecodeParams(d.Outputs, ret, out...)
 }
 
 // Create creates a new contract with the given contract code.
 // This call blocks until the transaction posts, and then returns
 // the contract's address.
 func (s *Sender) Create(code []byte, value *Int) (Address, error) {
-	opts := CallOpts{From: s.Addr, GasPrice: &s.GasPrice, Value: value}
+	return s.CreateContext(context.Background(), code, value)
+}
+
+// CreateContext is like Create, but it takes a context.
+func (s *Sender) CreateContext(ctx context.Context, code []byte, value *Int) (Address, error) {
+	opts := CallOpts{From: s.Addr, Value: value}
+	if _, err := s.chooseFees(ctx, &opts); err != nil {
+		return Address{}, err
+	}
 	opts.Data = Data(code)
-	gas, err := s.EstimateGas(&opts)
+	gas, err := s.EstimateGasContext(ctx, &opts)
 	if err != nil {
 		return Address{}, err
 	}
 	opts.Gas = s.pad(&gas)
-	h, err := s.Call(&opts)
+	h, err := s.CallContext(ctx, &opts)
 	if err != nil {
 		return Address{}, err
 	}
-	err = s.Wait(&h)
+	err = s.WaitContext(ctx, &h)
 	if err != nil {
 		return Address{}, err
 	}
-	r, err := s.GetReceipt(&h)
+	r, err := s.GetReceiptContext(ctx, &h)
 	if err != nil {
 		return Address{}, err
 	}
@@ -94,18 +188,36 @@ func (s *Sender) Create(code []byte, value *Int) (Address, error) {
 }
 
 // Call makes a transaction call using the given CallOpts. Omitted fields are
-// populated with default values.
+// populated with default values. Unless the sender is configured to send
+// legacy transactions, or opts specifies a gas price, the transaction is
+// sent as an EIP-1559 transaction. If the sender has a FeeStrategy, it
+// chooses the fees of transactions that don't specify any. Raw transactions
+// are signed for the chain ID of the client (see Client.ChainID).
 func (s *Sender) Call(opts *CallOpts) (Hash, error) {
+	return s.CallContext(context.Background(), opts)
+}
+
+// CallContext is like Call, but it takes a context.
+func (s *Sender) CallContext(ctx context.Context, opts *CallOpts) (Hash, error) {
 	if opts.From == nil {
 		opts.From = s.Addr
 	}
 
-	if opts.GasPrice == nil {
-		opts.GasPrice = &s.GasPrice
+	fees, err := s.chooseFees(ctx, opts)
+	if err != nil {
+		return Hash{}, err
 	}
+	h, err := s.call(ctx, opts)
+	if err == nil && s.Logf != nil {
+		s.Logf("%s: %s", h.String(), fees.String())
+	}
+	return h, err
+}
 
+// call sends a transaction once its fees have been chosen
+func (s *Sender) call(ctx context.Context, opts *CallOpts) (Hash, error) {
 	if opts.Gas == nil {
-		gas, err := s.EstimateGas(opts)
+		gas, err := s.EstimateGasContext(ctx, opts)
 		if err != nil {
 			return Hash{}, err
 		}
@@ -113,22 +225,64 @@ func (s *Sender) Call(opts *CallOpts) (Hash, error) {
 	}
 
 	if s.Signer == nil {
-		return s.Client.Call(opts)
+		if s.Simulate != nil {
+			if err := s.simulate(ctx, opts.Transaction()); err != nil {
+				return Hash{}, err
+			}
+		}
+		return s.Client.CallContext(ctx, opts)
 	}
 
 	tx := opts.Transaction()
+	id, err := s.ChainIDContext(ctx)
+	if err != nil {
+		return Hash{}, err
+	}
+	tx.SetChainID(id)
 
 	// if no nonce was specified, try to select it
 	if opts.Nonce == nil {
 		if tx.From == nil {
 			return Hash{}, fmt.Errorf("Sender.Call: unspecified nonce, and no from address provided")
 		}
-		n, err := s.GetNonceAt(tx.From, Pending)
+		if s.Nonces != nil {
+			for i := 0; ; i++ {
+				n, err := s.Nonces.Next(ctx, tx.From)
+				if err != nil {
+					return Hash{}, err
+				}
+				tx.Nonce = Uint64(n)
+				h, err := s.sign(ctx, tx, opts.From)
+				s.Nonces.Done(tx.From, n, err)
+				switch {
+				case IsKnownTransaction(err):
+					return h, nil
+				case !IsNonceError(err) || i == nonceRetries:
+					return h, err
+				}
+			}
+		}
+		n, err := s.GetNonceAtContext(ctx, tx.From, Pending)
 		if err != nil {
 			return Hash{}, err
 		}
 		tx.Nonce = Uint64(n)
 	}
+	h, err := s.sign(ctx, tx, opts.From)
+	if IsKnownTransaction(err) {
+		err = nil
+	}
+	return h, err
+}
+
+// sign signs tx and sends it as a raw transaction,
+// checking that it was signed by from (if from is set).
+// If the node already has the transaction, its hash is
+// returned along with the node's error.
+func (s *Sender) sign(ctx context.Context, tx *Transaction, from *Address) (Hash, error) {
+	if err := tx.check(); err != nil {
+		return Hash{}, err
+	}
 	hash := tx.HashToSign()
 
 	sig, err := s.Signer(hash)
@@ -138,66 +292,128 @@ func (s *Sender) Call(opts *CallOpts) (Hash, error) {
 
 	// If a from address was provided, verify that the signer produced a
 	// signature for the correct address.
-	if opts.From != nil {
+	if from != nil {
 		pub, err := sig.Recover(hash)
 		if err != nil {
 			return Hash{}, err
 		}
-		from := pub.Address()
-		if *from != *opts.From {
+		if signer := pub.Address(); *signer != *from {
 			return Hash{}, fmt.Errorf(
 				"sender: address mismatch: expected %v, got %v",
-				opts.From, pub.Address())
+				from, signer)
 		}
 	}
 
-	return s.RawCall(tx.Encode(sig))
+	raw, err := tx.encode(sig)
+	if err != nil {
+		return Hash{}, err
+	}
+	if s.Simulate != nil {
+		signed, _, err := ParseRawTransaction(raw)
+		if err != nil {
+			return Hash{}, err
+		}
+		if err := s.simulate(ctx, signed); err != nil {
+			return Hash{}, err
+		}
+	}
+	h, err := s.RawCallContext(ctx, raw)
+	if IsKnownTransaction(err) {
+		h = HashBytes(raw)
+	}
+	return h, err
 }
 
 // Send makes a contract call from the sender address.
 // It automatically handles gas estimation and padding.
 func (s *Sender) Send(to *Address, method string, args ...EtherType) (Hash, error) {
+	return s.SendContext(context.Background(), to, method, args...)
+}
+
+// SendContext is like Send, but it takes a context.
+func (s *Sender) SendContext(ctx context.Context, to *Address, method string, args ...EtherType) (Hash, error) {
 	opts := CallOpts{To: to}
 	opts.EncodeCall(method, args...)
-	return s.Call(&opts)
+	return s.CallContext(ctx, &opts)
+}
+
+// SendABI is like Send, but the call is
+// encoded using the contract ABI a.
+func (s *Sender) SendABI(to *Address, a *ABI, method string, args ...interface{}) (Hash, error) {
+	return s.SendABIContext(context.Background(), to, a, method, args...)
+}
+
+// SendABIContext is like SendABI, but it takes a context.
+func (s *Sender) SendABIContext(ctx context.Context, to *Address, a *ABI, method string, args ...interface{}) (Hash, error) {
+	opts := CallOpts{To: to}
+	if err := opts.Pack(a, method, args...); err != nil {
+		return Hash{}, err
+	}
+	return s.CallContext(ctx, &opts)
 }
 
-// Cancel a transaction with the given hash.
+// Cancel a transaction with the given hash by replacing it
+// with a transaction that sends nothing to the sender itself.
 func (s *Sender) Cancel(h *Hash) (Hash, error) {
-	tx, err := s.GetTransaction(h)
+	return s.CancelContext(context.Background(), h)
+}
+
+// CancelContext is like Cancel, but it takes a context.
+func (s *Sender) CancelContext(ctx context.Context, h *Hash) (Hash, error) {
+	tx, err := s.GetTransactionContext(ctx, h)
 	if err != nil {
 		return Hash{}, err
 	} else if tx.TxIndex != nil {
 		return Hash{}, ErrCannotCancel
 	}
-	opts := CallOpts{To: s.Addr, From: s.Addr, Nonce: &tx.Nonce}
-	if s.GasPrice.Cmp(&tx.GasPrice) > 0 {
+	// the replacement has to offer at least MinBump percent
+	// more than both fees of an EIP-1559 transaction, and a
+	// legacy gas price counts as both fees
+	price := &tx.GasPrice
+	if dynamic(tx) {
+		price = tx.MaxFeePerGas
+	}
+	opts := CallOpts{To: s.Addr, From: s.Addr, Nonce: &tx.Nonce, GasPrice: bump(price, MinBump)}
+	if s.GasPrice.Cmp(opts.GasPrice) > 0 {
 		opts.GasPrice = &s.GasPrice
-	} else {
-		opts.GasPrice = NewInt(tx.GasPrice.Int64() + 1)
 	}
-	return s.Call(&opts)
+	return s.CallContext(ctx, &opts)
 }
 
 // Wait waits for a transaction hash to be mined into the canonical chain.
+// It doesn't check whether the transaction succeeded; see WaitMined.
 func (s *Sender) Wait(h *Hash) error {
+	return s.WaitContext(context.Background(), h)
+}
+
+// WaitContext is like Wait, but it takes a context.
+func (s *Sender) WaitContext(ctx context.Context, h *Hash) error {
 	for {
-		t, err := s.GetTransaction(h)
+		t, err := s.GetTransactionContext(ctx, h)
 		if err != nil {
 			return err
 		}
 		if t.TxIndex != nil {
 			return nil
 		}
-		time.Sleep(2 * time.Second)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.pollInterval()):
+		}
 	}
 }
 
 // Drain waits for the pending transaction pool to
 // contain no transactions from this account.
 func (s *Sender) Drain(prompt ...func(t *Transaction)) error {
+	return s.DrainContext(context.Background(), prompt...)
+}
+
+// DrainContext is like Drain, but it takes a context.
+func (s *Sender) DrainContext(ctx context.Context, prompt ...func(t *Transaction)) error {
 	for {
-		p, err := s.GetBlock(-1, true)
+		p, err := s.GetBlockContext(ctx, -1, true)
 		if err != nil {
 			return err
 		}
@@ -218,7 +434,7 @@ func (s *Sender) Drain(prompt ...func(t *Transaction)) error {
 		for _, p := range prompt {
 			p(t)
 		}
-		if err := s.Wait(&t.Hash); err != nil {
+		if err := s.WaitContext(ctx, &t.Hash); err != nil {
 			return err
 		}
 	}
@@ -2,6 +2,7 @@ package seth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,10 +10,12 @@ import (
 	"log"
 	"math/big"
 	"reflect"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
 	"github.com/newalchemylimited/seth/keccak"
+	"github.com/newalchemylimited/seth/rlp"
 	"github.com/tinylib/msgp/msgp"
 )
 
@@ -43,9 +46,20 @@ type Transport interface {
 	Execute(req *RPCRequest, res *RPCResponse) error
 }
 
+// A ContextTransport is a Transport that can
+// abandon a request when its context is done.
+type ContextTransport interface {
+	Transport
+	// ExecuteContext is like Execute, but it returns
+	// ctx.Err() if ctx is done before the response arrives.
+	ExecuteContext(ctx context.Context, req *RPCRequest, res *RPCResponse) error
+}
+
 type Client struct {
-	tport  Transport
-	nextid uintptr
+	tport   Transport
+	nextid  uintptr
+	chainid uint64 // accessed atomically; 0 if unknown
+	pollint int64  // accessed atomically; filter poll interval
 }
 
 func NewClient(dial func() (io.ReadWriteCloser, error)) *Client {
@@ -121,6 +135,12 @@ func (i *Int) Big() *big.Int {
 	return (*big.Int)(i)
 }
 
+// EncodeRLP implements rlp.Encoder. A nil
+// Int is encoded as zero.
+func (i *Int) EncodeRLP(dst []byte) ([]byte, error) {
+	return rlp.AppendBig(dst, i.Big())
+}
+
 // Scan implements fmt.Scanner
 func (i *Int) Scan(s fmt.ScanState, verb rune) error {
 	// don't let a leading '0x' cause %x to silently
@@ -501,6 +521,35 @@ func (c *Client) GasPrice() (int64, error) {
 	return int64(wei), nil
 }
 
+// ChainID gets the chain ID used to sign transactions. Unless it
+// has been set with SetChainID, the chain ID is requested from the
+// node with eth_chainId the first time it is needed.
+func (c *Client) ChainID() (uint64, error) {
+	return c.ChainIDContext(context.Background())
+}
+
+// ChainIDContext is like ChainID, but it takes a context.
+func (c *Client) ChainIDContext(ctx context.Context) (uint64, error) {
+	if id := atomic.LoadUint64(&c.chainid); id != 0 {
+		return id, nil
+	}
+	var id Uint64
+	if err := c.DoContext(ctx, "eth_chainId", nil, &id); err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, errors.New("seth: node reported chain ID 0")
+	}
+	atomic.StoreUint64(&c.chainid, uint64(id))
+	return uint64(id), nil
+}
+
+// SetChainID sets the chain ID used to sign transactions,
+// which prevents it from being requested from the node.
+func (c *Client) SetChainID(id uint64) {
+	atomic.StoreUint64(&c.chainid, id)
+}
+
 // Accounts gets the accounts owned by the client.
 func (c *Client) Accounts() ([]Address, error) {
 	var out []Address
@@ -512,8 +561,13 @@ func (c *Client) Accounts() ([]Address, error) {
 
 // BlockNumber gets the number of the most recent block.
 func (c *Client) BlockNumber() (int64, error) {
+	return c.BlockNumberContext(context.Background())
+}
+
+// BlockNumberContext is like BlockNumber, but it takes a context.
+func (c *Client) BlockNumberContext(ctx context.Context) (int64, error) {
 	var block Uint64
-	if err := c.Do("eth_blockNumber", nil, &block); err != nil {
+	if err := c.DoContext(ctx, "eth_blockNumber", nil, &block); err != nil {
 		return 0, err
 	}
 	return int64(block), nil
@@ -558,12 +612,17 @@ func itobs(i int64) json.RawMessage {
 // GetNonceAt gets the account nonce for a specific address
 // and at a specific block number.
 func (c *Client) GetNonceAt(addr *Address, blocknum int64) (int64, error) {
+	return c.GetNonceAtContext(context.Background(), addr, blocknum)
+}
+
+// GetNonceAtContext is like GetNonceAt, but it takes a context.
+func (c *Client) GetNonceAtContext(ctx context.Context, addr *Address, blocknum int64) (int64, error) {
 	var params [2]json.RawMessage
 	buf, _ := json.Marshal(addr)
 	params[0] = buf
 	params[1] = itobs(blocknum)
 	var num Int
-	err := c.Do("eth_getTransactionCount", params[:], &num)
+	err := c.DoContext(ctx, "eth_getTransactionCount", params[:], &num)
 	return num.Int64(), err
 }
 
@@ -575,12 +634,17 @@ func (c *Client) GetNonce(addr *Address) (int64, error) {
 // GetBalanceAt gets the balance for a specific address
 // and at a specific block number.
 func (c *Client) GetBalanceAt(addr *Address, blocknum int64) (Int, error) {
+	return c.GetBalanceAtContext(context.Background(), addr, blocknum)
+}
+
+// GetBalanceAtContext is like GetBalanceAt, but it takes a context.
+func (c *Client) GetBalanceAtContext(ctx context.Context, addr *Address, blocknum int64) (Int, error) {
 	var params [2]json.RawMessage
 	buf, _ := json.Marshal(addr)
 	params[0] = buf
 	params[1] = itobs(blocknum)
 	wei := Int{}
-	err := c.Do("eth_getBalance", params[:], &wei)
+	err := c.DoContext(ctx, "eth_getBalance", params[:], &wei)
 	return wei, err
 }
 
@@ -593,6 +657,11 @@ func (c *Client) GetBalance(addr *Address) (Int, error) {
 // the block includes all the transactions in the block; otherwise
 // it only includes the transaction hashes.
 func (c *Client) GetBlock(num int64, txs bool) (*Block, error) {
+	return c.GetBlockContext(context.Background(), num, txs)
+}
+
+// GetBlockContext is like GetBlock, but it takes a context.
+func (c *Client) GetBlockContext(ctx context.Context, num int64, txs bool) (*Block, error) {
 	params := make([]json.RawMessage, 2)
 	params[0] = itobs(num)
 	if txs {
@@ -601,7 +670,7 @@ func (c *Client) GetBlock(num int64, txs bool) (*Block, error) {
 		params[1] = rawfalse
 	}
 	out := Block{}
-	err := c.Do("eth_getBlockByNumber", params, &out)
+	err := c.DoContext(ctx, "eth_getBlockByNumber", params, &out)
 	if err != nil {
 		return nil, err
 	}
@@ -610,9 +679,14 @@ func (c *Client) GetBlock(num int64, txs bool) (*Block, error) {
 
 // GetTransaction gets a transaction by its hash
 func (c *Client) GetTransaction(h *Hash) (*Transaction, error) {
+	return c.GetTransactionContext(context.Background(), h)
+}
+
+// GetTransactionContext is like GetTransaction, but it takes a context.
+func (c *Client) GetTransactionContext(ctx context.Context, h *Hash) (*Transaction, error) {
 	buf, _ := json.Marshal(h)
 	o := new(Transaction)
-	err := c.Do("eth_getTransactionByHash", []json.RawMessage{buf}, o)
+	err := c.DoContext(ctx, "eth_getTransactionByHash", []json.RawMessage{buf}, o)
 	if err != nil {
 		return nil, err
 	}
@@ -710,9 +784,14 @@ func (c *Client) GetCode(addr *Address) ([]byte, error) {
 
 // GetReceipt gets a receipt for a given transaction hash.
 func (c *Client) GetReceipt(tx *Hash) (*Receipt, error) {
+	return c.GetReceiptContext(context.Background(), tx)
+}
+
+// GetReceiptContext is like GetReceipt, but it takes a context.
+func (c *Client) GetReceiptContext(ctx context.Context, tx *Hash) (*Receipt, error) {
 	buf, _ := json.Marshal(tx)
 	out := &Receipt{}
-	err := c.Do("eth_getTransactionReceipt", []json.RawMessage{buf}, out)
+	err := c.DoContext(ctx, "eth_getTransactionReceipt", []json.RawMessage{buf}, out)
 	if err != nil {
 		return nil, err
 	}
@@ -10,6 +10,340 @@ import (
 	"github.com/tinylib/msgp/msgp"
 )
 
+// DecodeMsg implements msgp.Decodable
+func (z *AccessList) DecodeMsg(dc *msgp.Reader) (err error) {
+	var zb0005 uint32
+	zb0005, err = dc.ReadArrayHeader()
+	if err != nil {
+		return
+	}
+	if cap((*z)) >= int(zb0005) {
+		(*z) = (*z)[:zb0005]
+	} else {
+		(*z) = make(AccessList, zb0005)
+	}
+	for zb0001 := range *z {
+		var field []byte
+		_ = field
+		var zb0006 uint32
+		zb0006, err = dc.ReadMapHeader()
+		if err != nil {
+			return
+		}
+		for zb0006 > 0 {
+			zb0006--
+			field, err = dc.ReadMapKeyPtr()
+			if err != nil {
+				return
+			}
+			switch msgp.UnsafeString(field) {
+			case "Address":
+				err = dc.ReadExactBytes(((*z)[zb0001].Address)[:])
+				if err != nil {
+					return
+				}
+			case "StorageKeys":
+				var zb0007 uint32
+				zb0007, err = dc.ReadArrayHeader()
+				if err != nil {
+					return
+				}
+				if cap((*z)[zb0001].StorageKeys) >= int(zb0007) {
+					(*z)[zb0001].StorageKeys = ((*z)[zb0001].StorageKeys)[:zb0007]
+				} else {
+					(*z)[zb0001].StorageKeys = make([]Hash, zb0007)
+				}
+				for zb0003 := range (*z)[zb0001].StorageKeys {
+					err = dc.ReadExactBytes(((*z)[zb0001].StorageKeys[zb0003])[:])
+					if err != nil {
+						return
+					}
+				}
+			default:
+				err = dc.Skip()
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z AccessList) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteArrayHeader(uint32(len(z)))
+	if err != nil {
+		return
+	}
+	for zb0008 := range z {
+		// map header, size 2
+		// write "Address"
+		err = en.Append(0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes((z[zb0008].Address)[:])
+		if err != nil {
+			return
+		}
+		// write "StorageKeys"
+		err = en.Append(0xab, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z[zb0008].StorageKeys)))
+		if err != nil {
+			return
+		}
+		for zb0010 := range z[zb0008].StorageKeys {
+			err = en.WriteBytes((z[zb0008].StorageKeys[zb0010])[:])
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z AccessList) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendArrayHeader(o, uint32(len(z)))
+	for zb0008 := range z {
+		// map header, size 2
+		// string "Address"
+		o = append(o, 0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+		o = msgp.AppendBytes(o, (z[zb0008].Address)[:])
+		// string "StorageKeys"
+		o = append(o, 0xab, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z[zb0008].StorageKeys)))
+		for zb0010 := range z[zb0008].StorageKeys {
+			o = msgp.AppendBytes(o, (z[zb0008].StorageKeys[zb0010])[:])
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *AccessList) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var zb0005 uint32
+	zb0005, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	if cap((*z)) >= int(zb0005) {
+		(*z) = (*z)[:zb0005]
+	} else {
+		(*z) = make(AccessList, zb0005)
+	}
+	for zb0001 := range *z {
+		var field []byte
+		_ = field
+		var zb0006 uint32
+		zb0006, bts, err = msgp.ReadMapHeaderBytes(bts)
+		if err != nil {
+			return
+		}
+		for zb0006 > 0 {
+			zb0006--
+			field, bts, err = msgp.ReadMapKeyZC(bts)
+			if err != nil {
+				return
+			}
+			switch msgp.UnsafeString(field) {
+			case "Address":
+				bts, err = msgp.ReadExactBytes(bts, ((*z)[zb0001].Address)[:])
+				if err != nil {
+					return
+				}
+			case "StorageKeys":
+				var zb0007 uint32
+				zb0007, bts, err = msgp.ReadArrayHeaderBytes(bts)
+				if err != nil {
+					return
+				}
+				if cap((*z)[zb0001].StorageKeys) >= int(zb0007) {
+					(*z)[zb0001].StorageKeys = ((*z)[zb0001].StorageKeys)[:zb0007]
+				} else {
+					(*z)[zb0001].StorageKeys = make([]Hash, zb0007)
+				}
+				for zb0003 := range (*z)[zb0001].StorageKeys {
+					bts, err = msgp.ReadExactBytes(bts, ((*z)[zb0001].StorageKeys[zb0003])[:])
+					if err != nil {
+						return
+					}
+				}
+			default:
+				bts, err = msgp.Skip(bts)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z AccessList) Msgsize() (s int) {
+	s = msgp.ArrayHeaderSize
+	for zb0008 := range z {
+		s += 1 + 8 + msgp.ArrayHeaderSize + (20 * (msgp.ByteSize)) + 12 + msgp.ArrayHeaderSize + (len(z[zb0008].StorageKeys) * (32 * (msgp.ByteSize)))
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *AccessTuple) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Address":
+			err = dc.ReadExactBytes((z.Address)[:])
+			if err != nil {
+				return
+			}
+		case "StorageKeys":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.StorageKeys) >= int(zb0002) {
+				z.StorageKeys = (z.StorageKeys)[:zb0002]
+			} else {
+				z.StorageKeys = make([]Hash, zb0002)
+			}
+			for za0002 := range z.StorageKeys {
+				err = dc.ReadExactBytes((z.StorageKeys[za0002])[:])
+				if err != nil {
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *AccessTuple) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Address"
+	err = en.Append(0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes((z.Address)[:])
+	if err != nil {
+		return
+	}
+	// write "StorageKeys"
+	err = en.Append(0xab, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.StorageKeys)))
+	if err != nil {
+		return
+	}
+	for za0002 := range z.StorageKeys {
+		err = en.WriteBytes((z.StorageKeys[za0002])[:])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *AccessTuple) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Address"
+	o = append(o, 0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+	o = msgp.AppendBytes(o, (z.Address)[:])
+	// string "StorageKeys"
+	o = append(o, 0xab, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.StorageKeys)))
+	for za0002 := range z.StorageKeys {
+		o = msgp.AppendBytes(o, (z.StorageKeys[za0002])[:])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *AccessTuple) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Address":
+			bts, err = msgp.ReadExactBytes(bts, (z.Address)[:])
+			if err != nil {
+				return
+			}
+		case "StorageKeys":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.StorageKeys) >= int(zb0002) {
+				z.StorageKeys = (z.StorageKeys)[:zb0002]
+			} else {
+				z.StorageKeys = make([]Hash, zb0002)
+			}
+			for za0002 := range z.StorageKeys {
+				bts, err = msgp.ReadExactBytes(bts, (z.StorageKeys[za0002])[:])
+				if err != nil {
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *AccessTuple) Msgsize() (s int) {
+	s = 1 + 8 + msgp.ArrayHeaderSize + (20 * (msgp.ByteSize)) + 12 + msgp.ArrayHeaderSize + (len(z.StorageKeys) * (32 * (msgp.ByteSize)))
+	return
+}
+
 // DecodeMsg implements msgp.Decodable
 func (z *Address) DecodeMsg(dc *msgp.Reader) (err error) {
 	err = dc.ReadExactBytes((z)[:])
@@ -2465,6 +2799,121 @@ func (z *Transaction) DecodeMsg(dc *msgp.Reader) (err error) {
 				}
 				z.Input = Data(zb0006)
 			}
+		case "Type":
+			{
+				var zb0007 uint64
+				zb0007, err = dc.ReadUint64()
+				if err != nil {
+					return
+				}
+				z.Type = Uint64(zb0007)
+			}
+		case "ChainID":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					return
+				}
+				z.ChainID = nil
+			} else {
+				if z.ChainID == nil {
+					z.ChainID = new(Uint64)
+				}
+				{
+					var zb0008 uint64
+					zb0008, err = dc.ReadUint64()
+					if err != nil {
+						return
+					}
+					*z.ChainID = Uint64(zb0008)
+				}
+			}
+		case "MaxFeePerGas":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					return
+				}
+				z.MaxFeePerGas = nil
+			} else {
+				if z.MaxFeePerGas == nil {
+					z.MaxFeePerGas = new(Int)
+				}
+				err = z.MaxFeePerGas.DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		case "MaxPriorityFeePerGas":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					return
+				}
+				z.MaxPriorityFeePerGas = nil
+			} else {
+				if z.MaxPriorityFeePerGas == nil {
+					z.MaxPriorityFeePerGas = new(Int)
+				}
+				err = z.MaxPriorityFeePerGas.DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		case "AccessList":
+			var zb0009 uint32
+			zb0009, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.AccessList) >= int(zb0009) {
+				z.AccessList = (z.AccessList)[:zb0009]
+			} else {
+				z.AccessList = make(AccessList, zb0009)
+			}
+			for za0005 := range z.AccessList {
+				var zb0010 uint32
+				zb0010, err = dc.ReadMapHeader()
+				if err != nil {
+					return
+				}
+				for zb0010 > 0 {
+					zb0010--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Address":
+						err = dc.ReadExactBytes((z.AccessList[za0005].Address)[:])
+						if err != nil {
+							return
+						}
+					case "StorageKeys":
+						var zb0011 uint32
+						zb0011, err = dc.ReadArrayHeader()
+						if err != nil {
+							return
+						}
+						if cap(z.AccessList[za0005].StorageKeys) >= int(zb0011) {
+							z.AccessList[za0005].StorageKeys = (z.AccessList[za0005].StorageKeys)[:zb0011]
+						} else {
+							z.AccessList[za0005].StorageKeys = make([]Hash, zb0011)
+						}
+						for za0007 := range z.AccessList[za0005].StorageKeys {
+							err = dc.ReadExactBytes((z.AccessList[za0005].StorageKeys[za0007])[:])
+							if err != nil {
+								return
+							}
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -2477,11 +2926,11 @@ func (z *Transaction) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 11
+	// map header, size 16
 	// write "Hash"
-	err = en.Append(0x8b, 0xa4, 0x48, 0x61, 0x73, 0x68)
+	err = en.Append(0xde, 0x0, 0x10, 0xa4, 0x48, 0x61, 0x73, 0x68)
 	if err != nil {
-		return err
+		return
 	}
 	err = en.WriteBytes((z.Hash)[:])
 	if err != nil {
@@ -2490,7 +2939,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "Nonce"
 	err = en.Append(0xa5, 0x4e, 0x6f, 0x6e, 0x63, 0x65)
 	if err != nil {
-		return err
+		return
 	}
 	err = en.WriteUint64(uint64(z.Nonce))
 	if err != nil {
@@ -2499,7 +2948,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "Block"
 	err = en.Append(0xa5, 0x42, 0x6c, 0x6f, 0x63, 0x6b)
 	if err != nil {
-		return err
+		return
 	}
 	err = en.WriteBytes((z.Block)[:])
 	if err != nil {
@@ -2508,7 +2957,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "BlockNumber"
 	err = en.Append(0xab, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72)
 	if err != nil {
-		return err
+		return
 	}
 	err = en.WriteUint64(uint64(z.BlockNumber))
 	if err != nil {
@@ -2517,7 +2966,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "To"
 	err = en.Append(0xa2, 0x54, 0x6f)
 	if err != nil {
-		return err
+		return
 	}
 	if z.To == nil {
 		err = en.WriteNil()
@@ -2533,7 +2982,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "TxIndex"
 	err = en.Append(0xa7, 0x54, 0x78, 0x49, 0x6e, 0x64, 0x65, 0x78)
 	if err != nil {
-		return err
+		return
 	}
 	if z.TxIndex == nil {
 		err = en.WriteNil()
@@ -2549,7 +2998,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "From"
 	err = en.Append(0xa4, 0x46, 0x72, 0x6f, 0x6d)
 	if err != nil {
-		return err
+		return
 	}
 	if z.From == nil {
 		err = en.WriteNil()
@@ -2565,7 +3014,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "Value"
 	err = en.Append(0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
 	if err != nil {
-		return err
+		return
 	}
 	err = z.Value.EncodeMsg(en)
 	if err != nil {
@@ -2574,7 +3023,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "GasPrice"
 	err = en.Append(0xa8, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65)
 	if err != nil {
-		return err
+		return
 	}
 	err = z.GasPrice.EncodeMsg(en)
 	if err != nil {
@@ -2583,7 +3032,7 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "Gas"
 	err = en.Append(0xa3, 0x47, 0x61, 0x73)
 	if err != nil {
-		return err
+		return
 	}
 	err = en.WriteUint64(uint64(z.Gas))
 	if err != nil {
@@ -2592,21 +3041,114 @@ func (z *Transaction) EncodeMsg(en *msgp.Writer) (err error) {
 	// write "Input"
 	err = en.Append(0xa5, 0x49, 0x6e, 0x70, 0x75, 0x74)
 	if err != nil {
-		return err
+		return
 	}
 	err = en.WriteBytes([]byte(z.Input))
 	if err != nil {
 		return
 	}
+	// write "Type"
+	err = en.Append(0xa4, 0x54, 0x79, 0x70, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Type))
+	if err != nil {
+		return
+	}
+	// write "ChainID"
+	err = en.Append(0xa7, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x44)
+	if err != nil {
+		return
+	}
+	if z.ChainID == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = en.WriteUint64(uint64(*z.ChainID))
+		if err != nil {
+			return
+		}
+	}
+	// write "MaxFeePerGas"
+	err = en.Append(0xac, 0x4d, 0x61, 0x78, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73)
+	if err != nil {
+		return
+	}
+	if z.MaxFeePerGas == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.MaxFeePerGas.EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	// write "MaxPriorityFeePerGas"
+	err = en.Append(0xb4, 0x4d, 0x61, 0x78, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73)
+	if err != nil {
+		return
+	}
+	if z.MaxPriorityFeePerGas == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.MaxPriorityFeePerGas.EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	// write "AccessList"
+	err = en.Append(0xaa, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.AccessList)))
+	if err != nil {
+		return
+	}
+	for za0005 := range z.AccessList {
+		// map header, size 2
+		// write "Address"
+		err = en.Append(0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes((z.AccessList[za0005].Address)[:])
+		if err != nil {
+			return
+		}
+		// write "StorageKeys"
+		err = en.Append(0xab, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.AccessList[za0005].StorageKeys)))
+		if err != nil {
+			return
+		}
+		for za0007 := range z.AccessList[za0005].StorageKeys {
+			err = en.WriteBytes((z.AccessList[za0005].StorageKeys[za0007])[:])
+			if err != nil {
+				return
+			}
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Transaction) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 11
+	// map header, size 16
 	// string "Hash"
-	o = append(o, 0x8b, 0xa4, 0x48, 0x61, 0x73, 0x68)
+	o = append(o, 0xde, 0x0, 0x10, 0xa4, 0x48, 0x61, 0x73, 0x68)
 	o = msgp.AppendBytes(o, (z.Hash)[:])
 	// string "Nonce"
 	o = append(o, 0xa5, 0x4e, 0x6f, 0x6e, 0x63, 0x65)
@@ -2656,6 +3198,51 @@ func (z *Transaction) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "Input"
 	o = append(o, 0xa5, 0x49, 0x6e, 0x70, 0x75, 0x74)
 	o = msgp.AppendBytes(o, []byte(z.Input))
+	// string "Type"
+	o = append(o, 0xa4, 0x54, 0x79, 0x70, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Type))
+	// string "ChainID"
+	o = append(o, 0xa7, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x44)
+	if z.ChainID == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o = msgp.AppendUint64(o, uint64(*z.ChainID))
+	}
+	// string "MaxFeePerGas"
+	o = append(o, 0xac, 0x4d, 0x61, 0x78, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73)
+	if z.MaxFeePerGas == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.MaxFeePerGas.MarshalMsg(o)
+		if err != nil {
+			return
+		}
+	}
+	// string "MaxPriorityFeePerGas"
+	o = append(o, 0xb4, 0x4d, 0x61, 0x78, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73)
+	if z.MaxPriorityFeePerGas == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.MaxPriorityFeePerGas.MarshalMsg(o)
+		if err != nil {
+			return
+		}
+	}
+	// string "AccessList"
+	o = append(o, 0xaa, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.AccessList)))
+	for za0005 := range z.AccessList {
+		// map header, size 2
+		// string "Address"
+		o = append(o, 0x82, 0xa7, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73)
+		o = msgp.AppendBytes(o, (z.AccessList[za0005].Address)[:])
+		// string "StorageKeys"
+		o = append(o, 0xab, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.AccessList[za0005].StorageKeys)))
+		for za0007 := range z.AccessList[za0005].StorageKeys {
+			o = msgp.AppendBytes(o, (z.AccessList[za0005].StorageKeys[za0007])[:])
+		}
+	}
 	return
 }
 
@@ -2783,6 +3370,121 @@ func (z *Transaction) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				}
 				z.Input = Data(zb0006)
 			}
+		case "Type":
+			{
+				var zb0007 uint64
+				zb0007, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					return
+				}
+				z.Type = Uint64(zb0007)
+			}
+		case "ChainID":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.ChainID = nil
+			} else {
+				if z.ChainID == nil {
+					z.ChainID = new(Uint64)
+				}
+				{
+					var zb0008 uint64
+					zb0008, bts, err = msgp.ReadUint64Bytes(bts)
+					if err != nil {
+						return
+					}
+					*z.ChainID = Uint64(zb0008)
+				}
+			}
+		case "MaxFeePerGas":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.MaxFeePerGas = nil
+			} else {
+				if z.MaxFeePerGas == nil {
+					z.MaxFeePerGas = new(Int)
+				}
+				bts, err = z.MaxFeePerGas.UnmarshalMsg(bts)
+				if err != nil {
+					return
+				}
+			}
+		case "MaxPriorityFeePerGas":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.MaxPriorityFeePerGas = nil
+			} else {
+				if z.MaxPriorityFeePerGas == nil {
+					z.MaxPriorityFeePerGas = new(Int)
+				}
+				bts, err = z.MaxPriorityFeePerGas.UnmarshalMsg(bts)
+				if err != nil {
+					return
+				}
+			}
+		case "AccessList":
+			var zb0009 uint32
+			zb0009, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.AccessList) >= int(zb0009) {
+				z.AccessList = (z.AccessList)[:zb0009]
+			} else {
+				z.AccessList = make(AccessList, zb0009)
+			}
+			for za0005 := range z.AccessList {
+				var zb0010 uint32
+				zb0010, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					return
+				}
+				for zb0010 > 0 {
+					zb0010--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Address":
+						bts, err = msgp.ReadExactBytes(bts, (z.AccessList[za0005].Address)[:])
+						if err != nil {
+							return
+						}
+					case "StorageKeys":
+						var zb0011 uint32
+						zb0011, bts, err = msgp.ReadArrayHeaderBytes(bts)
+						if err != nil {
+							return
+						}
+						if cap(z.AccessList[za0005].StorageKeys) >= int(zb0011) {
+							z.AccessList[za0005].StorageKeys = (z.AccessList[za0005].StorageKeys)[:zb0011]
+						} else {
+							z.AccessList[za0005].StorageKeys = make([]Hash, zb0011)
+						}
+						for za0007 := range z.AccessList[za0005].StorageKeys {
+							bts, err = msgp.ReadExactBytes(bts, (z.AccessList[za0005].StorageKeys[za0007])[:])
+							if err != nil {
+								return
+							}
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -2796,7 +3498,7 @@ func (z *Transaction) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *Transaction) Msgsize() (s int) {
-	s = 1 + 5 + msgp.ArrayHeaderSize + (32 * (msgp.ByteSize)) + 6 + msgp.Uint64Size + 6 + msgp.ArrayHeaderSize + (32 * (msgp.ByteSize)) + 12 + msgp.Uint64Size + 3
+	s = 3 + 5 + msgp.ArrayHeaderSize + (32 * (msgp.ByteSize)) + 6 + msgp.Uint64Size + 6 + msgp.ArrayHeaderSize + (32 * (msgp.ByteSize)) + 12 + msgp.Uint64Size + 3
 	if z.To == nil {
 		s += msgp.NilSize
 	} else {
@@ -2814,7 +3516,28 @@ func (z *Transaction) Msgsize() (s int) {
 	} else {
 		s += msgp.ArrayHeaderSize + (20 * (msgp.ByteSize))
 	}
-	s += 6 + z.Value.Msgsize() + 9 + z.GasPrice.Msgsize() + 4 + msgp.Uint64Size + 6 + msgp.BytesPrefixSize + len([]byte(z.Input))
+	s += 6 + z.Value.Msgsize() + 9 + z.GasPrice.Msgsize() + 4 + msgp.Uint64Size + 6 + msgp.BytesPrefixSize + len([]byte(z.Input)) + 5 + msgp.Uint64Size + 8
+	if z.ChainID == nil {
+		s += msgp.NilSize
+	} else {
+		s += msgp.Uint64Size
+	}
+	s += 13
+	if z.MaxFeePerGas == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.MaxFeePerGas.Msgsize()
+	}
+	s += 21
+	if z.MaxPriorityFeePerGas == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.MaxPriorityFeePerGas.Msgsize()
+	}
+	s += 11 + msgp.ArrayHeaderSize
+	for za0005 := range z.AccessList {
+		s += 1 + 8 + msgp.ArrayHeaderSize + (20 * (msgp.ByteSize)) + 12 + msgp.ArrayHeaderSize + (len(z.AccessList[za0005].StorageKeys) * (32 * (msgp.ByteSize)))
+	}
 	return
 }
 
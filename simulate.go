@@ -0,0 +1,135 @@
+package seth
+
+import (
+	"context"
+	"strings"
+)
+
+// Simulation is the outcome of executing
+// a transaction without broadcasting it.
+type Simulation struct {
+	Return Data  // return data, or revert data if the transaction reverted
+	Logs   []Log // logs emitted, if the simulator reports them
+
+	// Err is the reason that the transaction failed, or
+	// nil if it succeeded. If the transaction reverted,
+	// Err is a *RevertError.
+	Err error
+}
+
+// Revert returns the revert reason of the
+// transaction, or nil if it didn't revert.
+func (s *Simulation) Revert() *RevertError {
+	re, _ := s.Err.(*RevertError)
+	return re
+}
+
+// A Simulator executes transactions without broadcasting them.
+type Simulator interface {
+	// Simulate executes tx against the pending state of
+	// the chain. The error it returns is for failures to
+	// simulate the transaction; failures of the transaction
+	// itself are reported in the Simulation.
+	Simulate(ctx context.Context, tx *Transaction) (*Simulation, error)
+}
+
+// CallSimulator is a Simulator that executes transactions
+// with eth_call at the pending block. Since eth_call doesn't
+// return logs, the simulations it produces have none.
+type CallSimulator struct {
+	Client *Client
+}
+
+// Simulate implements Simulator
+func (c *CallSimulator) Simulate(ctx context.Context, tx *Transaction) (*Simulation, error) {
+	nonce := tx.Nonce
+	opts := CallOpts{
+		From:       tx.From,
+		To:         tx.To,
+		Gas:        NewInt(int64(tx.Gas)),
+		Data:       tx.Input,
+		Nonce:      &nonce,
+		AccessList: tx.AccessList,
+	}
+	if dynamic(tx) {
+		opts.MaxFeePerGas = tx.MaxFeePerGas
+		opts.MaxPriorityFeePerGas = tx.MaxPriorityFeePerGas
+	} else if tx.GasPrice.Big().Sign() != 0 {
+		opts.GasPrice = &tx.GasPrice
+	}
+	if tx.Value.Big().Sign() != 0 {
+		opts.Value = &tx.Value
+	}
+	sim := new(Simulation)
+	err := c.Client.ConstCallAtContext(ctx, &opts, &sim.Return, Pending)
+	switch err := err.(type) {
+	case nil:
+	case *RevertError:
+		sim.Return, sim.Err = err.Data, err
+	case *RPCError:
+		if !isExecutionError(err) {
+			return nil, err
+		}
+		// the node refused to execute the
+		// transaction, e.g. for lack of funds
+		sim.Err = err
+	default:
+		return nil, err
+	}
+	return sim, nil
+}
+
+// execErrors are fragments of the errors that nodes return
+// when a call fails because of the transaction itself, rather
+// than because the node couldn't execute it
+var execErrors = []string{
+	"execution reverted",
+	"insufficient funds",
+	"intrinsic gas",
+	"out of gas",
+	"gas required exceeds",
+	"invalid opcode",
+	"invalid jump",
+	"stack underflow",
+	"stack overflow",
+	"write protection",
+	"max code size",
+}
+
+// isExecutionError returns whether e is an error
+// returned by a node for a call that failed
+func isExecutionError(e *RPCError) bool {
+	msg := strings.ToLower(e.Message)
+	for _, s := range execErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// SimulationError is the error returned by a Sender
+// when it refuses to broadcast a transaction because
+// its simulation failed (see Sender.Simulate).
+type SimulationError struct {
+	Tx *Transaction // the transaction that wasn't broadcast
+	*Simulation
+}
+
+// Error implements error
+func (e *SimulationError) Error() string {
+	return "seth: transaction not sent: simulation failed: " + e.Err.Error()
+}
+
+// simulate simulates tx, returning a *SimulationError
+// if it fails
+func (s *Sender) simulate(ctx context.Context, tx *Transaction) error {
+	sim, err := s.Simulate.Simulate(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if sim.Err != nil {
+		return &SimulationError{Tx: tx, Simulation: sim}
+	}
+	return nil
+}
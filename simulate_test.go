@@ -0,0 +1,90 @@
+package seth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// newSimNode returns a fake node whose eth_call reverts
+// with the given data for calls with the input "bad", fails
+// for lack of funds with "poor" and is rate limited with
+// "busy", and which records the calls it sees in calls
+func newSimNode(revert Data, calls *[]CallOpts) *testNode {
+	n := newTestNode()
+	n.nonce = 7
+	n.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+		var opts CallOpts
+		json.Unmarshal(params[0], &opts)
+		*calls = append(*calls, opts)
+		var block string
+		json.Unmarshal(params[1], &block)
+		switch {
+		case block != "pending":
+			return nil, &RPCError{Code: -32000, Message: "not at the pending block"}
+		case string(opts.Data) == "poor":
+			return nil, &RPCError{Code: -32000, Message: "insufficient funds for gas * price + value"}
+		case string(opts.Data) == "busy":
+			return nil, &RPCError{Code: -32005, Message: "rate limit exceeded"}
+		case string(opts.Data) == "bad":
+			data, _ := json.Marshal(&revert)
+			return nil, &RPCError{Code: 3, Message: "execution reverted", Data: data}
+		}
+		return Data{1}, nil
+	})
+	return n
+}
+
+func TestSimulate(t *testing.T) {
+	var calls []CallOpts
+	node := newSimNode(words(t, "08c379a0", word("20"), word("2"), rword("6e6f")), &calls)
+	c := NewClientTransport(node)
+	c.SetChainID(1)
+	key := GenPrivateKey()
+	s := NewSender(c, key.Address())
+	s.Signer = key.Signer()
+	s.Simulate = &CallSimulator{Client: c}
+	to := Address{2}
+
+	// the transaction is simulated exactly as signed
+	_, err := s.Call(&CallOpts{To: &to, Gas: NewInt(30000), Value: NewInt(5), Data: Data("good")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(node.sentTxs()) != 1 || len(calls) != 1 {
+		t.Fatalf("%d transactions sent after %d calls", len(node.sentTxs()), len(calls))
+	}
+	call := calls[0]
+	if *call.From != *key.Address() || *call.To != to || call.Gas.Int64() != 30000 ||
+		call.Value.Int64() != 5 || *call.Nonce != 7 || call.MaxFeePerGas.Cmp(&s.GasPrice) != 0 {
+		t.Errorf("simulated with %+v", call)
+	}
+
+	// transactions that revert aren't sent
+	_, err = s.Call(&CallOpts{To: &to, Gas: NewInt(30000), Data: Data("bad")})
+	se, ok := err.(*SimulationError)
+	if !ok || se.Revert() == nil || se.Revert().Reason != "no" {
+		t.Fatalf("got %v instead of a simulation error", err)
+	}
+	if len(node.sentTxs()) != 1 {
+		t.Errorf("reverted transaction was sent")
+	}
+	if se.Tx.From == nil || *se.Tx.From != *key.Address() || string(se.Tx.Input) != "bad" {
+		t.Errorf("simulation error for %+v", se.Tx)
+	}
+	if msg := err.Error(); msg != "seth: transaction not sent: simulation failed: execution reverted: no" {
+		t.Errorf("got error %q", msg)
+	}
+
+	// only failures of the transaction itself are simulation errors
+	_, err = s.Call(&CallOpts{To: &to, Gas: NewInt(30000), Data: Data("poor")})
+	if _, ok := err.(*SimulationError); !ok {
+		t.Errorf("got %v instead of a simulation error", err)
+	}
+	_, err = s.Call(&CallOpts{To: &to, Gas: NewInt(30000), Data: Data("busy")})
+	if _, ok := err.(*RPCError); !ok {
+		t.Errorf("got %v instead of the node's error", err)
+	}
+	if len(node.sentTxs()) != 1 {
+		t.Errorf("transaction sent after a failed simulation")
+	}
+}
@@ -0,0 +1,275 @@
+package seth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrDeepReorg is the error of a ChainStream that has seen
+// a reorganization that replaced a block it had already
+// reported as final.
+var ErrDeepReorg = errors.New("seth: reorg deeper than the confirmation depth")
+
+// errStreamClosed is returned internally
+// when a ChainStream is closed mid-update
+var errStreamClosed = errors.New("seth: stream closed")
+
+// A ChainEventKind is the kind of a ChainEvent.
+type ChainEventKind int
+
+const (
+	// BlockAdded is the kind of event emitted
+	// when a block joins the canonical chain.
+	BlockAdded ChainEventKind = iota
+	// BlockReverted is the kind of event emitted when a
+	// block is removed from the canonical chain by a reorg.
+	// Reverted blocks are emitted newest-first, and they are
+	// followed by the blocks that replace them.
+	BlockReverted
+	// BlockFinal is the kind of event emitted when
+	// a block has enough confirmations that it is
+	// no longer expected to be reverted.
+	BlockFinal
+)
+
+func (k ChainEventKind) String() string {
+	switch k {
+	case BlockAdded:
+		return "added"
+	case BlockReverted:
+		return "reverted"
+	case BlockFinal:
+		return "final"
+	default:
+		return "unknown"
+	}
+}
+
+// A ChainEvent is emitted by a ChainStream.
+type ChainEvent struct {
+	Kind  ChainEventKind
+	Block *Block
+	// Logs are the logs in the block that match
+	// the stream's query. In BlockReverted events,
+	// the logs have Removed set.
+	Logs []Log
+}
+
+// StreamOptions are the options for (*Client).StreamChain.
+type StreamOptions struct {
+	// From is the number of the first block to emit.
+	// If it is negative, the stream starts at the latest block.
+	From int64
+	// Depth is the number of blocks that have to be built on
+	// top of a block before it is final. A Depth of zero makes
+	// blocks final as soon as they are added.
+	Depth int
+	// Txs determines whether blocks include
+	// transaction bodies or just hashes.
+	Txs bool
+	// Logs, if non-nil, selects the logs
+	// that are included in each event.
+	Logs *LogQuery
+}
+
+// A ChainStream follows the canonical chain by the parent
+// hashes of its blocks, and emits events as blocks are added
+// to it, reverted by reorgs, and made final by confirmations.
+type ChainStream struct {
+	c    *Client
+	opts StreamOptions
+	out  chan *ChainEvent
+	exit chan struct{}
+
+	// owned by the run goroutine
+	chain  []*ChainEvent // non-final canonical blocks, oldest first
+	anchor *Block        // newest final block
+
+	lock   sync.Mutex // guards below
+	err    error
+	closed bool
+}
+
+// StreamChain creates a ChainStream. New blocks are
+// polled for at the client's poll interval (see SetPollInterval).
+// Errors fetching blocks are logged and retried, but a reorg
+// deeper than the confirmation depth stops the stream with
+// ErrDeepReorg.
+func (c *Client) StreamChain(opts *StreamOptions) *ChainStream {
+	s := &ChainStream{
+		c:    c,
+		opts: *opts,
+		out:  make(chan *ChainEvent, 64),
+		exit: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Out returns the channel of events. The channel is closed
+// when the stream is closed or when it fails, in which case
+// (*ChainStream).Err() will be non-nil.
+func (s *ChainStream) Out() <-chan *ChainEvent { return s.out }
+
+// Err returns the error that stopped the stream, if any.
+func (s *ChainStream) Err() error {
+	s.lock.Lock()
+	err := s.err
+	s.lock.Unlock()
+	return err
+}
+
+// Close stops the stream and closes its output
+// channel. Close is safe to call from any goroutine.
+func (s *ChainStream) Close() {
+	s.lock.Lock()
+	if !s.closed {
+		close(s.exit)
+		s.closed = true
+	}
+	s.lock.Unlock()
+}
+
+func (s *ChainStream) run() {
+	defer close(s.out)
+	ticker := time.NewTicker(s.c.pollInterval())
+	defer ticker.Stop()
+	for {
+		switch err := s.update(); err {
+		case nil:
+		case errStreamClosed:
+			return
+		case ErrDeepReorg:
+			s.lock.Lock()
+			s.err = err
+			s.lock.Unlock()
+			return
+		default:
+			log.Printf("seth: chain stream: %s", err)
+		}
+		select {
+		case <-s.exit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tip returns the newest canonical block
+func (s *ChainStream) tip() *Block {
+	if len(s.chain) > 0 {
+		return s.chain[len(s.chain)-1].Block
+	}
+	return s.anchor
+}
+
+func (s *ChainStream) emit(ev *ChainEvent) error {
+	select {
+	case s.out <- ev:
+		return nil
+	case <-s.exit:
+		return errStreamClosed
+	}
+}
+
+// revert removes the newest block from the chain
+func (s *ChainStream) revert() error {
+	if len(s.chain) == 0 {
+		return ErrDeepReorg
+	}
+	ev := s.chain[len(s.chain)-1]
+	s.chain = s.chain[:len(s.chain)-1]
+	logs := make([]Log, len(ev.Logs))
+	for i := range ev.Logs {
+		logs[i] = ev.Logs[i]
+		logs[i].Removed = true
+	}
+	return s.emit(&ChainEvent{Kind: BlockReverted, Block: ev.Block, Logs: logs})
+}
+
+// update catches up with the head of the chain
+func (s *ChainStream) update() error {
+	head, err := s.c.GetBlock(Latest, false)
+	if err != nil {
+		return err
+	}
+	hn := int64(*head.Number)
+
+	// if the head is behind our tip, or at the same
+	// height but different, a reorg has replaced our
+	// tip with a chain that is not (yet) longer
+	for tip := s.tip(); tip != nil; tip = s.tip() {
+		tn := int64(*tip.Number)
+		if tn < hn || (tn == hn && *tip.Hash == *head.Hash) {
+			break
+		}
+		if err := s.revert(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		n := s.opts.From
+		tip := s.tip()
+		if tip != nil {
+			n = int64(*tip.Number) + 1
+		} else if n < 0 {
+			n = hn
+		}
+		if n > hn {
+			return nil
+		}
+		b, err := s.c.GetBlock(n, s.opts.Txs)
+		if err == ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if tip != nil && b.Parent != *tip.Hash {
+			// the block doesn't build on our tip,
+			// so our tip is no longer canonical
+			if err := s.revert(); err != nil {
+				return err
+			}
+			continue
+		}
+		ev := &ChainEvent{Kind: BlockAdded, Block: b}
+		if s.opts.Logs != nil {
+			if ev.Logs, err = s.c.blockLogs(b.Hash, s.opts.Logs); err != nil {
+				return err
+			}
+		}
+		s.chain = append(s.chain, ev)
+		if err := s.emit(ev); err != nil {
+			return err
+		}
+		for len(s.chain) > 0 && n-int64(*s.chain[0].Block.Number) >= int64(s.opts.Depth) {
+			f := s.chain[0]
+			s.chain = s.chain[1:]
+			s.anchor = f.Block
+			if err := s.emit(&ChainEvent{Kind: BlockFinal, Block: f.Block, Logs: f.Logs}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// blockLogs gets the logs in the given block that match q
+func (c *Client) blockLogs(h *Hash, q *LogQuery) ([]Log, error) {
+	buf, err := json.Marshal(&newFilterReq{
+		BlockHash: h,
+		Address:   q.Address,
+		Topics:    q.Topics,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var out []Log
+	if err := c.Do("eth_getLogs", []json.RawMessage{buf}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
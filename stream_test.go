@@ -0,0 +1,143 @@
+package seth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// chainNode is a fake node with a
+// canonical chain that can be replaced
+type chainNode struct {
+	*testNode
+	blocks []*Block // indexed by number
+	logs   map[Hash][]Log
+}
+
+func newChainNode() *chainNode {
+	c := &chainNode{testNode: newTestNode(), logs: make(map[Hash][]Log)}
+	c.blocks = []*Block{c.block(0, 0, nil)}
+	c.handle("eth_getBlockByNumber", func(params []json.RawMessage) (interface{}, error) {
+		var n Uint64
+		if string(params[0]) == `"latest"` {
+			n = Uint64(len(c.blocks) - 1)
+		} else {
+			json.Unmarshal(params[0], &n)
+		}
+		if int(n) < len(c.blocks) {
+			return c.blocks[n], nil
+		}
+		return nil, nil
+	})
+	c.handle("eth_getLogs", func(params []json.RawMessage) (interface{}, error) {
+		var q newFilterReq
+		json.Unmarshal(params[0], &q)
+		return c.logs[*q.BlockHash], nil
+	})
+	return c
+}
+
+func (c *chainNode) block(n int, fork byte, parent *Block) *Block {
+	num := Uint64(n)
+	h := HashBytes([]byte{byte(n), fork})
+	b := &Block{Number: &num, Hash: &h}
+	if parent != nil {
+		b.Parent = *parent.Hash
+	}
+	idx := Uint64(0)
+	c.logs[h] = []Log{{BlockHash: &h, BlockNumber: &num, LogIndex: &idx}}
+	return b
+}
+
+// extend replaces the chain above block n with
+// count new blocks that have the given fork number
+func (c *chainNode) extend(n, count int, fork byte) {
+	c.with(func() {
+		blocks := c.blocks[: n+1 : n+1]
+		for i := 0; i < count; i++ {
+			blocks = append(blocks, c.block(len(blocks), fork, blocks[len(blocks)-1]))
+		}
+		c.blocks = blocks
+	})
+}
+
+func TestChainStream(t *testing.T) {
+	tp := newChainNode()
+	tp.extend(0, 3, 0)
+	c := NewClientTransport(tp)
+	c.SetPollInterval(time.Millisecond)
+
+	s := c.StreamChain(&StreamOptions{From: 1, Depth: 2, Logs: &LogQuery{}})
+	defer s.Close()
+
+	type event struct {
+		kind ChainEventKind
+		num  int
+		fork byte
+	}
+	expect := func(want ...event) {
+		t.Helper()
+		for _, w := range want {
+			var ev *ChainEvent
+			select {
+			case ev = <-s.Out():
+			case <-time.After(10 * time.Second):
+				t.Fatalf("timed out waiting for %v", w)
+			}
+			if ev == nil {
+				t.Fatalf("stream closed waiting for %v: %v", w, s.Err())
+			}
+			h := HashBytes([]byte{byte(w.num), w.fork})
+			if ev.Kind != w.kind || *ev.Block.Hash != h {
+				t.Fatalf("got %s %d; want %v", ev.Kind, *ev.Block.Number, w)
+			}
+			if len(ev.Logs) != 1 || *ev.Logs[0].BlockHash != h {
+				t.Fatalf("%s %d: unexpected logs %v", ev.Kind, *ev.Block.Number, ev.Logs)
+			}
+			if ev.Logs[0].Removed != (w.kind == BlockReverted) {
+				t.Errorf("%s %d: log has removed=%v", ev.Kind, *ev.Block.Number, ev.Logs[0].Removed)
+			}
+		}
+	}
+
+	expect(
+		event{BlockAdded, 1, 0},
+		event{BlockAdded, 2, 0},
+		event{BlockAdded, 3, 0},
+		event{BlockFinal, 1, 0},
+	)
+
+	// replace block 3 with a longer fork
+	tp.extend(2, 2, 1)
+	expect(
+		event{BlockReverted, 3, 0},
+		event{BlockAdded, 3, 1},
+		event{BlockAdded, 4, 1},
+		event{BlockFinal, 2, 0},
+	)
+
+	// replace block 4 with a fork of the same length
+	tp.extend(3, 1, 2)
+	expect(
+		event{BlockReverted, 4, 1},
+		event{BlockAdded, 4, 2},
+	)
+
+	// replacing a final block is fatal
+	tp.extend(1, 4, 3)
+	expect(
+		event{BlockReverted, 4, 2},
+		event{BlockReverted, 3, 1},
+	)
+	select {
+	case ev, ok := <-s.Out():
+		if ok {
+			t.Fatalf("unexpected event %s %d", ev.Kind, *ev.Block.Number)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the stream to fail")
+	}
+	if s.Err() != ErrDeepReorg {
+		t.Errorf("expected ErrDeepReorg; got %v", s.Err())
+	}
+}
@@ -0,0 +1,202 @@
+package seth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrNoSubscriptions is returned by (*Client).Subscribe when
+// the client's transport does not support subscriptions.
+var ErrNoSubscriptions = errors.New("seth: transport does not support subscriptions")
+
+// ErrSubscriptionOverflow is the error of a subscription
+// that failed because its notifications weren't being read.
+var ErrSubscriptionOverflow = errors.New("seth: subscription overflowed")
+
+// maxQueued is the number of notifications that are
+// buffered for a subscription before it fails
+const maxQueued = 4096
+
+// A subscriber is a Transport that supports eth_subscribe.
+type subscriber interface {
+	// subscribe is like Execute, but if the
+	// request succeeds, s receives notifications
+	// for the subscription ID in the response
+	subscribe(req *RPCRequest, res *RPCResponse, s *Subscription) error
+	// unsubscribe stops delivering notifications to s
+	// and returns whether or not it was still subscribed
+	unsubscribe(s *Subscription) bool
+}
+
+// A Subscription is a stream of notifications
+// created by (*Client).Subscribe.
+type Subscription struct {
+	c   *Client
+	sub subscriber
+	id  string
+	out chan json.RawMessage
+
+	lock   sync.Mutex // guards below
+	queue  []json.RawMessage
+	wake   chan struct{}
+	exit   chan struct{}
+	err    error
+	closed bool
+}
+
+// Subscribe creates a subscription with eth_subscribe.
+// The kind of subscription is one of "newHeads", "logs",
+// or "newPendingTransactions", and params are its additional
+// parameters, if any. (For "logs", the parameter is an object
+// with "address" and "topics" fields, as for eth_newFilter.)
+//
+// Notifications are delivered on the Out channel of the subscription
+// as JSON: a Block (without transactions) for "newHeads," a Log for
+// "logs," and a transaction Hash for "newPendingTransactions."
+//
+// Only some transports, like WSTransport, support subscriptions;
+// other transports return ErrNoSubscriptions.
+func (c *Client) Subscribe(kind string, params ...interface{}) (*Subscription, error) {
+	sub, ok := c.tport.(subscriber)
+	if !ok {
+		return nil, ErrNoSubscriptions
+	}
+	args := make([]json.RawMessage, 1+len(params))
+	args[0], _ = json.Marshal(kind)
+	for i := range params {
+		buf, err := json.Marshal(params[i])
+		if err != nil {
+			return nil, err
+		}
+		args[i+1] = buf
+	}
+	s := &Subscription{
+		c:    c,
+		sub:  sub,
+		out:  make(chan json.RawMessage),
+		wake: make(chan struct{}, 1),
+		exit: make(chan struct{}),
+	}
+	res := new(RPCResponse)
+	if err := sub.subscribe(c.request("eth_subscribe", args), res, s); err != nil {
+		return nil, err
+	}
+	var id string
+	if err := res.decode(&id); err != nil {
+		return nil, err
+	}
+	go s.deliver()
+	return s, nil
+}
+
+// ID returns the subscription ID assigned by the node.
+func (s *Subscription) ID() string { return s.id }
+
+// Out returns the channel of notifications.
+// The channel is closed when the subscription
+// is closed, or when the subscription fails, in
+// which case (*Subscription).Err() will be non-nil.
+func (s *Subscription) Out() <-chan json.RawMessage { return s.out }
+
+// Err returns the error that caused the subscription
+// to fail, if any. A subscription fails if the connection
+// to the node is lost or if its notifications aren't read
+// quickly enough.
+func (s *Subscription) Err() error {
+	s.lock.Lock()
+	err := s.err
+	s.lock.Unlock()
+	return err
+}
+
+// Close closes the subscription and
+// closes its output channel. Close is
+// safe to call from any goroutine.
+func (s *Subscription) Close() {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.exit)
+	s.lock.Unlock()
+
+	// only unsubscribe if the subscription
+	// didn't die along with its connection
+	if s.sub.unsubscribe(s) {
+		id, _ := json.Marshal(s.id)
+		var ok bool
+		err := s.c.Do("eth_unsubscribe", []json.RawMessage{id}, &ok)
+		if err != nil || !ok {
+			log.Printf("eth_unsubscribe: %s %v", err, ok)
+		}
+	}
+}
+
+// push queues a notification
+func (s *Subscription) push(msg json.RawMessage) {
+	s.lock.Lock()
+	if s.closed || s.err != nil {
+		s.lock.Unlock()
+		return
+	}
+	if len(s.queue) >= maxQueued {
+		s.err = ErrSubscriptionOverflow
+	} else {
+		s.queue = append(s.queue, msg)
+	}
+	s.lock.Unlock()
+	s.poke()
+}
+
+// fail stops the subscription with an error
+func (s *Subscription) fail(err error) {
+	s.lock.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.lock.Unlock()
+	s.poke()
+}
+
+func (s *Subscription) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends queued notifications to the output channel
+// so that slow readers don't hold up the connection
+func (s *Subscription) deliver() {
+	defer close(s.out)
+	for {
+		s.lock.Lock()
+		for len(s.queue) == 0 {
+			done := s.closed || s.err != nil
+			s.lock.Unlock()
+			if done {
+				return
+			}
+			select {
+			case <-s.wake:
+			case <-s.exit:
+				return
+			}
+			s.lock.Lock()
+		}
+		msg := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.lock.Unlock()
+
+		select {
+		case s.out <- msg:
+		case <-s.exit:
+			return
+		}
+	}
+}
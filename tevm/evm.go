@@ -1,11 +1,11 @@
 package tevm
 
 import (
-	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"math/big"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
@@ -69,6 +69,7 @@ var theparams = params.ChainConfig{
 	EIP150Block:    new(big.Int),
 	EIP155Block:    new(big.Int),
 	EIP158Block:    new(big.Int),
+	ByzantiumBlock: new(big.Int),
 }
 
 // State database for the EVM.
@@ -478,31 +479,39 @@ type Chain struct {
 	filters    map[int]*filter
 	filtcount  int
 	pendingrx  []*seth.Receipt // receipts for transactions in the pending block
+	subs       map[string]*subscription
+	subcount   int
+	canon      map[int64]seth.Hash // hashes of blocks that replaced others in a Reorg
+	reorgs     int
+	queued     map[seth.Address]map[uint64]*seth.Transaction // raw transactions with future nonces
 	mu         sync.Mutex
 }
 
 type filter struct {
-	from, to blocknum      // block range to inspect
-	addr     *seth.Address // address of contract to watch
-	topics   []*seth.Hash  // topics to match
-	lastlog  int           // last log index inspected
+	from, to blocknum         // block range to inspect
+	addrs    seth.AddressList // addresses of contracts to watch
+	topics   seth.TopicList   // topics to match
+	lastlog  int              // last log index inspected
+	removed  []seth.Log       // logs removed by a Reorg since the last inspection
 }
 
 func (f *filter) matches(log *types.Log) bool {
 	if (f.from >= 0 && log.BlockNumber < uint64(f.from)) || (f.to >= 0 && log.BlockNumber > uint64(f.to)) {
 		return false
 	}
-	if f.addr != nil && log.Address != common.Address(*f.addr) {
+	addr := seth.Address(log.Address)
+	if !f.addrs.Match(&addr) {
 		return false
 	}
 	for i := range f.topics {
-		if f.topics[i] == nil {
+		if len(f.topics[i]) == 0 {
 			continue
 		}
 		if len(log.Topics) <= i {
 			return false
 		}
-		if !bytes.Equal(log.Topics[i][:], f.topics[i][:]) {
+		topic := seth.Hash(log.Topics[i])
+		if !f.topics.Match(i, &topic) {
 			return false
 		}
 	}
@@ -551,7 +560,7 @@ func (c *Chain) AtBlock(n int64) *Chain {
 		snap = s
 	}
 
-	h := seth.Hash(n2h(uint64(n)))
+	h := c.blockHash(n)
 	buf := c.State.Blocks.Get(h[:])
 	if buf == nil {
 		return nil
@@ -567,6 +576,7 @@ func (c *Chain) AtBlock(n int64) *Chain {
 	cc.Debugf = c.Debugf
 	cc.State.Pending = nb
 	cc.block2snap = c.block2snap
+	cc.canon = c.canon
 	return cc
 }
 
@@ -695,6 +705,10 @@ func cantransfer(s vm.StateDB, addr common.Address, v *big.Int) bool {
 }
 
 func dotransfer(s vm.StateDB, from, to common.Address, v *big.Int) {
+	// calls with state overrides wrap the real state
+	if o, ok := s.(*overrideState); ok {
+		s = o.StateDB
+	}
 	st := s.(*gethState)
 	if st.Trace != nil {
 		st.Trace("Transfer", from.String(), to.String(), v.String())
@@ -789,25 +803,59 @@ func (c *Chain) CreateAt(addr, sender *seth.Address, code []byte) error {
 	return nil
 }
 
+// DeployMulticall deploys seth.MulticallCode at
+// seth.MulticallAddress, so that (*seth.Client).Multicall
+// can be used against the chain.
+func (c *Chain) DeployMulticall() {
+	c.mu.Lock()
+	addr := common.Address(seth.MulticallAddress)
+	s := c.State.StateDB()
+	s.CreateAccount(addr)
+	s.SetNonce(addr, 1)
+	s.SetCode(addr, seth.MulticallCode)
+	c.mu.Unlock()
+}
+
+// reverted returns whether or not err indicates
+// that execution was stopped by a REVERT
+//
+// geth does not export its revert error, so
+// the message is the only thing to match on.
+func reverted(err error) bool {
+	return err != nil && strings.HasSuffix(err.Error(), "execution reverted")
+}
+
+// callError converts an error returned by the EVM into the
+// error returned to callers, decoding the revert data (ret)
+// of reverted calls into a *seth.RevertError
+func callError(ret []byte, err error) error {
+	if reverted(err) {
+		return seth.DecodeRevert(ret, nil)
+	}
+	return err
+}
+
 // Call executes a transaction that represents
 // a call initiated by 'sender' to the destination
-// address.
+// address. If the call reverts, the returned error
+// is a *seth.RevertError.
 //
 // 'sig' must be in the canonical method signature encoding.
 func (c *Chain) Call(sender, dst *seth.Address, sig string, args ...seth.EtherType) ([]byte, error) {
 	c.mu.Lock()
 	ret, _, err := c.evm(*sender).Call(s2r(sender), common.Address(*dst), seth.ABIEncode(sig, args...), defaultGasLimit, &zero)
 	c.mu.Unlock()
-	return ret, err
+	return ret, callError(ret, err)
 }
 
 // StaticCall yields the result of the given transaction in
 // the pending block without comitting the state changes to the chain.
+// If the call reverts, the returned error is a *seth.RevertError.
 func (c *Chain) StaticCall(sender, dst *seth.Address, sig string, args ...seth.EtherType) ([]byte, error) {
 	c.mu.Lock()
 	ret, _, err := c.evm(*sender).StaticCall(s2r(sender), common.Address(*dst), seth.ABIEncode(sig, args...), defaultGasLimit)
 	c.mu.Unlock()
-	return ret, err
+	return ret, callError(ret, err)
 }
 
 // EstimateGas estimates the amount of gas that the given transaction will use.
@@ -891,15 +939,34 @@ func pretty(v interface{}) []byte {
 // this method respects the amount of gas sent in the transaction,
 // rather than offering all of the gas in the block to the transaction,
 // which more faithfully mimics the behavior of an actual ethereum node.
+// The transaction is given the sender's current nonce, which is then
+// incremented, and, unless tx.Hash is already set (as it is for signed
+// transactions), a made-up hash.
 func (c *Chain) Mine(tx *seth.Transaction) (ret []byte, h seth.Hash, err error) {
 	b := c.State.Pending
 
-	// make up a tx hash:
-	// combine block number and transaction index deterministically
-	bh := n2h(uint64(*b.Number) | (uint64(len(b.Transactions)) << 48))
-	tx.Hash = seth.HashBytes(bh[:])
+	if tx.Hash == (seth.Hash{}) {
+		// make up a tx hash:
+		// combine block number and transaction index deterministically
+		bh := n2h(uint64(*b.Number) | (uint64(len(b.Transactions)) << 48))
+		tx.Hash = seth.HashBytes(bh[:])
+		if c.reorgs != 0 {
+			// don't reuse the hashes of
+			// transactions in replaced blocks
+			tx.Hash = seth.HashBytes(append(tx.Hash[:], b.Hash[:]...))
+		}
+	}
 	h = tx.Hash
 
+	st := c.State.StateDB()
+	from := common.Address(*tx.From)
+	tx.Nonce = seth.Uint64(st.GetNonce(from))
+	if tx.To != nil {
+		// contract creation increments
+		// the nonce of the sender itself
+		st.SetNonce(from, uint64(tx.Nonce)+1)
+	}
+
 	l0 := len(c.State.Logs)
 
 	var gas uint64
@@ -926,6 +993,7 @@ func (c *Chain) Mine(tx *seth.Transaction) (ret []byte, h seth.Hash, err error)
 
 	for _, l := range c.State.Logs[l0:] {
 		copy(l.TxHash[:], tx.Hash[:])
+		copy(l.BlockHash[:], b.Hash[:])
 	}
 
 	rx := &seth.Receipt{
@@ -944,6 +1012,7 @@ func (c *Chain) Mine(tx *seth.Transaction) (ret []byte, h seth.Hash, err error)
 	b.Transactions = append(b.Transactions, js(&tx.Hash))
 	c.pendingrx = append(c.pendingrx, rx)
 	c.State.Transactions.Insert(tx.Hash[:], encode(tx))
+	c.notifyPending(&tx.Hash)
 	return
 }
 
@@ -974,9 +1043,10 @@ func (c *Chain) Seal() {
 	// seal the current state
 	c.block2snap[int64(*b.Number)] = (*gethState)(&c.State).Snapshot()
 	c.State.Blocks.Insert(b.Hash[:], encode(b))
+	c.notifySealed(b)
 
 	n := seth.Uint64(uint64(*b.Number) + 1)
-	h := seth.Hash(n2h(uint64(n)))
+	h := c.newHash(int64(n))
 	c.State.Pending = &seth.Block{
 		Number:          &n,
 		Parent:          *b.Hash,
@@ -994,7 +1064,9 @@ func (c *Chain) MarshalJSON() ([]byte, error) {
 	b, err := json.Marshal(&struct {
 		State      State
 		Block2snap map[int64]int
-	}{c.State, c.block2snap})
+		Canon      map[int64]seth.Hash `json:",omitempty"`
+		Reorgs     int                 `json:",omitempty"`
+	}{c.State, c.block2snap, c.canon, c.reorgs})
 	c.mu.Unlock()
 	return b, err
 }
@@ -1004,6 +1076,8 @@ func (c *Chain) UnmarshalJSON(b []byte) error {
 	var s struct {
 		State      State
 		Block2snap map[int64]int
+		Canon      map[int64]seth.Hash
+		Reorgs     int
 	}
 	if err := json.Unmarshal(b, &s); err != nil {
 		return err
@@ -1011,6 +1085,8 @@ func (c *Chain) UnmarshalJSON(b []byte) error {
 	c.mu.Lock()
 	c.State = s.State
 	c.block2snap = s.Block2snap
+	c.canon = s.Canon
+	c.reorgs = s.Reorgs
 	c.mu.Unlock()
 	return nil
 }
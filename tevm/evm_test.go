@@ -2,10 +2,12 @@ package tevm
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -125,6 +127,13 @@ func TestTransportBasic(t *testing.T) {
 		t.Error(err)
 	}
 
+	// eth_chainId
+	if id, err := client.ChainID(); err != nil {
+		t.Error(err)
+	} else if id != theparams.ChainID.Uint64() {
+		t.Errorf("chain ID %d; want %d", id, theparams.ChainID.Uint64())
+	}
+
 	// eth_syncing
 	if _, err := client.Syncing(); err != nil {
 		t.Error(err)
@@ -281,3 +290,257 @@ func TestForkedChain(t *testing.T) {
 		t.Errorf("non-zero balance (%d) on the other side of the chain copy...?", bal)
 	}
 }
+
+// revertcode deploys a contract that always
+// reverts with Error("no")
+var revertcode = []byte{
+	0x60, 0x57, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, 0x57, 0x60, 0x00, 0xf3,
+	// mstore(0, 0x08c379a0 << 224)
+	0x7f, 0x08, 0xc3, 0x79, 0xa0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x60, 0x00, 0x52,
+	// mstore(4, 0x20); mstore(0x24, 2)
+	0x60, 0x20, 0x60, 0x04, 0x52, 0x60, 0x02, 0x60, 0x24, 0x52,
+	// mstore(0x44, "no")
+	0x7f, 0x6e, 0x6f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x60, 0x44, 0x52,
+	// revert(0, 0x64)
+	0x60, 0x64, 0x60, 0x00, 0xfd,
+}
+
+func TestRevertError(t *testing.T) {
+	t.Parallel()
+	chain := NewChain()
+	me := chain.NewAccount(1)
+	addr, err := seth.ParseAddress("0x0123456789abcdef0123456789abcdef0123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.CreateAt(addr, &me, revertcode); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = chain.Call(&me, addr, "f()")
+	if re, ok := err.(*seth.RevertError); !ok || re.Reason != "no" {
+		t.Fatalf("expected revert error; got %v", err)
+	}
+
+	// the revert data should survive a trip through JSON-RPC
+	var out seth.Data
+	err = chain.Sender(&me).ConstCall(addr, "f()", &out)
+	if re, ok := err.(*seth.RevertError); !ok || re.Reason != "no" {
+		t.Fatalf("expected revert error; got %v", err)
+	}
+}
+
+func TestMulticall(t *testing.T) {
+	t.Parallel()
+	bundle, err := seth.CompileGlob("./erc20/*.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := NewChain()
+	chain.DeployMulticall()
+	me := chain.NewAccount(1)
+	other := chain.NewAccount(1)
+	sender := chain.Sender(&me)
+	token, err := sender.Create(bundle.Contract("TokenERC20").Code, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := sender.Send(&token, "mint(address,uint256)", &me, seth.NewInt(10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Wait(&h); err != nil {
+		t.Fatal(err)
+	}
+	bad, err := seth.ParseAddress("0x0123456789abcdef0123456789abcdef0123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.CreateAt(bad, &me, revertcode); err != nil {
+		t.Fatal(err)
+	}
+
+	var mine, theirs seth.Int
+	elems := []seth.MulticallElem{
+		{To: &token, Data: seth.ABIEncode("balanceOf(address)", &me), Result: []interface{}{&mine}},
+		{To: &token, Data: seth.ABIEncode("balanceOf(address)", &other), Result: []interface{}{&theirs}},
+		{To: bad, Data: seth.ABIEncode("f()")},
+	}
+	if err := chain.Client().Multicall(elems, seth.Latest); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range elems[:2] {
+		if !e.Success || e.Error != nil {
+			t.Errorf("unexpected failure %v", e.Error)
+		}
+	}
+	if mine.Int64() != 10000 || theirs.Int64() != 0 {
+		t.Errorf("balances are %s and %s", mine.String(), theirs.String())
+	}
+	if re, ok := elems[2].Error.(*seth.RevertError); elems[2].Success || !ok || re.Reason != "no" {
+		t.Errorf("expected revert error; got %v", elems[2].Error)
+	}
+}
+
+// echocode returns the first argument
+// passed to it, whatever the function
+var echocode = []byte{
+	0x60, 0x04, 0x35, 0x60, 0x00, 0x52, // mstore(0, calldataload(4))
+	0x60, 0x20, 0x60, 0x00, 0xf3, // return(0, 0x20)
+}
+
+// TestMulticallOverride exercises MulticallCode
+// through the state override fallback, since
+// nothing is deployed at MulticallAddress
+func TestMulticallOverride(t *testing.T) {
+	t.Parallel()
+	chain := NewChain()
+	me := chain.NewAccount(1)
+	echo := seth.Address{0xec}
+	db := chain.State.StateDB()
+	db.CreateAccount(common.Address(echo))
+	db.SetCode(common.Address(echo), echocode)
+	bad, err := seth.ParseAddress("0x0123456789abcdef0123456789abcdef0123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.CreateAt(bad, &me, revertcode); err != nil {
+		t.Fatal(err)
+	}
+
+	var a, b, c seth.Int
+	elems := []seth.MulticallElem{
+		{To: &echo, Data: seth.ABIEncode("f(uint256)", seth.NewInt(7)), Result: []interface{}{&a}},
+		{To: bad, Data: seth.ABIEncode("f()")},
+		{To: &echo, Data: seth.ABIEncode("f(uint256)", seth.NewInt(1e9)), Result: []interface{}{&b}},
+		// an account without code returns nothing
+		{To: &me, Data: seth.ABIEncode("f()"), Result: []interface{}{&c}},
+	}
+	if err := chain.Client().Multicall(elems, seth.Latest); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{0, 2} {
+		if e := elems[i]; !e.Success || e.Error != nil || len(e.Return) != 32 {
+			t.Errorf("call %d: unexpected failure %v (returned %x)", i, e.Error, []byte(e.Return))
+		}
+	}
+	if a.Int64() != 7 || b.Int64() != 1e9 {
+		t.Errorf("results are %s and %s", a.String(), b.String())
+	}
+	if re, ok := elems[1].Error.(*seth.RevertError); elems[1].Success || !ok || re.Reason != "no" {
+		t.Errorf("expected revert error; got %v", elems[1].Error)
+	}
+	if e := elems[3]; !e.Success || len(e.Return) != 0 || e.Error == nil {
+		t.Errorf("call to an account without code: success %v, return %x, error %v", e.Success, []byte(e.Return), e.Error)
+	}
+
+	// the aggregator code must not have been deployed
+	if code := chain.State.StateDB().GetCode(common.Address(seth.MulticallAddress)); len(code) != 0 {
+		t.Errorf("code %x at MulticallAddress", code)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+	bundle, err := seth.CompileGlob("./erc20/*.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := NewChain()
+	key := seth.GenPrivateKey()
+	me := key.Address()
+	chain.AddBalance(me, big.NewInt(1e18))
+	other := chain.NewAccount(1)
+	sender := chain.Sender(me)
+	token, err := sender.Create(bundle.Contract("TokenERC20").Code, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := sender.Send(&token, "mint(address,uint256)", me, seth.NewInt(10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Wait(&h); err != nil {
+		t.Fatal(err)
+	}
+	bad, err := seth.ParseAddress("0x0123456789abcdef0123456789abcdef0123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.CreateAt(bad, me, revertcode); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulated transfers emit logs but don't change the chain
+	transfer := seth.ABIEncode("transfer(address,uint256)", &other, seth.NewInt(100))
+	sim, err := chain.Simulate(context.Background(), &seth.Transaction{
+		From:  me,
+		To:    &token,
+		Gas:   100000,
+		Input: seth.Data(transfer),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Err != nil || len(sim.Logs) != 1 || !bytes.Equal(sim.Logs[0].Topics[0], seth.ERC20Transfer[:]) {
+		t.Fatalf("simulated transfer: %+v", sim)
+	}
+	var bal seth.Int
+	if err := sender.ConstCall(&token, "balanceOf(address)", &bal, &other); err != nil {
+		t.Fatal(err)
+	}
+	if bal.Int64() != 0 {
+		t.Errorf("simulation changed balance to %d", bal.Int64())
+	}
+
+	// signed transactions must have the next nonce
+	for _, n := range []int64{-1, 1} {
+		nonce, err := sender.GetNonce(me)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim, err = chain.Simulate(context.Background(), &seth.Transaction{
+			Nonce: seth.Uint64(nonce + n),
+			From:  me,
+			To:    &token,
+			Gas:   100000,
+			Input: seth.Data(transfer),
+			Hash:  seth.Hash{1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sim.Err == nil || !strings.HasPrefix(sim.Err.Error(), "nonce too") {
+			t.Errorf("simulated nonce %d+%d: %v", nonce, n, sim.Err)
+		}
+	}
+
+	// senders don't broadcast transactions that revert
+	sender.Signer = key.Signer()
+	sender.Simulate = chain
+	nonce, err := sender.GetNonce(me)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sender.Call(&seth.CallOpts{To: bad, Gas: seth.NewInt(100000), Data: seth.ABIEncode("f()")})
+	se, ok := err.(*seth.SimulationError)
+	if !ok || se.Revert() == nil || se.Revert().Reason != "no" {
+		t.Fatalf("expected a simulation error; got %v", err)
+	}
+	if n, err := sender.GetNonce(me); err != nil || n != nonce {
+		t.Errorf("nonce went from %d to %d (%v)", nonce, n, err)
+	}
+	if _, err := sender.Call(&seth.CallOpts{To: &token, Gas: seth.NewInt(100000), Data: seth.Data(transfer)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.ConstCall(&token, "balanceOf(address)", &bal, &other); err != nil {
+		t.Fatal(err)
+	}
+	if bal.Int64() != 100 {
+		t.Errorf("balance is %d after transfer", bal.Int64())
+	}
+}
@@ -2,6 +2,7 @@ package tevm
 
 import (
 	"bytes"
+	"sync"
 	"testing"
 	"time"
 
@@ -68,3 +69,162 @@ func TestFilter(t *testing.T) {
 	checkTransfer()
 	filter.Close()
 }
+
+func TestFilterAddresses(t *testing.T) {
+	bundle, err := seth.CompileGlob("./erc20/*.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := NewChain()
+	acct := chain.NewAccount(1)
+	client := seth.NewClientTransport(chain)
+	client.SetPollInterval(time.Millisecond)
+	sender := seth.NewSender(client, &acct)
+
+	var tokens [3]seth.Address
+	for i := range tokens {
+		tokens[i], err = sender.Create(bundle.Contract("TokenERC20").Code, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// transfers from the zero address (mints)
+	// or from acct on the first two tokens
+	var zero, from seth.Hash
+	copy(from[12:], acct[:])
+	q := &seth.LogQuery{
+		Address: seth.AddressList{tokens[0], tokens[1]},
+		Topics:  seth.TopicList{{seth.ERC20Transfer}, {zero, from}},
+	}
+	filter, err := client.Filter(q, -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer filter.Close()
+
+	acct2 := chain.NewAccount(0)
+	for i := range tokens {
+		if _, err := sender.Send(&tokens[i], "mint(address,uint256)", &acct, seth.NewInt(100)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sender.Send(&tokens[i], "transfer(address,uint256)", &acct2, seth.NewInt(10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(logs []*seth.Log) {
+		t.Helper()
+		if len(logs) != 4 {
+			t.Fatalf("got %d logs; want 4", len(logs))
+		}
+		for i, l := range logs {
+			if l.Address != tokens[i/2] {
+				t.Errorf("log %d is from %s", i, l.Address.String())
+			}
+		}
+	}
+
+	var logs []*seth.Log
+	for len(logs) < 4 {
+		select {
+		case l := <-filter.Out():
+			logs = append(logs, l)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("got %d logs from the filter", len(logs))
+		}
+	}
+	check(logs)
+
+	logs = logs[:0]
+	scan := client.GetLogs(q, &seth.ScanOptions{To: -1, Span: 2})
+	for l := range scan.Out() {
+		logs = append(logs, l)
+	}
+	if err := scan.Err(); err != nil {
+		t.Fatal(err)
+	}
+	check(logs)
+}
+
+// flightNode counts the requests in flight to a chain
+type flightNode struct {
+	*Chain
+	lock     sync.Mutex
+	inflight int
+	max      int
+}
+
+func (n *flightNode) Execute(req *seth.RPCRequest, res *seth.RPCResponse) error {
+	n.lock.Lock()
+	if n.inflight++; n.inflight > n.max {
+		n.max = n.inflight
+	}
+	n.lock.Unlock()
+	defer func() {
+		n.lock.Lock()
+		n.inflight--
+		n.lock.Unlock()
+	}()
+	// give the other requests time to arrive
+	time.Sleep(time.Millisecond)
+	return n.Chain.Execute(req, res)
+}
+
+func TestGetLogs(t *testing.T) {
+	chain := NewChain()
+	acct := chain.NewAccount(1)
+	// a contract that emits an empty log
+	emitter, err := chain.Create(&acct, []byte{
+		0x65, 0x60, 0x00, 0x60, 0x00, 0xa0, 0x00, // push6 (log0(0, 0))
+		0x60, 0x00, 0x52, // mstore(0, ...)
+		0x60, 0x06, 0x60, 0x1a, 0xf3, // return(26, 6)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := chain.Sender(&acct)
+	first := -1
+	for i := 0; i < 20; i++ {
+		h, err := sender.Send(&emitter, "emit()")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := sender.GetReceipt(&h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first < 0 {
+			first = int(*r.Logs[0].BlockNumber)
+		}
+	}
+
+	node := &flightNode{Chain: chain}
+	client := seth.NewClientTransport(node)
+	s := client.GetLogs(&seth.LogQuery{Address: seth.AddressList{emitter}}, &seth.ScanOptions{
+		From:        int64(first),
+		To:          int64(first + 19),
+		Span:        2,
+		Concurrency: 3,
+	})
+	defer s.Close()
+	next := first
+	for l := range s.Out() {
+		if int(*l.BlockNumber) != next {
+			t.Fatalf("got a log from block %d; want %d", *l.BlockNumber, next)
+		}
+		next++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if next != first+20 {
+		t.Fatalf("scan stopped before block %d", next)
+	}
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	if node.max < 2 || node.max > 3 {
+		t.Errorf("%d concurrent requests; want 2 or 3", node.max)
+	}
+}
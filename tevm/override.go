@@ -0,0 +1,88 @@
+package tevm
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/newalchemylimited/seth"
+)
+
+// overrideState is a vm.StateDB in which the
+// storage of some accounts has been replaced
+// entirely by an eth_call state override
+type overrideState struct {
+	vm.StateDB
+	storage map[common.Address]map[common.Hash]common.Hash
+}
+
+func (o *overrideState) GetState(addr common.Address, key common.Hash) common.Hash {
+	if st, ok := o.storage[addr]; ok {
+		return st[key]
+	}
+	return o.StateDB.GetState(addr, key)
+}
+
+// SetState isn't undone by RevertToSnapshot for
+// accounts with replaced storage, but that doesn't
+// matter for static calls, which can't modify storage
+func (o *overrideState) SetState(addr common.Address, key, value common.Hash) {
+	if st, ok := o.storage[addr]; ok {
+		st[key] = value
+		return
+	}
+	o.StateDB.SetState(addr, key, value)
+}
+
+// override applies eth_call state and block overrides
+// to c, which should be a throwaway copy of the chain,
+// and returns the vm.StateDB in which to execute the call.
+func (c *Chain) override(state seth.StateOverride, bo *seth.BlockOverrides) (vm.StateDB, error) {
+	if bo != nil {
+		b := c.State.Pending
+		if bo.Number != nil {
+			n := *bo.Number
+			b.Number = &n
+		}
+		if bo.Timestamp != nil {
+			b.Timestamp = *bo.Timestamp
+		}
+		// bo.BaseFee is ignored; the EVM predates
+		// EIP-1559, so contracts can't observe it
+	}
+	s := (*gethState)(&c.State)
+	db := &overrideState{StateDB: s}
+	for addr, o := range state {
+		a := addr
+		if o.State != nil && o.StateDiff != nil {
+			return nil, fmt.Errorf("account %s has both 'state' and 'stateDiff'", a.String())
+		}
+		// the account is created if necessary, since
+		// calls to accounts that don't exist do nothing
+		acct, _ := s.getAccount(&a)
+		if o.Balance != nil {
+			acct.SetBalance(o.Balance.Big())
+		}
+		if o.Nonce != nil {
+			acct.SetNonce(uint64(*o.Nonce))
+		}
+		s.setAccount(&a, &acct)
+		if o.Code != nil {
+			s.SetCode(common.Address(a), *o.Code)
+		}
+		for k, v := range o.StateDiff {
+			s.SetState(common.Address(a), common.Hash(k), common.Hash(v))
+		}
+		if o.State != nil {
+			st := make(map[common.Hash]common.Hash, len(o.State))
+			for k, v := range o.State {
+				st[common.Hash(k)] = common.Hash(v)
+			}
+			if db.storage == nil {
+				db.storage = make(map[common.Address]map[common.Hash]common.Hash)
+			}
+			db.storage[common.Address(a)] = st
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,119 @@
+package tevm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/newalchemylimited/seth"
+)
+
+// blockHash returns the hash of the
+// canonical block with the given number.
+func (c *Chain) blockHash(n int64) seth.Hash {
+	if h, ok := c.canon[n]; ok {
+		return h
+	}
+	// blocks that haven't been replaced
+	// are named after their block number
+	return seth.Hash(n2h(uint64(n)))
+}
+
+// newHash returns the hash of a new block with the given
+// number. After a Reorg, new blocks get hashes that are
+// different from the hashes of the blocks they replace.
+func (c *Chain) newHash(n int64) seth.Hash {
+	h := seth.Hash(n2h(uint64(n)))
+	if c.reorgs == 0 {
+		return h
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(c.reorgs))
+	h = seth.HashBytes(append(h[:], buf[:]...))
+	if c.canon == nil {
+		c.canon = make(map[int64]seth.Hash)
+	}
+	c.canon[n] = h
+	return h
+}
+
+// Reorg simulates a chain reorganization in which the last
+// depth sealed blocks are replaced by a competing chain.
+//
+// The pending block and the replaced blocks are discarded
+// along with their transactions, and the state of the chain
+// is rolled back to the end of the last block that remains.
+// Blocks sealed afterwards have different hashes than the
+// blocks they replace, so the competing chain can be built
+// with Mine and Seal. (The replaced blocks can still be
+// retrieved by hash.) Subscribers and filters see the logs
+// of the replaced blocks again with Removed set.
+func (c *Chain) Reorg(depth int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending := int64(*c.State.Pending.Number)
+	base := pending - 1 - int64(depth)
+	snap, ok := c.block2snap[base]
+	if depth < 1 || !ok {
+		return fmt.Errorf("tevm: cannot replace %d blocks", depth)
+	}
+	h := c.blockHash(base)
+	buf := c.State.Blocks.Get(h[:])
+	if buf == nil {
+		return fmt.Errorf("tevm: unknown block %d", base)
+	}
+	parent := new(seth.Block)
+	if _, err := parent.UnmarshalMsg(buf); err != nil {
+		return err
+	}
+
+	// copy the logs that are about to be removed,
+	// since their storage is reused after the rollback
+	keep := c.State.Snapshots[snap].LogLen
+	old := c.State.Logs[keep:]
+	removed := make([]*types.Log, len(old))
+	for i := range old {
+		l := *old[i]
+		l.Removed = true
+		removed[i] = &l
+	}
+
+	(*gethState)(&c.State).RevertToSnapshot(snap)
+	c.State.Snapshots = c.State.Snapshots[:snap+1]
+	for n := base + 1; n <= pending; n++ {
+		delete(c.block2snap, n)
+		delete(c.canon, n)
+	}
+	c.pendingrx = c.pendingrx[:0]
+	c.reorgs++
+
+	n := seth.Uint64(base + 1)
+	nh := c.newHash(int64(n))
+	c.State.Pending = &seth.Block{
+		Number:          &n,
+		Parent:          *parent.Hash,
+		Hash:            &nh,
+		GasLimit:        parent.GasLimit,
+		Difficulty:      seth.NewInt(0),
+		TotalDifficulty: seth.NewInt(0),
+		Timestamp:       seth.Uint64(now()),
+	}
+
+	// filters only report the removal
+	// of logs that they have already reported
+	for _, f := range c.filters {
+		for i, l := range removed {
+			if keep+i < f.lastlog && f.matches(l) {
+				var sl seth.Log
+				l2l(l, &sl)
+				f.removed = append(f.removed, sl)
+			}
+		}
+		if f.lastlog > keep {
+			f.lastlog = keep
+		}
+	}
+	c.notifyRemoved(removed)
+	return nil
+}
@@ -0,0 +1,103 @@
+package tevm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/newalchemylimited/seth"
+)
+
+func TestReorg(t *testing.T) {
+	bundle, err := seth.CompileGlob("./erc20/*.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := NewChain()
+	acct := chain.NewAccount(1)
+	client := seth.NewClientTransport(chain)
+	client.SetPollInterval(time.Millisecond)
+	sender := seth.NewSender(client, &acct)
+
+	token, err := sender.Create(bundle.Contract("TokenERC20").Code, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := client.StreamChain(&seth.StreamOptions{
+		From:  seth.Latest,
+		Depth: 3,
+		Logs:  &seth.LogQuery{Address: seth.AddressList{token}},
+	})
+	defer stream.Close()
+	next := func(kind seth.ChainEventKind) *seth.ChainEvent {
+		t.Helper()
+		select {
+		case ev, ok := <-stream.Out():
+			if !ok {
+				t.Fatalf("stream closed: %v", stream.Err())
+			}
+			if ev.Kind != kind {
+				t.Fatalf("got %s event; want %s", ev.Kind, kind)
+			}
+			return ev
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for a %s event", kind)
+		}
+		return nil
+	}
+	created := next(seth.BlockAdded)
+
+	h, err := sender.Send(&token, "mint(address,uint256)", &acct, seth.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	minted := next(seth.BlockAdded)
+	if minted.Block.Parent != *created.Block.Hash {
+		t.Fatal("mint block doesn't follow the creation block")
+	}
+	if len(minted.Logs) != 1 || *minted.Logs[0].TxHash != h {
+		t.Fatalf("unexpected logs %v", minted.Logs)
+	}
+
+	// replace the mint block with one that mints a different amount
+	if err := chain.Reorg(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetTransaction(&h); err == nil {
+		t.Error("replaced transaction still exists")
+	}
+	h2, err := sender.Send(&token, "mint(address,uint256)", &acct, seth.NewInt(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h2 == h {
+		t.Error("replacement transaction has the same hash")
+	}
+
+	reverted := next(seth.BlockReverted)
+	if *reverted.Block.Hash != *minted.Block.Hash {
+		t.Error("wrong block reverted")
+	}
+	if len(reverted.Logs) != 1 || !reverted.Logs[0].Removed {
+		t.Errorf("unexpected reverted logs %v", reverted.Logs)
+	}
+	replaced := next(seth.BlockAdded)
+	if *replaced.Block.Number != *minted.Block.Number || *replaced.Block.Hash == *minted.Block.Hash {
+		t.Error("expected a different block at the same height")
+	}
+	if len(replaced.Logs) != 1 || *replaced.Logs[0].TxHash != h2 {
+		t.Errorf("unexpected logs %v", replaced.Logs)
+	}
+
+	// the old block is still available by hash
+	var old seth.Block
+	hb, _ := json.Marshal(minted.Block.Hash)
+	if err := client.Do("eth_getBlockByHash", []json.RawMessage{hb, json.RawMessage("false")}, &old); err != nil {
+		t.Error(err)
+	}
+	if err := chain.Reorg(100); err == nil {
+		t.Error("expected an error replacing too many blocks")
+	}
+}
@@ -0,0 +1,61 @@
+package tevm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/newalchemylimited/seth"
+)
+
+// Simulate implements seth.Simulator by mining tx on a copy
+// of the chain, which leaves the chain itself unchanged.
+// Unlike seth.CallSimulator, the simulations it produces
+// include the logs emitted by the transaction.
+//
+// Mine ignores the nonces of transactions, so the nonce
+// of a signed transaction (one whose Hash is set) is
+// checked against the sender's nonce before it is mined.
+func (c *Chain) Simulate(ctx context.Context, tx *seth.Transaction) (*seth.Simulation, error) {
+	c.mu.Lock()
+	cc := c.Copy()
+	c.mu.Unlock()
+
+	if tx.Hash != (seth.Hash{}) {
+		nonce := cc.State.StateDB().GetNonce(common.Address(*tx.From))
+		switch n := uint64(tx.Nonce); {
+		case n < nonce:
+			return &seth.Simulation{Err: fmt.Errorf("nonce too low: tx: %d state: %d", n, nonce)}, nil
+		case n > nonce:
+			return &seth.Simulation{Err: fmt.Errorf("nonce too high: tx: %d state: %d", n, nonce)}, nil
+		}
+	}
+
+	t := *tx
+	ret, _, err := cc.Mine(&t)
+	sim := &seth.Simulation{Return: seth.Data(ret)}
+	if err != nil {
+		sim.Err = callError(ret, err)
+		return sim, nil
+	}
+	if n := len(cc.pendingrx); n > 0 {
+		sim.Logs = cc.pendingrx[n-1].Logs
+	}
+	return sim, nil
+}
+
+// ForkSimulator is a seth.Simulator that simulates each
+// transaction on a fresh fork (see NewFork) of the latest
+// block of the chain backing Client.
+type ForkSimulator struct {
+	Client *seth.Client
+}
+
+// Simulate implements seth.Simulator
+func (f *ForkSimulator) Simulate(ctx context.Context, tx *seth.Transaction) (*seth.Simulation, error) {
+	head, err := f.Client.BlockNumberContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewFork(f.Client, head).Simulate(ctx, tx)
+}
@@ -28,6 +28,8 @@ func client() *seth.Client {
 		client = seth.NewClientTransport(seth.InfuraTransport{})
 	} else if strings.HasPrefix(src, "http") {
 		client = seth.NewClientTransport(&seth.HTTPTransport{URL: src})
+	} else if strings.HasPrefix(src, "ws") {
+		client = seth.NewWSClient(src)
 	} else {
 		if _, err := os.Stat(src); err != nil {
 			log.Fatal(err)
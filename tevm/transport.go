@@ -12,27 +12,42 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/gorilla/websocket"
 	"github.com/newalchemylimited/seth"
 )
 
 type callArgs struct {
-	From     common.Address  `json:"from"`
-	To       *common.Address `json:"to"`
-	Gas      seth.Uint64     `json:"gas"`
-	GasPrice seth.Int        `json:"gasPrice"`
-	Value    seth.Int        `json:"value"`
-	Data     seth.Data       `json:"data"`
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  seth.Uint64     `json:"gas"`
+	GasPrice             seth.Int        `json:"gasPrice"`
+	MaxFeePerGas         *seth.Int       `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *seth.Int       `json:"maxPriorityFeePerGas"`
+	Value                seth.Int        `json:"value"`
+	Data                 seth.Data       `json:"data"`
+	AccessList           seth.AccessList `json:"accessList"`
 }
 
 func (c *callArgs) tx() *seth.Transaction {
-	return &seth.Transaction{
+	tx := &seth.Transaction{
 		From:     (*seth.Address)(&c.From),
 		To:       (*seth.Address)(c.To),
 		Gas:      c.Gas,
 		GasPrice: c.GasPrice,
 		Value:    c.Value,
 		Input:    c.Data,
+
+		MaxFeePerGas:         c.MaxFeePerGas,
+		MaxPriorityFeePerGas: c.MaxPriorityFeePerGas,
+		AccessList:           c.AccessList,
+	}
+	switch {
+	case tx.MaxFeePerGas != nil || tx.MaxPriorityFeePerGas != nil:
+		tx.Type = seth.TxDynamicFee
+	case tx.AccessList != nil:
+		tx.Type = seth.TxAccessList
 	}
+	return tx
 }
 
 type blocknum int64
@@ -50,8 +65,12 @@ func (b *blocknum) UnmarshalJSON(buf []byte) error {
 	case bytes.Equal(rawearliest, buf):
 		*b = 0
 	default:
-		// should be an integer
-		i, err := strconv.ParseInt(string(buf), 10, 64)
+		// should be an integer, or an
+		// integer in a string (like "0x10")
+		if len(buf) >= 2 && buf[0] == '"' {
+			buf = buf[1 : len(buf)-1]
+		}
+		i, err := strconv.ParseInt(string(buf), 0, 64)
 		if err != nil {
 			return err
 		}
@@ -65,18 +84,29 @@ func (a *callArgs) Ref() vm.ContractRef {
 }
 
 // ServeHTTP implements http.Handler.
+// WebSocket upgrade requests are served as a connection
+// over which eth_subscribe is supported in addition to
+// the usual methods.
 func (s *Chain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWS(w, r)
+		return
+	}
 	defer r.Body.Close()
-	var jsr seth.RPCRequest
-	err := json.NewDecoder(r.Body).Decode(&jsr)
+	var body json.RawMessage
+	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
 		log.Printf("decode body error: %s", err)
 		w.WriteHeader(401)
 		return
 	}
-	var res seth.RPCResponse
-	s.Execute(&jsr, &res)
-	err = json.NewEncoder(w).Encode(&res)
+	out, err := s.serveJSON(body)
+	if err != nil {
+		log.Printf("decode body error: %s", err)
+		w.WriteHeader(401)
+		return
+	}
+	err = json.NewEncoder(w).Encode(out)
 	if err != nil {
 		log.Printf("error writing response: %s", err)
 		w.WriteHeader(500)
@@ -84,6 +114,37 @@ func (s *Chain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveJSON executes a request or a batch of
+// requests and returns the response(s) to it
+func (c *Chain) serveJSON(body []byte) (interface{}, error) {
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []seth.RPCRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			return nil, err
+		}
+		res := make([]seth.RPCResponse, len(reqs))
+		c.ExecuteBatch(reqs, res)
+		return res, nil
+	}
+	var req seth.RPCRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	res := new(seth.RPCResponse)
+	c.Execute(&req, res)
+	return res, nil
+}
+
+// ExecuteBatch implements seth.BatchTransport.
+func (c *Chain) ExecuteBatch(reqs []seth.RPCRequest, res []seth.RPCResponse) error {
+	for i := range reqs {
+		if err := c.Execute(&reqs[i], &res[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Execute implements seth.Transport.
 func (c *Chain) Execute(req *seth.RPCRequest, res *seth.RPCResponse) error {
 	res.ID = req.ID
@@ -96,11 +157,21 @@ func (c *Chain) Execute(req *seth.RPCRequest, res *seth.RPCResponse) error {
 	c.mu.Lock()
 	ret, err := c.execute(req.Method, req.Params)
 	c.mu.Unlock()
+	return c.respond(ret, err, res)
+}
+
+// respond fills in res with the result of a request
+func (c *Chain) respond(ret interface{}, err error, res *seth.RPCResponse) error {
 	if err != nil {
 		res.Result = nil
 		res.Error.Code = -32601
 		res.Error.Message = err.Error()
 		res.Error.Data = nil
+		if re, ok := err.(*seth.RevertError); ok {
+			// mimic geth's reporting of reverted calls
+			res.Error.Code = 3
+			res.Error.Data = js(&re.Data)
+		}
 		err = nil
 	} else {
 		err = gross(ret, &res.Result)
@@ -120,6 +191,11 @@ func (c *Chain) execute(method string, params []json.RawMessage) (interface{}, e
 			return nil, err
 		}
 		return seth.Uint64(63), nil
+	case "eth_chainId":
+		if err := marshal(params); err != nil {
+			return nil, err
+		}
+		return seth.Uint64(theparams.ChainID.Uint64()), nil
 	case "eth_syncing":
 		if err := marshal(params); err != nil {
 			return nil, err
@@ -137,16 +213,35 @@ func (c *Chain) execute(method string, params []json.RawMessage) (interface{}, e
 		return c.State.Pending.Number, nil
 	case "eth_call":
 		tx := new(callArgs)
-		if err := marshal(params, tx, &b); err != nil {
+		var state seth.StateOverride
+		var bo *seth.BlockOverrides
+		// the state and block overrides are optional
+		args := []interface{}{tx, &b, &state, &bo}
+		if n := len(params); n >= 2 && n < len(args) {
+			args = args[:n]
+		}
+		if err := marshal(params, args...); err != nil {
 			return nil, err
 		}
-		return c.staticCall(tx, int64(b))
+		return c.staticCall(tx, int64(b), state, bo)
 	case "eth_sendTransaction":
 		a := new(callArgs)
 		if err := marshal(params, a); err != nil {
 			return nil, err
 		}
 		return c.send(a.tx())
+	case "eth_sendRawTransaction":
+		var raw seth.Data
+		if err := marshal(params, &raw); err != nil {
+			return nil, err
+		}
+		return c.sendRaw(raw)
+	case "eth_getTransactionCount":
+		var addr seth.Address
+		if err := marshal(params, &addr, &b); err != nil {
+			return nil, err
+		}
+		return c.nonce(&addr, int64(b))
 	case "eth_getTransactionReceipt":
 		var h seth.Hash
 		if err := marshal(params, &h); err != nil {
@@ -165,6 +260,12 @@ func (c *Chain) execute(method string, params []json.RawMessage) (interface{}, e
 			return nil, err
 		}
 		return c.balance(&addr, int64(b))
+	case "eth_getCode":
+		var addr seth.Address
+		if err := marshal(params, &addr, &b); err != nil {
+			return nil, err
+		}
+		return c.code(&addr, int64(b))
 	case "eth_estimateGas":
 		a := new(callArgs)
 		if err := marshal(params, a, &b); err != nil {
@@ -179,26 +280,43 @@ func (c *Chain) execute(method string, params []json.RawMessage) (interface{}, e
 		}
 		return c.getBlock(&h, all)
 	case "eth_getBlockByNumber":
-		var n seth.Int
 		var all bool
-		if err := marshal(params, &n, &all); err != nil {
+		if err := marshal(params, &b, &all); err != nil {
 			return nil, err
 		}
-		// hack: block hashes are hashes of the block number
-		h := seth.Hash(n2h(uint64(n.Int64())))
+		h := c.blockHash(int64(c.resolve(b)))
 		return c.getBlock(&h, all)
 	case "eth_newFilter":
 		type newFilterReq struct {
-			FromBlock blocknum      `json:"fromBlock,omitempty"`
-			ToBlock   blocknum      `json:"toBlock,omitempty"`
-			Address   *seth.Address `json:"address,omitempty"`
-			Topics    []*seth.Hash  `json:"topics,omitempty"`
+			FromBlock blocknum         `json:"fromBlock,omitempty"`
+			ToBlock   blocknum         `json:"toBlock,omitempty"`
+			Address   seth.AddressList `json:"address,omitempty"`
+			Topics    seth.TopicList   `json:"topics,omitempty"`
 		}
 		req := new(newFilterReq)
 		if err := marshal(params, req); err != nil {
 			return nil, err
 		}
 		return c.newFilter(req.FromBlock, req.ToBlock, req.Address, req.Topics)
+	case "eth_getLogs":
+		type getLogsReq struct {
+			BlockHash *seth.Hash       `json:"blockHash,omitempty"`
+			FromBlock blocknum         `json:"fromBlock,omitempty"`
+			ToBlock   blocknum         `json:"toBlock,omitempty"`
+			Address   seth.AddressList `json:"address,omitempty"`
+			Topics    seth.TopicList   `json:"topics,omitempty"`
+		}
+		// the range defaults to the latest block
+		req := &getLogsReq{FromBlock: -2, ToBlock: -2}
+		if err := marshal(params, req); err != nil {
+			return nil, err
+		}
+		return c.getLogs(&filter{
+			from:   req.FromBlock,
+			to:     req.ToBlock,
+			addrs:  req.Address,
+			topics: req.Topics,
+		}, req.BlockHash)
 	case "eth_getFilterChanges":
 		var n seth.Int
 		if err := marshal(params, &n); err != nil {
@@ -222,7 +340,12 @@ func (c *Chain) execute(method string, params []json.RawMessage) (interface{}, e
 	}
 }
 
-func (c *Chain) newFilter(from, to blocknum, addr *seth.Address, topics []*seth.Hash) (int, error) {
+// errFilterNotFound is the same
+// error that geth returns for a
+// filter that doesn't exist
+var errFilterNotFound = errors.New("filter not found")
+
+func (c *Chain) newFilter(from, to blocknum, addrs seth.AddressList, topics seth.TopicList) (int, error) {
 	if from > to {
 		return 0, fmt.Errorf("cannot filter block range [%d,%d)", from, to)
 	}
@@ -233,7 +356,7 @@ func (c *Chain) newFilter(from, to blocknum, addr *seth.Address, topics []*seth.
 	c.filters[c.filtcount] = &filter{
 		from:   from,
 		to:     to,
-		addr:   addr,
+		addrs:  addrs,
 		topics: topics,
 	}
 	return c.filtcount, nil
@@ -243,11 +366,11 @@ func (c *Chain) filterLogs(fd int) ([]seth.Log, error) {
 	// unlike filterChanges, this is supposed
 	// to yield every matching entry to the filter
 	if c.filters == nil {
-		return nil, fmt.Errorf("bad filter id %d", fd)
+		return nil, errFilterNotFound
 	}
 	filt, ok := c.filters[fd]
 	if !ok {
-		return nil, fmt.Errorf("bad filter id %d", fd)
+		return nil, errFilterNotFound
 	}
 
 	out := make([]seth.Log, 0)
@@ -263,14 +386,15 @@ func (c *Chain) filterLogs(fd int) ([]seth.Log, error) {
 
 func (c *Chain) filterChanges(fd int) ([]seth.Log, error) {
 	if c.filters == nil {
-		return nil, fmt.Errorf("bad filter id %d", fd)
+		return nil, errFilterNotFound
 	}
 	filt, ok := c.filters[fd]
 	if !ok {
-		return nil, fmt.Errorf("bad filter id %d", fd)
+		return nil, errFilterNotFound
 	}
 
-	out := make([]seth.Log, 0)
+	out := append(make([]seth.Log, 0), filt.removed...)
+	filt.removed = nil
 	sub := c.State.Logs[filt.lastlog:]
 	for i := range sub {
 		if filt.matches(sub[i]) {
@@ -283,19 +407,64 @@ func (c *Chain) filterChanges(fd int) ([]seth.Log, error) {
 	return out, nil
 }
 
+// resolve turns "latest" and "pending" into block numbers
+func (c *Chain) resolve(b blocknum) blocknum {
+	switch b {
+	case -1:
+		return blocknum(*c.State.Pending.Number)
+	case -2:
+		return blocknum(*c.State.Pending.Number) - 1
+	}
+	return b
+}
+
+// getLogs handles eth_getLogs.
+func (c *Chain) getLogs(f *filter, blockhash *seth.Hash) ([]seth.Log, error) {
+	if blockhash != nil {
+		b, err := c.getBlock(blockhash, false)
+		if err != nil {
+			return nil, err
+		}
+		if c.blockHash(int64(*b.Number)) != *blockhash {
+			return nil, fmt.Errorf("block %s is not canonical", blockhash)
+		}
+		f.from, f.to = blocknum(*b.Number), blocknum(*b.Number)
+	} else {
+		f.from, f.to = c.resolve(f.from), c.resolve(f.to)
+	}
+	out := make([]seth.Log, 0)
+	for _, l := range c.State.Logs {
+		if f.matches(l) {
+			var next seth.Log
+			l2l(l, &next)
+			out = append(out, next)
+		}
+	}
+	return out, nil
+}
+
 func (c *Chain) deleteFilter(fd int) (bool, error) {
 	l := len(c.filters)
 	delete(c.filters, fd)
 	return len(c.filters) != l, nil
 }
 
-// staticCall handles eth_call.
-func (c *Chain) staticCall(a *callArgs, blocknum int64) (seth.Data, error) {
+// staticCall handles eth_call. State and block overrides,
+// if any, are applied to a throwaway copy of the chain.
+func (c *Chain) staticCall(a *callArgs, blocknum int64, state seth.StateOverride, bo *seth.BlockOverrides) (seth.Data, error) {
 	c = c.AtBlock(blocknum)
 	if c == nil {
 		return nil, fmt.Errorf("unknown block number %d", blocknum)
 	}
-	evm := c.evm(a.From)
+	db := c.State.StateDB()
+	if state != nil || bo != nil {
+		c = c.Copy()
+		var err error
+		if db, err = c.override(state, bo); err != nil {
+			return nil, err
+		}
+	}
+	evm := vm.NewEVM(c.context(a.From), db, &theparams, theconfig)
 	gas := uint64(c.State.Pending.GasLimit)
 	if a.Gas != 0 {
 		gas = uint64(a.Gas)
@@ -306,7 +475,7 @@ func (c *Chain) staticCall(a *callArgs, blocknum int64) (seth.Data, error) {
 	}
 	ret, _, err := evm.StaticCall(a.Ref(), to, a.Data, gas)
 	if err != nil {
-		return nil, err
+		return nil, callError(ret, err)
 	}
 	return seth.Data(ret), nil
 }
@@ -363,6 +532,56 @@ func (c *Chain) send(a *seth.Transaction) (*seth.Hash, error) {
 	return &h, nil
 }
 
+// sendRaw handles eth_sendRawTransaction. Like a node,
+// the chain holds on to transactions with nonces from
+// the future until the gap before them has been filled.
+func (c *Chain) sendRaw(raw []byte) (*seth.Hash, error) {
+	tx, _, err := seth.ParseRawTransaction(raw)
+	if err != nil {
+		return nil, err
+	}
+	h := tx.Hash
+	q := c.queued[*tx.From]
+	if c.State.Transactions.Get(h[:]) != nil || (q[uint64(tx.Nonce)] != nil && q[uint64(tx.Nonce)].Hash == h) {
+		return nil, fmt.Errorf("known transaction: %x", h[:])
+	}
+	nonce := c.State.StateDB().GetNonce(common.Address(*tx.From))
+	switch n := uint64(tx.Nonce); {
+	case n < nonce:
+		return nil, fmt.Errorf("nonce too low: address %s, tx: %d state: %d", tx.From.String(), n, nonce)
+	case n > nonce:
+		if q == nil {
+			if c.queued == nil {
+				c.queued = make(map[seth.Address]map[uint64]*seth.Transaction)
+			}
+			q = make(map[uint64]*seth.Transaction)
+			c.queued[*tx.From] = q
+		}
+		q[n] = tx
+		return &h, nil
+	}
+	for tx != nil {
+		// unlike eth_sendTransaction, the transaction
+		// is accepted even if it fails
+		c.Mine(tx)
+		c.Seal()
+		tx = q[uint64(tx.Nonce)+1]
+		if tx != nil {
+			delete(q, uint64(tx.Nonce))
+		}
+	}
+	return &h, nil
+}
+
+// nonce handles eth_getTransactionCount.
+func (c *Chain) nonce(addr *seth.Address, block int64) (seth.Uint64, error) {
+	c = c.AtBlock(block)
+	if c == nil {
+		return 0, fmt.Errorf("unknown block number %d", block)
+	}
+	return seth.Uint64(c.State.StateDB().GetNonce(common.Address(*addr))), nil
+}
+
 // receipt handles eth_getTransactionReceipt.
 func (c *Chain) receipt(h seth.Hash) (*seth.Receipt, error) {
 	b := c.State.Receipts.Get(h[:])
@@ -400,6 +619,15 @@ func (c *Chain) balance(addr *seth.Address, block int64) (*seth.Int, error) {
 	return (*seth.Int)(c.balanceOf(addr)), nil
 }
 
+// code handles eth_getCode.
+func (c *Chain) code(addr *seth.Address, block int64) (seth.Data, error) {
+	c = c.AtBlock(block)
+	if c == nil {
+		return nil, fmt.Errorf("unknown block number %d", block)
+	}
+	return seth.Data(c.State.StateDB().GetCode(common.Address(*addr))), nil
+}
+
 // estimate handles eth_estimateGas.
 func (c *Chain) estimate(a *callArgs, blocknum int64) (seth.Uint64, error) {
 	c = c.AtBlock(blocknum)
@@ -424,9 +652,9 @@ func (c *Chain) estimate(a *callArgs, blocknum int64) (seth.Uint64, error) {
 		}
 		gas -= rem
 	} else {
-		_, rem, err := evm.Call(a.Ref(), *a.To, a.Data, gas, a.Value.Big())
+		ret, rem, err := evm.Call(a.Ref(), *a.To, a.Data, gas, a.Value.Big())
 		if err != nil {
-			return 0, err
+			return 0, callError(ret, err)
 		}
 		gas -= rem
 	}
@@ -0,0 +1,239 @@
+package tevm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/newalchemylimited/seth"
+)
+
+func TestBatch(t *testing.T) {
+	chain := NewChain()
+	a, b := chain.NewAccount(1), chain.NewAccount(2)
+
+	srv := httptest.NewServer(chain)
+	defer srv.Close()
+	clients := map[string]*seth.Client{
+		"direct": seth.NewClientTransport(chain),
+		"http":   seth.NewHTTPClient(srv.URL),
+		"ws":     seth.NewWSClient("ws" + strings.TrimPrefix(srv.URL, "http")),
+	}
+	for name, client := range clients {
+		var balances [2]seth.Int
+		var out json.RawMessage
+		elems := []seth.BatchElem{
+			{Method: "eth_getBalance", Params: []json.RawMessage{js(&a), json.RawMessage(`"latest"`)}, Result: &balances[0]},
+			{Method: "eth_getBalance", Params: []json.RawMessage{js(&b), json.RawMessage(`"latest"`)}, Result: &balances[1]},
+			{Method: "eth_bogus", Result: &out},
+		}
+		if err := client.Batch(elems); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		for i := range balances {
+			if elems[i].Error != nil {
+				t.Errorf("%s: %s", name, elems[i].Error)
+			}
+			want := new(big.Int).Mul(big.NewInt(int64(i+1)), big.NewInt(1e18))
+			if balances[i].Big().Cmp(want) != 0 {
+				t.Errorf("%s: balance %d is %s", name, i, balances[i].String())
+			}
+		}
+		if elems[2].Error == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// probecode returns sload(0), block.number,
+// block.timestamp, and address(this).balance
+var probecode = seth.Data{
+	0x60, 0x00, 0x54, 0x60, 0x00, 0x52, // mstore(0, sload(0))
+	0x43, 0x60, 0x20, 0x52, // mstore(0x20, number)
+	0x42, 0x60, 0x40, 0x52, // mstore(0x40, timestamp)
+	0x30, 0x31, 0x60, 0x60, 0x52, // mstore(0x60, balance(address))
+	0x60, 0x80, 0x60, 0x00, 0xf3, // return(0, 0x80)
+}
+
+func TestCallOverride(t *testing.T) {
+	chain := NewChain()
+	me := chain.NewAccount(1)
+	client := chain.Client()
+	probe := seth.Address{0xaa}
+	opts := &seth.CallOpts{From: &me, To: &probe}
+
+	var slot, num, ts, bal big.Int
+	n, when := seth.Uint64(1234), seth.Uint64(99)
+	state := seth.StateOverride{probe: {
+		Balance: seth.NewInt(5),
+		Code:    &probecode,
+		State:   map[seth.Hash]seth.Hash{{}: {31: 7}},
+	}}
+	bo := &seth.BlockOverrides{Number: &n, Timestamp: &when}
+	out := seth.NewABIDecoder(&slot, &num, &ts, &bal)
+	if err := client.ConstCallOverride(opts, out, seth.Latest, state, bo); err != nil {
+		t.Fatal(err)
+	}
+	if slot.Int64() != 7 || num.Int64() != 1234 || ts.Int64() != 99 || bal.Int64() != 5 {
+		t.Errorf("got sload %s, number %s, timestamp %s, balance %s", &slot, &num, &ts, &bal)
+	}
+
+	// the overrides must not leak into the chain
+	if code, err := client.GetCode(&probe); err != nil || len(code) != 0 {
+		t.Errorf("code %x after an overridden call (%v)", code, err)
+	}
+
+	// an empty 'state' clears the account's storage
+	stored := seth.Address{0xcc}
+	db := chain.State.StateDB()
+	db.CreateAccount(common.Address(stored))
+	db.SetCode(common.Address(stored), probecode)
+	db.SetState(common.Address(stored), common.Hash{}, common.Hash{31: 3})
+	opts.To = &stored
+	if err := client.ConstCallAt(opts, out, seth.Latest); err != nil || slot.Int64() != 3 {
+		t.Fatalf("sload is %s (%v); want 3", &slot, err)
+	}
+	state = seth.StateOverride{stored: {State: map[seth.Hash]seth.Hash{}}}
+	if err := client.ConstCallOverride(opts, out, seth.Latest, state, nil); err != nil || slot.Int64() != 0 {
+		t.Errorf("sload is %s (%v) with empty state; want 0", &slot, err)
+	}
+
+	state = seth.StateOverride{probe: {
+		Code:      &probecode,
+		State:     map[seth.Hash]seth.Hash{},
+		StateDiff: map[seth.Hash]seth.Hash{},
+	}}
+	opts.To = &probe
+	if err := client.ConstCallOverride(opts, out, seth.Latest, state, nil); err == nil {
+		t.Error("expected an error for both state and stateDiff")
+	}
+
+	// overridden code can call other overridden accounts
+	caller := seth.Address{0xbb}
+	state = seth.StateOverride{
+		caller: {Code: callcode(&probe)},
+		probe: {
+			Code:  &probecode,
+			State: map[seth.Hash]seth.Hash{{}: {31: 9}},
+		},
+	}
+	opts.To = &caller
+	var word seth.Data
+	if err := client.ConstCallOverride(opts, &word, seth.Latest, state, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(word) != 32 || word[31] != 9 {
+		t.Errorf("got %x from the inner call", word)
+	}
+}
+
+// callcode returns code that calls 'to' and
+// returns the first word of the return data
+func callcode(to *seth.Address) *seth.Data {
+	code := seth.Data{
+		0x60, 0x20, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, // retSize, retOffset, argsSize, argsOffset, value
+		0x73, // push20 to
+	}
+	code = append(code, to[:]...)
+	code = append(code,
+		0x5a, 0xf1, 0x50, // pop(call(gas, ...))
+		0x60, 0x20, 0x60, 0x00, 0xf3, // return(0, 0x20)
+	)
+	return &code
+}
+
+func TestNonceManager(t *testing.T) {
+	chain := NewChain()
+	client := chain.Client()
+	nonces := seth.NewNonceManager(client)
+	to := chain.NewAccount(0)
+
+	// senders from two addresses share the nonce manager
+	senders := make([]*seth.Sender, 2)
+	for i := range senders {
+		key := seth.GenPrivateKey()
+		chain.AddBalance(key.Address(), big.NewInt(1e18))
+		s := seth.NewSender(client, key.Address())
+		s.Signer = key.Signer()
+		s.Nonces = nonces
+		senders[i] = s
+	}
+
+	const workers = 10
+	hashes := make([]seth.Hash, workers*len(senders))
+	send := func() {
+		var wg sync.WaitGroup
+		for i := range hashes {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				var err error
+				hashes[i], err = senders[i%len(senders)].Call(&seth.CallOpts{To: &to, Value: seth.NewInt(1)})
+				if err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+	check := func(nonce int64, sent int) {
+		t.Helper()
+		for _, s := range senders {
+			if n, err := client.GetNonce(s.Addr); err != nil || n != nonce {
+				t.Fatalf("nonce is %d (%v); want %d", n, err, nonce)
+			}
+		}
+		if bal := chain.BalanceOf(&to); bal.Int64() != int64(sent) {
+			t.Errorf("balance is %s; want %d", bal, sent)
+		}
+		type use struct {
+			from  seth.Address
+			nonce seth.Uint64
+		}
+		seen := make(map[use]bool)
+		for i := range hashes {
+			tx, err := client.GetTransaction(&hashes[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			key := use{*tx.From, tx.Nonce}
+			if tx.TxIndex == nil || seen[key] {
+				t.Errorf("transaction %d with nonce %d mined twice or not at all", i, tx.Nonce)
+			}
+			seen[key] = true
+		}
+	}
+	send()
+	check(workers, len(hashes))
+
+	// if a transaction is dropped, the transactions
+	// after it are queued until the gap is filled
+	for _, s := range senders {
+		n, err := nonces.Next(context.Background(), s.Addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces.Done(s.Addr, n, nil)
+	}
+	send()
+	if n, err := client.GetNonce(senders[0].Addr); err != nil || n != workers {
+		t.Fatalf("nonce is %d (%v) with a gap", n, err)
+	}
+	nonces.GapTimeout = time.Millisecond
+	time.Sleep(10 * time.Millisecond)
+	send()
+	check(3*workers, 3*len(hashes))
+
+	// a nonce that was already used is rejected
+	zero := seth.Uint64(0)
+	_, err := senders[0].Call(&seth.CallOpts{To: &to, Nonce: &zero})
+	if !seth.IsNonceError(err) {
+		t.Errorf("expected a nonce error; got %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package tevm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gorilla/websocket"
+	"github.com/newalchemylimited/seth"
+)
+
+// wsQueue is the number of messages that can be queued
+// for a websocket connection before it is dropped
+const wsQueue = 256
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// subscription is an eth_subscribe subscription
+type subscription struct {
+	kind string
+	conn *wsConn
+	filt *filter // for "logs"
+}
+
+// wsConn is a websocket connection to a Chain
+type wsConn struct {
+	ws   *websocket.Conn
+	out  chan interface{}
+	done chan struct{}
+	once sync.Once
+}
+
+type wsNotification struct {
+	Version string   `json:"jsonrpc"`
+	Method  string   `json:"method"`
+	Params  wsParams `json:"params"`
+}
+
+type wsParams struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// send queues a message to be written, and drops
+// the connection if the client isn't keeping up
+func (w *wsConn) send(v interface{}) {
+	select {
+	case <-w.done:
+	case w.out <- v:
+	default:
+		w.close()
+	}
+}
+
+func (w *wsConn) notify(id string, v interface{}) {
+	w.send(&wsNotification{
+		Version: "2.0",
+		Method:  "eth_subscription",
+		Params:  wsParams{Subscription: id, Result: js(v)},
+	})
+}
+
+func (w *wsConn) writer() {
+	for {
+		select {
+		case v := <-w.out:
+			if err := w.ws.WriteJSON(v); err != nil {
+				w.close()
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *wsConn) close() {
+	w.once.Do(func() {
+		close(w.done)
+		w.ws.Close()
+	})
+}
+
+func (c *Chain) serveWS(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("websocket upgrade error: %s", err)
+		return
+	}
+	conn := &wsConn{
+		ws:   ws,
+		out:  make(chan interface{}, wsQueue),
+		done: make(chan struct{}),
+	}
+	go conn.writer()
+	defer func() {
+		conn.close()
+		c.mu.Lock()
+		for id, s := range c.subs {
+			if s.conn == conn {
+				delete(c.subs, id)
+			}
+		}
+		c.mu.Unlock()
+	}()
+
+	for {
+		_, buf, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		if len(buf) > 0 && buf[0] == '[' {
+			// subscriptions aren't supported in batches
+			res, err := c.serveJSON(buf)
+			if err != nil {
+				return
+			}
+			conn.send(res)
+			continue
+		}
+		var req seth.RPCRequest
+		if err := json.Unmarshal(buf, &req); err != nil {
+			return
+		}
+		var res seth.RPCResponse
+		if req.Method != "eth_subscribe" && req.Method != "eth_unsubscribe" {
+			c.Execute(&req, &res)
+			conn.send(&res)
+			continue
+		}
+		res.ID = req.ID
+		res.Version = req.Version
+		// respond while holding the lock so that the
+		// response to eth_subscribe precedes any notifications
+		c.mu.Lock()
+		ret, err := c.subscribe(conn, req.Method, req.Params)
+		c.respond(ret, err, &res)
+		conn.send(&res)
+		c.mu.Unlock()
+	}
+}
+
+// subscribe handles eth_subscribe and eth_unsubscribe.
+func (c *Chain) subscribe(conn *wsConn, method string, params []json.RawMessage) (interface{}, error) {
+	if method == "eth_unsubscribe" {
+		var id string
+		if err := marshal(params, &id); err != nil {
+			return nil, err
+		}
+		s, ok := c.subs[id]
+		if !ok || s.conn != conn {
+			return false, nil
+		}
+		delete(c.subs, id)
+		return true, nil
+	}
+
+	if len(params) == 0 {
+		return nil, errors.New("eth_subscribe: missing subscription kind")
+	}
+	s := &subscription{conn: conn}
+	if err := json.Unmarshal(params[0], &s.kind); err != nil {
+		return nil, err
+	}
+	switch s.kind {
+	case "newHeads", "newPendingTransactions":
+		if len(params) != 1 {
+			return nil, fmt.Errorf("%s: unexpected params", s.kind)
+		}
+	case "logs":
+		var q struct {
+			Address seth.AddressList `json:"address"`
+			Topics  seth.TopicList   `json:"topics"`
+		}
+		if len(params) > 2 {
+			return nil, fmt.Errorf("%s: unexpected params", s.kind)
+		}
+		if len(params) == 2 {
+			if err := json.Unmarshal(params[1], &q); err != nil {
+				return nil, err
+			}
+		}
+		s.filt = &filter{from: -1, to: -1, addrs: q.Address, topics: q.Topics}
+	default:
+		return nil, fmt.Errorf("unsupported subscription %q", s.kind)
+	}
+	c.subcount++
+	id := "0x" + strconv.FormatInt(int64(c.subcount), 16)
+	if c.subs == nil {
+		c.subs = make(map[string]*subscription)
+	}
+	c.subs[id] = s
+	return id, nil
+}
+
+// notifyPending notifies newPendingTransactions
+// subscribers of a new transaction.
+func (c *Chain) notifyPending(h *seth.Hash) {
+	for id, s := range c.subs {
+		if s.kind == "newPendingTransactions" {
+			s.conn.notify(id, h)
+		}
+	}
+}
+
+// notifySealed notifies newHeads and logs
+// subscribers of a newly-sealed block.
+func (c *Chain) notifySealed(b *seth.Block) {
+	if len(c.subs) == 0 {
+		return
+	}
+	// the logs from this block are
+	// at the end of the list of logs
+	i := len(c.State.Logs)
+	for i > 0 && c.State.Logs[i-1].BlockNumber == uint64(*b.Number) {
+		i--
+	}
+	logs := c.State.Logs[i:]
+	head := *b
+	head.Transactions = nil
+	for id, s := range c.subs {
+		switch s.kind {
+		case "newHeads":
+			s.conn.notify(id, &head)
+		case "logs":
+			for _, l := range logs {
+				if s.filt.matches(l) {
+					var sl seth.Log
+					l2l(l, &sl)
+					s.conn.notify(id, &sl)
+				}
+			}
+		}
+	}
+}
+
+// notifyRemoved notifies logs subscribers
+// of logs that were removed by a Reorg.
+func (c *Chain) notifyRemoved(logs []*types.Log) {
+	for id, s := range c.subs {
+		if s.kind != "logs" {
+			continue
+		}
+		for _, l := range logs {
+			if s.filt.matches(l) {
+				var sl seth.Log
+				l2l(l, &sl)
+				s.conn.notify(id, &sl)
+			}
+		}
+	}
+}
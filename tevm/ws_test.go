@@ -0,0 +1,96 @@
+package tevm
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newalchemylimited/seth"
+)
+
+func TestSubscribe(t *testing.T) {
+	bundle, err := seth.CompileGlob("./erc20/*.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := NewChain()
+	acct := chain.NewAccount(1)
+
+	srv := httptest.NewServer(chain)
+	defer srv.Close()
+	client := seth.NewWSClient("ws" + strings.TrimPrefix(srv.URL, "http"))
+	sender := seth.NewSender(client, &acct)
+
+	token, err := sender.Create(bundle.Contract("TokenERC20").Code, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	heads, err := client.Subscribe("newHeads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer heads.Close()
+	pending, err := client.Subscribe("newPendingTransactions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pending.Close()
+	logs, err := client.Subscribe("logs", map[string]interface{}{
+		"address": &token,
+		"topics":  []*seth.Hash{&seth.ERC20Transfer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logs.Close()
+	if _, err := client.Subscribe("bogus"); err == nil {
+		t.Error("expected an error for an unknown subscription kind")
+	}
+
+	next := func(s *seth.Subscription, v interface{}) {
+		t.Helper()
+		select {
+		case msg, ok := <-s.Out():
+			if !ok {
+				t.Fatalf("subscription closed: %v", s.Err())
+			}
+			if err := json.Unmarshal(msg, v); err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a notification")
+		}
+	}
+
+	h, err := sender.Send(&token, "mint(address,uint256)", &acct, seth.NewInt(10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var txh seth.Hash
+	next(pending, &txh)
+	if txh != h {
+		t.Errorf("pending tx %s; want %s", &txh, &h)
+	}
+	var l seth.Log
+	next(logs, &l)
+	if !bytes.Equal(l.Topics[0], seth.ERC20Transfer[:]) || l.Address != token {
+		t.Errorf("unexpected log %+v", &l)
+	}
+	if l.TxHash == nil || *l.TxHash != h {
+		t.Errorf("log has tx hash %v; want %s", l.TxHash, &h)
+	}
+	var b seth.Block
+	next(heads, &b)
+	if b.Number == nil || l.BlockNumber == nil || *b.Number != *l.BlockNumber {
+		t.Errorf("head %v; log block %v", b.Number, l.BlockNumber)
+	}
+	if b.Hash == nil || l.BlockHash == nil || *b.Hash != *l.BlockHash {
+		t.Errorf("head hash %v; log block hash %v", b.Hash, l.BlockHash)
+	}
+}
@@ -1,7 +1,11 @@
 package seth
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/newalchemylimited/seth/rlp"
 )
 
 // Transaction represents an ethereum transaction.
@@ -17,143 +21,345 @@ type Transaction struct {
 	GasPrice    Int      `json:"gasPrice"`         // gas price
 	Gas         Uint64   `json:"gas"`              // gas spent on transaction
 	Input       Data     `json:"input"`            // input data
-}
 
-// Encode returns an RLP encoded representation of the transaction. If a
-// signature is provided, this will return an encoded representation containing
-// the signature.
-func (t *Transaction) Encode(sig *Signature) []byte {
-	var e rlpEncoder
-	if sig == nil {
-		e.EncodeTransaction(t)
-	} else {
-		e.EncodeSignedTx(t, sig)
-	}
-	return e.Bytes()
+	// Typed (EIP-2718) transaction fields
+	Type                 Uint64     `json:"type"`                           // one of TxLegacy, TxAccessList, or TxDynamicFee
+	ChainID              *Uint64    `json:"chainId,omitempty"`              // chain ID, or nil for mainnet (see SetChainID)
+	MaxFeePerGas         *Int       `json:"maxFeePerGas,omitempty"`         // EIP-1559 fee cap
+	MaxPriorityFeePerGas *Int       `json:"maxPriorityFeePerGas,omitempty"` // EIP-1559 tip cap
+	AccessList           AccessList `json:"accessList,omitempty"`           // EIP-2930 access list
 }
 
-// HashToSign returns a hash which can be used to sign the transaction.
-func (t *Transaction) HashToSign() *Hash {
-	var data, res rlpEncoder
-
-	data.EncodeTransaction(t)
-	data.EncodeInt(1)
-	data.EncodeInt(0)
-	data.EncodeInt(0)
+// Transaction types
+const (
+	TxLegacy     = 0 // legacy (pre-EIP-2718) transaction
+	TxAccessList = 1 // EIP-2930 transaction with an access list
+	TxDynamicFee = 2 // EIP-1559 transaction
+)
 
-	res.EncodeList(data.Bytes())
+// AccessTuple is an entry in an EIP-2930 access list.
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
+}
 
-	hash := HashBytes(res.Bytes())
+// AccessList is an EIP-2930 access list.
+type AccessList []AccessTuple
 
-	return &hash
+// SetChainID sets the chain ID of the transaction. Legacy transactions
+// with a chain ID of zero are signed without replay protection
+// (i.e. as they were before EIP-155); typed transactions always
+// include the chain ID in their signature.
+func (t *Transaction) SetChainID(id uint64) {
+	c := Uint64(id)
+	t.ChainID = &c
 }
 
-// An rlpEncoder is a byte buffer that can RLP encode values.
-type rlpEncoder bytes.Buffer
+// chainID returns the chain ID of the transaction
+func (t *Transaction) chainID() uint64 {
+	if t.ChainID == nil {
+		return 1
+	}
+	return uint64(*t.ChainID)
+}
 
-// Write proxies to bytes.Buffer.Write.
-func (e *rlpEncoder) Write(b []byte) (n int, err error) {
-	return (*bytes.Buffer)(e).Write(b)
+// DecodeSignature converts the v, r, and s values of a
+// signed transaction into a Signature. For legacy transactions,
+// the chain ID is derived from v as described in EIP-155 and
+// stored in t.ChainID. For typed transactions, v is the y-parity
+// of the signature, and t.ChainID is left unchanged.
+func (t *Transaction) DecodeSignature(v, r, s *big.Int) (*Signature, error) {
+	if !v.IsUint64() {
+		return nil, fmt.Errorf("invalid signature v value %s", v)
+	}
+	n := v.Uint64()
+	legacy := !t.typed()
+	var id uint64
+	switch {
+	case !legacy:
+		if n > 1 {
+			return nil, fmt.Errorf("invalid y-parity %d for type %d transaction", n, t.Type)
+		}
+	case n == 27 || n == 28:
+		n -= 27
+	case n >= 35:
+		id, n = (n-35)/2, (n-35)%2
+	default:
+		return nil, fmt.Errorf("invalid signature v value %d", n)
+	}
+	sig := NewSignature(r, s, int(n))
+	if !sig.Valid() {
+		return nil, errors.New("invalid signature")
+	}
+	if legacy {
+		t.SetChainID(id)
+	}
+	return sig, nil
 }
 
-// Bytes proxies to bytes.Buffer.Bytes.
-func (e *rlpEncoder) Bytes() []byte {
-	return (*bytes.Buffer)(e).Bytes()
+// Sender recovers the address of the account
+// that signed the transaction with sig.
+func (t *Transaction) Sender(sig *Signature) (*Address, error) {
+	pub, err := sig.Recover(t.HashToSign())
+	if err != nil {
+		return nil, err
+	}
+	return pub.Address(), nil
 }
 
-func intBytes(n uint64) []byte {
-	var buf bytes.Buffer
-	shift := uint64(0)
+// typed returns whether or not t is an EIP-2718 typed transaction
+func (t *Transaction) typed() bool {
+	return t.Type != TxLegacy
+}
 
-	for (n >> shift) > 0xFF {
-		shift += 8
+// Encode returns an RLP encoded representation of the transaction. If a
+// signature is provided, this will return an encoded representation containing
+// the signature. Encode panics if the transaction has a negative value
+// or fee; use SignTransaction to have that reported as an error.
+func (t *Transaction) Encode(sig *Signature) []byte {
+	b, err := t.encode(sig)
+	if err != nil {
+		panic("seth: Transaction.Encode: " + err.Error())
 	}
+	return b
+}
 
-	for shift > 0 {
-		buf.Write([]byte{byte(n >> shift)})
-		shift -= 8
+// encode encodes the transaction with an optional signature.
+func (t *Transaction) encode(sig *Signature) ([]byte, error) {
+	fields := t.fields()
+	if sig != nil {
+		r, s, v := sig.Parts()
+		fields = append(fields, t.v(v), &r, &s)
+	}
+	b, err := rlp.Encode(fields)
+	if err != nil {
+		return nil, err
+	}
+	if sig != nil && t.typed() {
+		b = append([]byte{byte(t.Type)}, b...)
 	}
-	buf.Write([]byte{byte(n)})
-	return buf.Bytes()
+	return b, nil
 }
 
-// EncodeInt encodes an int.
-func (e *rlpEncoder) EncodeInt(n uint64) {
-	if n == 0 {
-		e.Write([]byte{0x80})
+// fields returns the unsigned fields of the transaction in the
+// order in which they are encoded. For typed transactions,
+// these are the fields of the payload.
+func (t *Transaction) fields() []interface{} {
+	var f []interface{}
+	if t.typed() {
+		f = append(f, t.chainID())
+	}
+	f = append(f, t.Nonce)
+	if t.Type == TxDynamicFee {
+		f = append(f, t.MaxPriorityFeePerGas, t.MaxFeePerGas)
 	} else {
-		e.EncodeBytes(intBytes(n), 0x80, 0xB7)
+		f = append(f, &t.GasPrice)
+	}
+	f = append(f, t.Gas, t.To, &t.Value, []byte(t.Input))
+	if t.typed() {
+		f = append(f, t.AccessList)
 	}
+	return f
 }
 
-// EncodeString encodes a string.
-func (e *rlpEncoder) EncodeString(b []byte) {
-	if len(b) == 0 {
-		e.Write([]byte{0x80})
-	} else if bytes.Equal(b, []byte{0x00}) {
-		e.Write([]byte{0x00})
-	} else {
-		e.EncodeBytes(b, 0x80, 0xB7)
+// v returns the signature v value of the
+// transaction given the signature recovery id
+func (t *Transaction) v(recid int) uint64 {
+	switch id := t.chainID(); {
+	case t.typed():
+		// typed transactions use the y-parity
+		return uint64(recid)
+	case id != 0:
+		return uint64(recid) + 35 + 2*id
+	default:
+		return uint64(recid) + 27
 	}
 }
 
-// EncodeList encodes a list of bytes.
-func (e *rlpEncoder) EncodeList(b []byte) {
-	if len(b) == 1 && b[0] == 0x00 {
-		e.Write([]byte{0x80})
-	} else {
-		e.EncodeBytes(b, 0xC0, 0xF7)
+// HashToSign returns a hash which can be used to sign the transaction.
+// For legacy transactions, this is the EIP-155 signing hash (unless
+// the chain ID is zero), and for typed transactions, it is the hash
+// of the type and the payload.
+func (t *Transaction) HashToSign() *Hash {
+	fields := t.fields()
+	if id := t.chainID(); !t.typed() && id != 0 {
+		fields = append(fields, id, uint(0), uint(0))
+	}
+	b, err := rlp.Encode(fields)
+	if err != nil {
+		panic("seth: Transaction.HashToSign: " + err.Error())
 	}
+	if t.typed() {
+		b = append([]byte{byte(t.Type)}, b...)
+	}
+	hash := HashBytes(b)
+	return &hash
 }
 
-// EncodeBytes encodes bytes using sh or lh as headers depending on len(b).
-func (e *rlpEncoder) EncodeBytes(b []byte, sh byte, lh byte) {
-	if len(b) == 1 && b[0] < 0x80 {
-		e.Write(b)
-		return
+// check returns an error if the transaction can't be
+// signed, so that HashToSign and Encode won't panic
+func (t *Transaction) check() error {
+	if t.Type > TxDynamicFee {
+		return fmt.Errorf("unsupported transaction type %d", t.Type)
 	}
-
-	if len(b) < 56 {
-		e.Write([]byte{byte(int(sh) + len(b))})
-	} else {
-		blen := intBytes(uint64(len(b)))
-		e.Write([]byte{byte(int(lh) + len(blen))})
-		e.Write(blen)
+	if t.typed() && t.chainID() == 0 {
+		return fmt.Errorf("type %d transaction without a chain ID", t.Type)
 	}
-
-	e.Write(b)
+	_, err := rlp.Encode(t.fields())
+	return err
 }
 
-// EncodeTransaction encodes the given transaction.
-func (e *rlpEncoder) EncodeTransaction(t *Transaction) {
-	e.EncodeInt(uint64(t.Nonce))
-	e.EncodeString(t.GasPrice.Big().Bytes())
-	e.EncodeInt(uint64(t.Gas))
-
-	if t.To == nil {
-		e.EncodeString(nil)
-	} else {
-		e.EncodeString(t.To[:])
+// ParseRawTransaction parses a signed, serialized 'raw' transaction
+// (as produced by SignTransaction) and returns the transaction and its
+// signature. The transaction hash and the From address (recovered from
+// the signature) are filled in.
+func ParseRawTransaction(raw []byte) (*Transaction, *Signature, error) {
+	t := new(Transaction)
+	d := rlp.Decoder(raw)
+	if len(raw) > 0 && raw[0] < 0x80 {
+		// legacy transactions are bare RLP lists,
+		// so they can't have a type prefix
+		t.Type = Uint64(raw[0])
+		if t.Type == TxLegacy || t.Type > TxDynamicFee {
+			return nil, nil, fmt.Errorf("unsupported transaction type %d", t.Type)
+		}
+		d = d[1:]
 	}
-
-	e.EncodeString(t.Value.Big().Bytes())
-	e.EncodeString(t.Input)
-
-	return
+	fields, err := d.DecodeList()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !d.Empty() {
+		return nil, nil, rlp.ErrTrailing
+	}
+	v, r, s, err := decodeTransaction(&fields, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !fields.Empty() {
+		return nil, nil, rlp.ErrTrailing
+	}
+	sig, err := t.DecodeSignature(v, r, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.From, err = t.Sender(sig); err != nil {
+		return nil, nil, err
+	}
+	t.Hash = HashBytes(raw)
+	return t, sig, nil
 }
 
-// EncodeSignedTx encodes a transaction with the given signature.
-func (e *rlpEncoder) EncodeSignedTx(t *Transaction, sig *Signature) {
-	var buf rlpEncoder
-	buf.EncodeTransaction(t)
-
-	r, s, v := sig.Parts()
+// decodeTransaction decodes the fields of a signed transaction
+// into t, whose type must already be set, and returns v, r, and s.
+func decodeTransaction(d *rlp.Decoder, t *Transaction) (v, r, s *big.Int, err error) {
+	if t.typed() {
+		id, err := d.DecodeUint()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		t.SetChainID(id)
+	}
+	n, err := d.DecodeUint()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	t.Nonce = Uint64(n)
+	if t.Type == TxDynamicFee {
+		t.MaxPriorityFeePerGas, t.MaxFeePerGas = new(Int), new(Int)
+		if err := decodeInt(d, t.MaxPriorityFeePerGas); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := decodeInt(d, t.MaxFeePerGas); err != nil {
+			return nil, nil, nil, err
+		}
+	} else if err := decodeInt(d, &t.GasPrice); err != nil {
+		return nil, nil, nil, err
+	}
+	if n, err = d.DecodeUint(); err != nil {
+		return nil, nil, nil, err
+	}
+	t.Gas = Uint64(n)
+	to, err := d.DecodeString()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	switch len(to) {
+	case 0:
+	case len(Address{}):
+		t.To = new(Address)
+		copy(t.To[:], to)
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid recipient address length %d", len(to))
+	}
+	if err := decodeInt(d, &t.Value); err != nil {
+		return nil, nil, nil, err
+	}
+	input, err := d.DecodeString()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	t.Input = Data(input)
+	if t.typed() {
+		if t.AccessList, err = decodeAccessList(d); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if v, err = d.DecodeBig(); err != nil {
+		return nil, nil, nil, err
+	}
+	if r, err = d.DecodeBig(); err != nil {
+		return nil, nil, nil, err
+	}
+	if s, err = d.DecodeBig(); err != nil {
+		return nil, nil, nil, err
+	}
+	return v, r, s, nil
+}
 
-	buf.EncodeInt(uint64(v) + 37)
-	buf.EncodeString(r.Bytes())
-	buf.EncodeString(s.Bytes())
+// decodeInt decodes an integer into an Int.
+func decodeInt(d *rlp.Decoder, i *Int) error {
+	b, err := d.DecodeBig()
+	if err != nil {
+		return err
+	}
+	*i = Int(*b)
+	return nil
+}
 
-	e.EncodeList(buf.Bytes())
+// decodeAccessList decodes an access list.
+func decodeAccessList(d *rlp.Decoder) (AccessList, error) {
+	list, err := d.DecodeList()
+	if err != nil {
+		return nil, err
+	}
+	out := AccessList{}
+	for !list.Empty() {
+		tuple, err := list.DecodeList()
+		if err != nil {
+			return nil, err
+		}
+		at := AccessTuple{StorageKeys: []Hash{}}
+		if err := tuple.DecodeFixed(at.Address[:]); err != nil {
+			return nil, err
+		}
+		keys, err := tuple.DecodeList()
+		if err != nil {
+			return nil, err
+		}
+		if !tuple.Empty() {
+			return nil, rlp.ErrTrailing
+		}
+		for !keys.Empty() {
+			var h Hash
+			if err := keys.DecodeFixed(h[:]); err != nil {
+				return nil, err
+			}
+			at.StorageKeys = append(at.StorageKeys, h)
+		}
+		out = append(out, at)
+	}
+	return out, nil
 }
 
 // A Signer is a function capable of signing a hash.
@@ -162,10 +368,13 @@ type Signer func(*Hash) (*Signature, error)
 // SignTransaction produces a signed, serialized 'raw' transaction
 // from the given transaction and signer.
 func SignTransaction(t *Transaction, sign Signer) ([]byte, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
 	hash := t.HashToSign()
 	sig, err := sign(hash)
 	if err != nil {
 		return nil, err
 	}
-	return t.Encode(sig), nil
+	return t.encode(sig)
 }
@@ -5,9 +5,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
+	"math/big"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/newalchemylimited/seth/rlp"
 )
 
 func unhex(t *testing.T, str string) []byte {
@@ -36,22 +39,28 @@ var encodeIntTests = []encodeTest{
 	{input: uint64(0xFFFFFFFF), output: "84FFFFFFFF"},
 }
 
-func runEncTests(t *testing.T, d []encodeTest, f func(e *rlpEncoder, val interface{})) {
+func runEncTests(t *testing.T, d []encodeTest, f func(val interface{}) []byte) {
 	for i, test := range d {
-		res := new(rlpEncoder)
-
-		f(res, test.input)
+		res := f(test.input)
 
-		if !bytes.Equal(res.Bytes(), unhex(t, test.output)) {
+		if !bytes.Equal(res, unhex(t, test.output)) {
 			t.Errorf("test %d: output mismatch:\ngot   %X\nwant  %s\nvalue %#v\ntype  %T",
-				i, res.Bytes(), test.output, test.input, test.input)
+				i, res, test.output, test.input, test.input)
 		}
 	}
 }
 
+func rlpEncode(t *testing.T, val interface{}) []byte {
+	b, err := rlp.Encode(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
 func TestEncodeInt(t *testing.T) {
-	runEncTests(t, encodeIntTests, func(e *rlpEncoder, val interface{}) {
-		e.EncodeInt(val.(uint64))
+	runEncTests(t, encodeIntTests, func(val interface{}) []byte {
+		return rlpEncode(t, val.(uint64))
 	})
 }
 
@@ -92,8 +101,8 @@ var encodeBytesTests = []encodeTest{
 }
 
 func TestEncodeBytes(t *testing.T) {
-	runEncTests(t, encodeBytesTests, func(e *rlpEncoder, val interface{}) {
-		e.EncodeString(val.([]byte))
+	runEncTests(t, encodeBytesTests, func(val interface{}) []byte {
+		return rlpEncode(t, val.([]byte))
 	})
 }
 
@@ -160,7 +169,15 @@ func signedTx(t *testing.T, p string) {
 			Value:    rtx.Value,
 		}
 
-		signedTransaction := sTx{t: &tx, s: NewSignature(rtx.R.Big(), rtx.S.Big(), int(rtx.V)-37)}
+		sig, err := tx.DecodeSignature(big.NewInt(int64(rtx.V)), rtx.R.Big(), rtx.S.Big())
+		if err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		if tx.chainID() != 1 {
+			t.Fatalf("%s: v=%d decoded as chain ID %d", file, rtx.V, tx.chainID())
+		}
+
+		signedTransaction := sTx{t: &tx, s: sig}
 		signedTxTests = append(signedTxTests, encodeTest{input: signedTransaction, output: rtx.Hex})
 		unsignedTxTests = append(unsignedTxTests, encodeTest{input: tx, output: "3031"})
 	}
@@ -169,7 +186,7 @@ func signedTx(t *testing.T, p string) {
 func TestSignedTxMarshal(t *testing.T) {
 	signedTx(t, "./_test/txs/*.json")
 
-	runEncTests(t, signedTxTests, func(e *rlpEncoder, val interface{}) {
+	runEncTests(t, signedTxTests, func(val interface{}) []byte {
 		tx := val.(sTx)
 		res, err := SignTransaction(tx.t, func(*Hash) (*Signature, error) {
 			return tx.s, nil
@@ -178,7 +195,232 @@ func TestSignedTxMarshal(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		e.Write(res)
-		return
+		return res
 	})
 }
+
+func TestTypedTx(t *testing.T) {
+	to, _ := ParseAddress("0x3535353535353535353535353535353535353535")
+	from, _ := ParseAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	al := AccessList{{Address: *to, StorageKeys: []Hash{{31: 1}}}}
+	sig := func(r, s string, v int) Signer {
+		return func(*Hash) (*Signature, error) {
+			var rb, sb Int
+			if err := rb.FromString("0x" + r); err != nil {
+				return nil, err
+			}
+			if err := sb.FromString("0x" + s); err != nil {
+				return nil, err
+			}
+			return NewSignature(rb.Big(), sb.Big(), v), nil
+		}
+	}
+
+	tests := []struct {
+		tx   Transaction
+		hash string
+		sign Signer
+		raw  string
+	}{
+		{
+			tx: Transaction{
+				Type:       TxAccessList,
+				Nonce:      9,
+				GasPrice:   *NewInt(20e9),
+				Gas:        21000,
+				To:         to,
+				Value:      *NewInt(1e18),
+				AccessList: al,
+			},
+			hash: "a4441968a0519a1bb79f9a4c352397415c411f98ff1e3bead9dea1f8813aaa37",
+			sign: sig("b68d5fa6f4ab6a5dcff8fa89ae74763a55fb09d44c26920938e639225fcb2c0e", "247251ee01472c0acc767f4914bf58c6597ee1217de48b577458214c53f29fb4", 1),
+			raw:  "01f8a701098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080f838f7943535353535353535353535353535353535353535e1a0000000000000000000000000000000000000000000000000000000000000000101a0b68d5fa6f4ab6a5dcff8fa89ae74763a55fb09d44c26920938e639225fcb2c0ea0247251ee01472c0acc767f4914bf58c6597ee1217de48b577458214c53f29fb4",
+		},
+		{
+			tx: Transaction{
+				Type:                 TxDynamicFee,
+				Nonce:                9,
+				MaxFeePerGas:         NewInt(20e9),
+				MaxPriorityFeePerGas: NewInt(1e9),
+				Gas:                  21000,
+				To:                   to,
+				Value:                *NewInt(1e18),
+				Input:                Data{1, 2},
+				AccessList:           al,
+			},
+			hash: "e427b14b1ceb4e55e66ed6bac0338a92d82a1ecb06fd6613aeb939ba8b37b613",
+			sign: sig("b849e2a38aa3e5e94a75bc2bdf4838140324c99216cee35f9fb3b42d46ed03bf", "39883e9c9695a48703c2de991682bd9a2a5c55a9cf481d16fe0c9c5b399da1b6", 0),
+			raw:  "02f8ae0109843b9aca008504a817c800825208943535353535353535353535353535353535353535880de0b6b3a7640000820102f838f7943535353535353535353535353535353535353535e1a0000000000000000000000000000000000000000000000000000000000000000180a0b849e2a38aa3e5e94a75bc2bdf4838140324c99216cee35f9fb3b42d46ed03bfa039883e9c9695a48703c2de991682bd9a2a5c55a9cf481d16fe0c9c5b399da1b6",
+		},
+	}
+	for i := range tests {
+		tx := &tests[i].tx
+		if h := hex.EncodeToString(tx.HashToSign()[:]); h != tests[i].hash {
+			t.Errorf("type %d: signing hash %s; want %s", tx.Type, h, tests[i].hash)
+		}
+		raw, err := SignTransaction(tx, tests[i].sign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := hex.EncodeToString(raw); h != tests[i].raw {
+			t.Errorf("type %d: got  %s\nwant %s", tx.Type, h, tests[i].raw)
+		}
+		ptx, psig, err := ParseRawTransaction(raw)
+		if err != nil {
+			t.Fatalf("type %d: %s", tx.Type, err)
+		}
+		if ptx.Type != tx.Type || ptx.From == nil || *ptx.From != *from {
+			t.Errorf("type %d: parsed %+v", tx.Type, ptx)
+		}
+		if !bytes.Equal(ptx.Encode(psig), raw) {
+			t.Errorf("type %d: parsed transaction doesn't re-encode", tx.Type)
+		}
+
+		buf, err := json.Marshal(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out Transaction
+		if err := json.Unmarshal(buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out.Encode(nil), tx.Encode(nil)) || out.Type != tx.Type {
+			t.Errorf("type %d: JSON round trip: %s", tx.Type, buf)
+		}
+	}
+
+	opts := &CallOpts{To: to, Gas: NewInt(21000), GasPrice: NewInt(1)}
+	if typ := opts.Transaction().Type; typ != TxLegacy {
+		t.Errorf("gasPrice: got type %d", typ)
+	}
+	opts.AccessList = al
+	if typ := opts.Transaction().Type; typ != TxAccessList {
+		t.Errorf("accessList: got type %d", typ)
+	}
+	opts.GasPrice, opts.MaxFeePerGas = nil, NewInt(1)
+	if typ := opts.Transaction().Type; typ != TxDynamicFee {
+		t.Errorf("maxFeePerGas: got type %d", typ)
+	}
+}
+
+func TestParseRawTransaction(t *testing.T) {
+	files, err := filepath.Glob("./_test/txs/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		js, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rtx jstx
+		if err := json.Unmarshal(js, &rtx); err != nil {
+			t.Fatal(err)
+		}
+		raw := unhex(t, rtx.Hex)
+		tx, sig, err := ParseRawTransaction(raw)
+		if err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		if hex.EncodeToString(tx.Hash[:]) != rtx.TxHash {
+			t.Errorf("%s: hash %s", file, &tx.Hash)
+		}
+		if uint64(tx.Nonce) != rtx.Nonce || int64(tx.Gas) != rtx.StartGas ||
+			tx.GasPrice.Cmp(&rtx.GasPrice) != 0 || tx.Value.Cmp(&rtx.Value) != 0 ||
+			hex.EncodeToString(tx.Input) != rtx.Data || tx.chainID() != 1 {
+			t.Errorf("%s: parsed %+v", file, tx)
+		}
+		if to, err := ParseAddress(rtx.To); err == nil && (tx.To == nil || *tx.To != *to) {
+			t.Errorf("%s: to %v", file, tx.To)
+		}
+		if r, s, _ := sig.Parts(); r.Cmp(rtx.R.Big()) != 0 || s.Cmp(rtx.S.Big()) != 0 {
+			t.Errorf("%s: signature %s", file, sig)
+		}
+		if tx.From == nil {
+			t.Errorf("%s: no sender", file)
+		}
+		if !bytes.Equal(tx.Encode(sig), raw) {
+			t.Errorf("%s: parsed transaction doesn't re-encode", file)
+		}
+		// legacy transactions can't have a type prefix
+		if _, _, err := ParseRawTransaction(append([]byte{0}, raw...)); err == nil {
+			t.Errorf("%s: parsed with a type 0 prefix", file)
+		}
+	}
+}
+
+func TestTxChainID(t *testing.T) {
+	key := GenPrivateKey()
+	to, _ := ParseAddress("0x3535353535353535353535353535353535353535")
+	for _, id := range []uint64{0, 1, 5, 1337} {
+		tx := &Transaction{Nonce: 1, GasPrice: *NewInt(1), Gas: 21000, To: to}
+		tx.SetChainID(id)
+		sig := key.Sign(tx.HashToSign())
+		r, s, recid := sig.Parts()
+		v := uint64(recid) + 27
+		if id != 0 {
+			v = uint64(recid) + 35 + 2*id
+		}
+
+		// the transaction as seen by someone who only has v, r, and s
+		dec := &Transaction{Nonce: 1, GasPrice: *NewInt(1), Gas: 21000, To: to}
+		dsig, err := dec.DecodeSignature(new(big.Int).SetUint64(v), &r, &s)
+		if err != nil {
+			t.Fatalf("chain %d: %s", id, err)
+		}
+		if dec.chainID() != id {
+			t.Errorf("chain %d: v=%d decoded as chain ID %d", id, v, dec.chainID())
+		}
+		if from, err := dec.Sender(dsig); err != nil {
+			t.Errorf("chain %d: %s", id, err)
+		} else if *from != *key.Address() {
+			t.Errorf("chain %d: recovered %s; want %s", id, from, key.Address())
+		}
+		if !bytes.Equal(dec.Encode(dsig), tx.Encode(sig)) {
+			t.Errorf("chain %d: encodings differ", id)
+		}
+	}
+	if _, err := new(Transaction).DecodeSignature(big.NewInt(30), big.NewInt(1), big.NewInt(1)); err == nil {
+		t.Error("expected an error for v=30")
+	}
+}
+
+func TestSignNegative(t *testing.T) {
+	key := GenPrivateKey()
+	to := Address{1}
+	tx := &Transaction{Gas: 21000, To: &to, Value: *NewInt(-1)}
+	if _, err := SignTransaction(tx, key.Signer()); err == nil {
+		t.Error("expected an error for a negative value")
+	}
+
+	// Sender.Call reports the error rather than panicking
+	c := NewClientTransport(newTestNode())
+	c.SetChainID(1)
+	s := NewSender(c, key.Address())
+	s.Signer = key.Signer()
+	nonce := Uint64(0)
+	_, err := s.Call(&CallOpts{To: &to, Gas: NewInt(21000), Nonce: &nonce, Value: NewInt(-1)})
+	if err == nil {
+		t.Error("expected an error for a negative value")
+	}
+}
+
+func TestClientChainID(t *testing.T) {
+	tp := newTestNode()
+	tp.handle("eth_chainId", func([]json.RawMessage) (interface{}, error) {
+		return Uint64(42), nil
+	})
+	c := NewClientTransport(tp)
+	for i := 0; i < 2; i++ {
+		if id, err := c.ChainID(); err != nil || id != 42 {
+			t.Fatalf("got %d, %v", id, err)
+		}
+	}
+	if n := tp.count("eth_chainId"); n != 1 {
+		t.Errorf("eth_chainId requested %d times", n)
+	}
+	c.SetChainID(5)
+	if id, _ := c.ChainID(); id != 5 || tp.count("eth_chainId") != 1 {
+		t.Errorf("SetChainID: got %d after %d calls", id, tp.count("eth_chainId"))
+	}
+}
@@ -0,0 +1,375 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TypedDataField is a member of an EIP-712 struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is EIP-712 typed structured data, as it
+// appears in the JSON argument of eth_signTypedData.
+//
+// Struct values in Domain and Message are represented as
+// maps from field names to values, and arrays are represented
+// as slices. Integers may be given as any of the Go integer
+// types, *big.Int, Int, json.Number, or as decimal or hex
+// strings; addresses may be given as an Address or a hex
+// string; and bytes and bytesN values may be given as byte
+// slices or arrays or hex strings.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// domainFields are the fields that may appear in
+// the EIP712Domain type, in their canonical order
+var domainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+// ParseTypedData parses the JSON representation
+// of EIP-712 typed data and validates its types.
+func ParseTypedData(b []byte) (*TypedData, error) {
+	td := new(TypedData)
+	if err := json.Unmarshal(b, td); err != nil {
+		return nil, err
+	}
+	if err := td.validate(); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// Numbers in the domain and message are decoded
+// as json.Number so that they don't lose precision.
+func (d *TypedData) UnmarshalJSON(b []byte) error {
+	type typedData TypedData
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode((*typedData)(d))
+}
+
+// validate checks that each of the struct
+// types only refers to known types
+func (d *TypedData) validate() error {
+	if _, ok := d.Types[d.PrimaryType]; !ok {
+		return fmt.Errorf("eip712: primary type %q is not defined", d.PrimaryType)
+	}
+	for name, fields := range d.Types {
+		if name == "" || strings.ContainsAny(name, "()[], ") {
+			return fmt.Errorf("eip712: bad type name %q", name)
+		}
+		seen := make(map[string]bool, len(fields))
+		for _, f := range fields {
+			if seen[f.Name] {
+				return fmt.Errorf("eip712: type %s has more than one field named %q", name, f.Name)
+			}
+			seen[f.Name] = true
+			base := f.Type
+			if lb := strings.IndexByte(base, '['); lb != -1 {
+				base = base[:lb]
+			}
+			if _, ok := d.Types[base]; ok {
+				continue
+			}
+			if _, err := parseType(f.Type, nil); err != nil || base == "tuple" || strings.HasPrefix(base, "(") {
+				return fmt.Errorf("eip712: %s.%s has unknown type %q", name, f.Name, f.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// fields returns the fields of the given struct type
+func (d *TypedData) fields(name string) ([]TypedDataField, bool) {
+	if f, ok := d.Types[name]; ok {
+		return f, true
+	}
+	if name != "EIP712Domain" {
+		return nil, false
+	}
+	// when the domain type isn't given explicitly,
+	// it is made up of the fields present in the domain
+	var f []TypedDataField
+	for _, df := range domainFields {
+		if _, ok := d.Domain[df.Name]; ok {
+			f = append(f, df)
+		}
+	}
+	return f, true
+}
+
+// deps adds the struct types that typ refers to to set
+func (d *TypedData) deps(typ string, set map[string]bool) {
+	if lb := strings.IndexByte(typ, '['); lb != -1 {
+		typ = typ[:lb]
+	}
+	fields, ok := d.fields(typ)
+	if !ok || set[typ] {
+		return
+	}
+	set[typ] = true
+	for _, f := range fields {
+		d.deps(f.Type, set)
+	}
+}
+
+// EncodeType returns the encoding of the named struct type,
+// which is its signature followed by the signatures of each
+// of the struct types it refers to in alphabetical order,
+// as in "Mail(Person from,Person to,string contents)Person(string name,address wallet)".
+func (d *TypedData) EncodeType(name string) (string, error) {
+	if _, ok := d.fields(name); !ok {
+		return "", fmt.Errorf("eip712: unknown type %q", name)
+	}
+	set := make(map[string]bool)
+	d.deps(name, set)
+	delete(set, name)
+	types := []string{name}
+	for t := range set {
+		types = append(types, t)
+	}
+	sort.Strings(types[1:])
+
+	var buf strings.Builder
+	for _, t := range types {
+		fields, _ := d.fields(t)
+		buf.WriteString(t)
+		buf.WriteByte('(')
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(f.Type)
+			buf.WriteByte(' ')
+			buf.WriteString(f.Name)
+		}
+		buf.WriteByte(')')
+	}
+	return buf.String(), nil
+}
+
+// TypeHash returns the hash of the encoding of the named type.
+func (d *TypedData) TypeHash(name string) (Hash, error) {
+	enc, err := d.EncodeType(name)
+	if err != nil {
+		return Hash{}, err
+	}
+	return HashString(enc), nil
+}
+
+// HashStruct returns the EIP-712 hash of a value of the named struct type.
+func (d *TypedData) HashStruct(name string, data map[string]interface{}) (Hash, error) {
+	b, err := d.encodeData(name, data, name)
+	if err != nil {
+		return Hash{}, err
+	}
+	return HashBytes(b), nil
+}
+
+// DomainSeparator returns the hash of the domain.
+func (d *TypedData) DomainSeparator() (Hash, error) {
+	return d.HashStruct("EIP712Domain", d.Domain)
+}
+
+// HashTypedData returns the hash of typed data that
+// is signed to produce an EIP-712 signature, which is
+//
+//	keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func HashTypedData(d *TypedData) (*Hash, error) {
+	buf := []byte{0x19, 0x01}
+	sep, err := d.DomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, sep[:]...)
+	// when the domain itself is being signed,
+	// the message hash is omitted
+	if d.PrimaryType != "EIP712Domain" {
+		msg, err := d.HashStruct(d.PrimaryType, d.Message)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, msg[:]...)
+	}
+	h := HashBytes(buf)
+	return &h, nil
+}
+
+// SignTypedData signs typed data with the given signer.
+func SignTypedData(d *TypedData, sign Signer) (*Signature, error) {
+	h, err := HashTypedData(d)
+	if err != nil {
+		return nil, err
+	}
+	return sign(h)
+}
+
+// encodeData encodes the type hash and members of a struct
+func (d *TypedData) encodeData(name string, data map[string]interface{}, path string) ([]byte, error) {
+	fields, ok := d.fields(name)
+	if !ok {
+		return nil, fmt.Errorf("eip712: unknown type %q", name)
+	}
+	th, err := d.TypeHash(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > len(fields) {
+		for k := range data {
+			if !hasField(fields, k) {
+				return nil, fmt.Errorf("eip712: %s: unknown field %q", path, k)
+			}
+		}
+	}
+	buf := make([]byte, 0, 32*(len(fields)+1))
+	buf = append(buf, th[:]...)
+	for _, f := range fields {
+		v, ok := data[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("eip712: %s: missing field %q", path, f.Name)
+		}
+		w, err := d.encodeValue(f.Type, v, path+"."+f.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, w[:]...)
+	}
+	return buf, nil
+}
+
+func hasField(fields []TypedDataField, name string) bool {
+	for i := range fields {
+		if fields[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeValue returns the 32-byte encoding of a struct member
+func (d *TypedData) encodeValue(typ string, v interface{}, path string) (Hash, error) {
+	// arrays are encoded as the hash of
+	// the concatenated encodings of their elements
+	if strings.HasSuffix(typ, "]") {
+		lb := strings.LastIndexByte(typ, '[')
+		elem, dim := typ[:lb], typ[lb+1:len(typ)-1]
+		rv := reflect.ValueOf(v)
+		if v == nil || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+			return Hash{}, fmt.Errorf("eip712: %s: cannot use %T as %s", path, v, typ)
+		}
+		if dim != "" {
+			if n, _ := strconv.Atoi(dim); n != rv.Len() {
+				return Hash{}, fmt.Errorf("eip712: %s: %d elements for %s", path, rv.Len(), typ)
+			}
+		}
+		buf := make([]byte, 0, 32*rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			w, err := d.encodeValue(elem, rv.Index(i).Interface(), path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return Hash{}, err
+			}
+			buf = append(buf, w[:]...)
+		}
+		return HashBytes(buf), nil
+	}
+
+	// structs are encoded as their hashStruct
+	if _, ok := d.fields(typ); ok {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return Hash{}, fmt.Errorf("eip712: %s: cannot use %T as %s", path, v, typ)
+		}
+		b, err := d.encodeData(typ, m, path)
+		if err != nil {
+			return Hash{}, err
+		}
+		return HashBytes(b), nil
+	}
+
+	t, err := parseType(typ, nil)
+	if err != nil {
+		return Hash{}, fmt.Errorf("eip712: %s: %s", path, err)
+	}
+	v, err = typedValue(t, v)
+	if err != nil {
+		return Hash{}, fmt.Errorf("eip712: %s: %s", path, err)
+	}
+	switch t.kind {
+	case abiBytes, abiString:
+		// dynamic values are encoded as their hash
+		b, ok := bytesOf(reflect.ValueOf(v))
+		if !ok || (t.kind == abiString) != (reflect.ValueOf(v).Kind() == reflect.String) {
+			return Hash{}, fmt.Errorf("eip712: %s: cannot use %T as %s", path, v, t)
+		}
+		return HashBytes(b), nil
+	case abiTuple:
+		return Hash{}, fmt.Errorf("eip712: %s: tuple types are not allowed", path)
+	}
+	b, err := t.encode(nil, reflect.ValueOf(v))
+	if err != nil {
+		return Hash{}, fmt.Errorf("eip712: %s: %s", path, err)
+	}
+	var h Hash
+	copy(h[:], b)
+	return h, nil
+}
+
+// typedValue converts the JSON representation of
+// an atomic value to a value that can be ABI-encoded
+func typedValue(t *abiType, v interface{}) (interface{}, error) {
+	switch t.kind {
+	case abiUint, abiInt:
+		var s string
+		switch v := v.(type) {
+		case json.Number:
+			s = string(v)
+		case string:
+			s = v
+		case float64:
+			if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+				return nil, fmt.Errorf("%v is not an exact integer", v)
+			}
+			return big.NewInt(int64(v)), nil
+		default:
+			return v, nil
+		}
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("cannot parse %q as %s", s, t)
+		}
+		return n, nil
+	case abiAddress:
+		if s, ok := v.(string); ok {
+			return ParseAddress(s)
+		}
+	case abiBool:
+		if s, ok := v.(string); ok {
+			return strconv.ParseBool(s)
+		}
+	case abiFixedBytes, abiBytes:
+		if s, ok := v.(string); ok {
+			return hexparse([]byte(s))
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,186 @@
+package seth
+
+import (
+	"math/big"
+	"testing"
+)
+
+// the example from EIP-712
+const mailJSON = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func hashOf(t *testing.T, s string) Hash {
+	h, err := ParseHash(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *h
+}
+
+func TestTypedData(t *testing.T) {
+	td, err := ParseTypedData([]byte(mailJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := td.EncodeType("Mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc != "Mail(Person from,Person to,string contents)Person(string name,address wallet)" {
+		t.Errorf("encodeType: got %q", enc)
+	}
+	if th, _ := td.TypeHash("Mail"); th != hashOf(t, "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2") {
+		t.Errorf("typeHash: got %s", &th)
+	}
+	if sep, err := td.DomainSeparator(); err != nil || sep != hashOf(t, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f") {
+		t.Errorf("domain separator: got %s (%v)", &sep, err)
+	}
+	if h, err := td.HashStruct("Mail", td.Message); err != nil || h != hashOf(t, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e") {
+		t.Errorf("hashStruct: got %s (%v)", &h, err)
+	}
+	h, err := HashTypedData(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *h != hashOf(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2") {
+		t.Errorf("HashTypedData: got %s", h)
+	}
+
+	cow := HashString("cow")
+	key := NewPrivateKey(new(big.Int).SetBytes(cow[:]))
+	sig, err := SignTypedData(td, key.Signer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := sig.Recover(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := pub.Address().String(); addr != "0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826" {
+		t.Errorf("signature recovers to %s", addr)
+	}
+
+	// the domain type may be omitted
+	delete(td.Types, "EIP712Domain")
+	if h2, err := HashTypedData(td); err != nil || *h2 != *h {
+		t.Errorf("implicit domain: got %v (%v)", h2, err)
+	}
+}
+
+func TestTypedDataArrays(t *testing.T) {
+	td := &TypedData{
+		Types: map[string][]TypedDataField{
+			"Person": {{Name: "name", Type: "string"}, {Name: "wallets", Type: "address[]"}},
+			"Group":  {{Name: "members", Type: "Person[2]"}, {Name: "tags", Type: "bytes4[]"}, {Name: "data", Type: "bytes"}},
+		},
+		PrimaryType: "Group",
+		Domain:      map[string]interface{}{"chainId": big.NewInt(5)},
+		Message: map[string]interface{}{
+			"members": []interface{}{
+				map[string]interface{}{"name": "a", "wallets": []interface{}{"0x0000000000000000000000000000000000000001"}},
+				map[string]interface{}{"name": "b", "wallets": []Address{{19: 2}, {19: 3}}},
+			},
+			"tags": []interface{}{"0x01020304"},
+			"data": []byte{0xff},
+		},
+	}
+	if err := td.validate(); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := td.EncodeType("Group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc != "Group(Person[2] members,bytes4[] tags,bytes data)Person(string name,address[] wallets)" {
+		t.Errorf("encodeType: got %q", enc)
+	}
+
+	// compute the hash by hand
+	cat := func(hs ...Hash) []byte {
+		var b []byte
+		for i := range hs {
+			b = append(b, hs[i][:]...)
+		}
+		return b
+	}
+	word := func(b ...byte) (h Hash) {
+		copy(h[32-len(b):], b)
+		return h
+	}
+	personType := HashString("Person(string name,address[] wallets)")
+	a := HashBytes(cat(personType, HashString("a"), HashBytes(cat(word(1)))))
+	b := HashBytes(cat(personType, HashString("b"), HashBytes(cat(word(2), word(3)))))
+	tag := Hash{0: 1, 1: 2, 2: 3, 3: 4}
+	want := HashBytes(cat(HashString(enc), HashBytes(cat(a, b)), HashBytes(cat(tag)), HashBytes([]byte{0xff})))
+	if got, err := td.HashStruct("Group", td.Message); err != nil || got != want {
+		t.Errorf("hashStruct: got %s (%v); want %s", &got, err, &want)
+	}
+
+	// wrong array length
+	td.Message["members"] = td.Message["members"].([]interface{})[:1]
+	if _, err := td.HashStruct("Group", td.Message); err == nil {
+		t.Error("expected an error for a short fixed-size array")
+	}
+}
+
+func TestTypedDataErrors(t *testing.T) {
+	bad := []string{
+		`{"types": {"A": [{"name": "x", "type": "uint256"}]}, "primaryType": "B"}`,
+		`{"types": {"A": [{"name": "x", "type": "Missing"}]}, "primaryType": "A"}`,
+		`{"types": {"A": [{"name": "x", "type": "uint7"}]}, "primaryType": "A"}`,
+		`{"types": {"A": [{"name": "x", "type": "bool"}, {"name": "x", "type": "bool"}]}, "primaryType": "A"}`,
+	}
+	for _, js := range bad {
+		if _, err := ParseTypedData([]byte(js)); err == nil {
+			t.Errorf("expected an error parsing %s", js)
+		}
+	}
+
+	td, err := ParseTypedData([]byte(`{"types": {"A": [{"name": "x", "type": "uint8"}]}, "primaryType": "A", "domain": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []map[string]interface{}{
+		{},                     // missing field
+		{"x": 1, "y": 2},       // extra field
+		{"x": 256},             // out of range
+		{"x": "0x100"},         // out of range
+		{"x": 1.5},             // not an integer
+		{"x": "twelve"},        // not a number
+		{"x": []interface{}{}}, // not a number
+	} {
+		td.Message = msg
+		if _, err := HashTypedData(td); err == nil {
+			t.Errorf("expected an error hashing %v", msg)
+		}
+	}
+}
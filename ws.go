@@ -0,0 +1,255 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrTransportClosed is returned for requests that
+// are outstanding when a WSTransport is closed.
+var ErrTransportClosed = errors.New("seth: transport closed")
+
+// A WSTransport is a client transport for making requests over
+// a WebSocket connection. Like RPCTransport, it dials lazily,
+// and if the connection is lost, it redials on the next request.
+// Unlike the other transports, it supports subscriptions (see
+// (*Client).Subscribe). Subscriptions do not survive the loss
+// of the connection; they fail instead.
+type WSTransport struct {
+	URL string // ws:// or wss:// URL of the node
+
+	lock    sync.Mutex
+	conn    *websocket.Conn
+	pending map[int]*pending
+	subreqs map[int]*Subscription // outstanding eth_subscribe requests
+	subs    map[string]*Subscription
+}
+
+// NewWSClient creates a client that talks to
+// a node over a WebSocket connection.
+func NewWSClient(url string) *Client {
+	return NewClientTransport(&WSTransport{URL: url})
+}
+
+// wsMessage is either a response to
+// a request or a subscription notification
+type wsMessage struct {
+	RPCResponse
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type notification struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+func (t *WSTransport) background(conn *websocket.Conn) {
+	for {
+		_, buf, err := conn.ReadMessage()
+		if err != nil {
+			t.lock.Lock()
+			// only abort if we haven't already reconnected
+			if t.conn == conn {
+				log.Printf("seth: websocket read: %s", err)
+				t.abort(err)
+			}
+			t.lock.Unlock()
+			return
+		}
+		var batch []wsMessage
+		if isBatch(buf) {
+			err = json.Unmarshal(buf, &batch)
+		} else {
+			batch = make([]wsMessage, 1)
+			err = json.Unmarshal(buf, &batch[0])
+		}
+		if err != nil {
+			log.Printf("seth: bad websocket message: %s", err)
+			continue
+		}
+		if !isBatch(buf) {
+			t.lock.Lock()
+			failed := failBatches(t.pending, &batch[0].RPCResponse)
+			t.lock.Unlock()
+			if failed {
+				continue
+			}
+		}
+		var group []int
+		for i := range batch {
+			if p := t.dispatch(&batch[i]); p != nil {
+				group = p.batch
+			}
+		}
+		if group != nil {
+			t.lock.Lock()
+			finishBatch(t.pending, group)
+			t.lock.Unlock()
+		}
+	}
+}
+
+// dispatch delivers a notification to its subscription
+// or a response to its pending request
+func (t *WSTransport) dispatch(msg *wsMessage) *pending {
+	if msg.Method == "eth_subscription" {
+		var n notification
+		if err := json.Unmarshal(msg.Params, &n); err != nil {
+			log.Printf("seth: bad notification: %s", err)
+			return nil
+		}
+		t.lock.Lock()
+		s := t.subs[n.Subscription]
+		t.lock.Unlock()
+		if s != nil {
+			s.push(n.Result)
+		}
+		return nil
+	}
+	t.lock.Lock()
+	p := t.pending[msg.ID]
+	delete(t.pending, msg.ID)
+	// register subscriptions before reading any
+	// more messages so that no notifications are lost
+	if s := t.subreqs[msg.ID]; s != nil {
+		delete(t.subreqs, msg.ID)
+		var id string
+		if json.Unmarshal(msg.Result, &id) == nil && id != "" {
+			s.id = id
+			t.subs[id] = s
+		}
+	}
+	t.lock.Unlock()
+	if p == nil {
+		log.Printf("spurious response ID %d", msg.ID)
+		return nil
+	}
+	*p.res = msg.RPCResponse
+	close(p.notify)
+	return p
+}
+
+// abort fails all outstanding requests
+// and subscriptions and closes the connection
+func (t *WSTransport) abort(err error) {
+	for id, p := range t.pending {
+		p.err = err
+		close(p.notify)
+		delete(t.pending, id)
+	}
+	for id, s := range t.subs {
+		s.fail(err)
+		delete(t.subs, id)
+	}
+	for id, s := range t.subreqs {
+		s.fail(err)
+		delete(t.subreqs, id)
+	}
+	t.conn.Close()
+	t.conn = nil
+}
+
+func (t *WSTransport) reconnect() error {
+	conn, _, err := websocket.DefaultDialer.Dial(t.URL, nil)
+	if err != nil {
+		return err
+	}
+	if t.pending == nil {
+		t.pending = make(map[int]*pending)
+		t.subreqs = make(map[int]*Subscription)
+		t.subs = make(map[string]*Subscription)
+	}
+	t.conn = conn
+	go t.background(conn)
+	return nil
+}
+
+// Execute implements Transport.
+func (t *WSTransport) Execute(req *RPCRequest, res *RPCResponse) error {
+	return t.execute(context.Background(), req, []int{req.ID}, []*RPCResponse{res}, nil)
+}
+
+// ExecuteContext implements ContextTransport.
+func (t *WSTransport) ExecuteContext(ctx context.Context, req *RPCRequest, res *RPCResponse) error {
+	return t.execute(ctx, req, []int{req.ID}, []*RPCResponse{res}, nil)
+}
+
+// ExecuteBatch implements BatchTransport.
+func (t *WSTransport) ExecuteBatch(reqs []RPCRequest, res []RPCResponse) error {
+	return t.ExecuteBatchContext(context.Background(), reqs, res)
+}
+
+// ExecuteBatchContext implements BatchContextTransport.
+func (t *WSTransport) ExecuteBatchContext(ctx context.Context, reqs []RPCRequest, res []RPCResponse) error {
+	ids := make([]int, len(reqs))
+	out := make([]*RPCResponse, len(reqs))
+	for i := range reqs {
+		ids[i] = reqs[i].ID
+		out[i] = &res[i]
+	}
+	return t.execute(ctx, reqs, ids, out, nil)
+}
+
+func (t *WSTransport) subscribe(req *RPCRequest, res *RPCResponse, s *Subscription) error {
+	return t.execute(context.Background(), req, []int{req.ID}, []*RPCResponse{res}, s)
+}
+
+func (t *WSTransport) unsubscribe(s *Subscription) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.subs[s.id] != s {
+		return false
+	}
+	delete(t.subs, s.id)
+	return true
+}
+
+// execute writes v, which is a request or a batch of requests
+// with the given IDs, and waits for all of their responses or
+// for ctx to be done. If s is non-nil, v is an eth_subscribe
+// request for s.
+func (t *WSTransport) execute(ctx context.Context, v interface{}, ids []int, res []*RPCResponse, s *Subscription) error {
+	var batch []int
+	if _, ok := v.([]RPCRequest); ok {
+		batch = ids
+	}
+	t.lock.Lock()
+	if t.conn == nil {
+		if err := t.reconnect(); err != nil {
+			t.lock.Unlock()
+			return err
+		}
+	}
+	ps := make([]*pending, len(ids))
+	for i, id := range ids {
+		ps[i] = &pending{notify: make(chan struct{}), res: res[i], batch: batch}
+		t.pending[id] = ps[i]
+	}
+	if s != nil {
+		t.subreqs[ids[0]] = s
+	}
+	if err := t.conn.WriteJSON(v); err != nil {
+		t.abort(err)
+	}
+	pending := t.pending
+	t.lock.Unlock()
+	return wait(ctx, &t.lock, pending, ids, ps)
+}
+
+// Close closes the connection, if there is one. Outstanding
+// requests and subscriptions fail with ErrTransportClosed.
+// A subsequent request will redial.
+func (t *WSTransport) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.conn != nil {
+		t.abort(ErrTransportClosed)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package seth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsServer is a fake node that serves
+// a few methods over a websocket
+type wsServer struct {
+	t      *testing.T
+	unsubs chan string
+}
+
+func (s *wsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var up websocket.Upgrader
+	conn, err := up.Upgrade(w, r, nil)
+	if err != nil {
+		s.t.Error(err)
+		return
+	}
+	defer conn.Close()
+	for {
+		var req RPCRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		res := RPCResponse{ID: req.ID, Version: "2.0"}
+		var notes []interface{}
+		switch req.Method {
+		case "eth_blockNumber":
+			res.Result = json.RawMessage(`"0x10"`)
+		case "eth_subscribe":
+			res.Result = json.RawMessage(`"0xabc"`)
+			var kind string
+			json.Unmarshal(req.Params[0], &kind)
+			for i := 1; i <= 3; i++ {
+				if kind == "logs" {
+					idx := Uint64(i)
+					notes = append(notes, &Log{LogIndex: &idx})
+				} else {
+					notes = append(notes, i)
+				}
+			}
+		case "eth_unsubscribe":
+			var id string
+			json.Unmarshal(req.Params[0], &id)
+			s.unsubs <- id
+			res.Result = json.RawMessage(`true`)
+		case "test_drop":
+			return
+		default:
+			res.Error.Code = -32601
+			res.Error.Message = "unsupported method"
+		}
+		if err := conn.WriteJSON(&res); err != nil {
+			return
+		}
+		for _, n := range notes {
+			conn.WriteJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "eth_subscription",
+				"params":  map[string]interface{}{"subscription": "0xabc", "result": n},
+			})
+		}
+	}
+}
+
+func wsURL(hs *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(hs.URL, "http")
+}
+
+func TestWSTransport(t *testing.T) {
+	srv := &wsServer{t: t, unsubs: make(chan string, 1)}
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	c := NewWSClient(wsURL(hs))
+
+	bn, err := c.BlockNumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bn != 16 {
+		t.Errorf("block number %d", bn)
+	}
+	if err := c.Do("eth_bogus", nil, nil); err == nil {
+		t.Error("expected an error from an unsupported method")
+	}
+
+	next := func(s *Subscription) (json.RawMessage, bool) {
+		t.Helper()
+		select {
+		case msg, ok := <-s.Out():
+			return msg, ok
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a notification")
+		}
+		return nil, false
+	}
+
+	s, err := c.Subscribe("newHeads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID() != "0xabc" {
+		t.Errorf("subscription id %q", s.ID())
+	}
+	for _, want := range []string{"1", "2", "3"} {
+		if msg, _ := next(s); string(msg) != want {
+			t.Errorf("got notification %s; want %s", msg, want)
+		}
+	}
+	s.Close()
+	if _, ok := next(s); ok {
+		t.Error("expected the output channel to be closed")
+	}
+	if id := <-srv.unsubs; id != "0xabc" {
+		t.Errorf("unsubscribed from %q", id)
+	}
+	if s.Err() != nil {
+		t.Errorf("unexpected error %v", s.Err())
+	}
+
+	// losing the connection fails subscriptions,
+	// but new requests reconnect
+	s, err = c.Subscribe("newHeads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Do("test_drop", nil, nil); err == nil {
+		t.Error("expected an error when the connection dropped")
+	}
+	for {
+		if _, ok := next(s); !ok {
+			break
+		}
+	}
+	if s.Err() == nil {
+		t.Error("expected an error after the connection dropped")
+	}
+	s.Close()
+	select {
+	case id := <-srv.unsubs:
+		t.Errorf("unexpected unsubscribe from %q", id)
+	default:
+	}
+	if _, err := c.BlockNumber(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewHTTPClient(hs.URL).Subscribe("newHeads"); err != ErrNoSubscriptions {
+		t.Errorf("expected ErrNoSubscriptions; got %v", err)
+	}
+}